@@ -6,10 +6,13 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
 
 	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+	"j4k.co/cgm/mat4"
 )
 
 var dirs = filepath.SplitList(os.Getenv("GOPATH"))
@@ -21,6 +24,12 @@ func init() {
 	}
 }
 
+// SetRoot makes dir the only directory searched for assets, instead of
+// the assets directory of this repository in each $GOPATH entry.
+func SetRoot(dir string) {
+	dirs = []string{dir}
+}
+
 func Open(name string) (f *os.File, err error) {
 	for _, dir := range dirs {
 		f, err = os.Open(filepath.Join(dir, name))
@@ -45,18 +54,15 @@ func LoadProgram(vsh, fsh string) bgfx.Program {
 }
 
 func loadShader(name string) (bgfx.Shader, error) {
-	f, err := Open(filepath.Join("shaders/glsl", name+".bin"))
-	if err != nil {
-		return bgfx.Shader{}, err
-	}
-	defer f.Close()
-	data, err := ioutil.ReadAll(f)
+	data, err := readShader(name)
 	if err != nil {
 		return bgfx.Shader{}, err
 	}
 	return bgfx.CreateShader(data), nil
 }
 
+// LoadTexture loads a texture, such as a DDS, from the textures
+// directory. It fails loudly if the texture can't be loaded.
 func LoadTexture(name string, flags bgfx.TextureFlags) bgfx.Texture {
 	f, err := Open(filepath.Join("textures", name))
 	if err != nil {
@@ -67,7 +73,10 @@ func LoadTexture(name string, flags bgfx.TextureFlags) bgfx.Texture {
 	if err != nil {
 		log.Fatalln(err)
 	}
-	tex, _ := bgfx.CreateTexture(data, flags, 0)
+	tex, err := bgfx.CreateTexture(data, flags, 0)
+	if err != nil {
+		log.Fatalf("assets: texture %s: %v", name, err)
+	}
 	return tex
 }
 
@@ -82,6 +91,45 @@ type Sphere struct {
 	Radius float32
 }
 
+// Transform returns a sphere around s transformed by mtx, which may
+// scale it unevenly.
+func (s Sphere) Transform(mtx [16]float32) Sphere {
+	var scale float32
+	for col := 0; col < 3; col++ {
+		x, y, z := mtx[col*4], mtx[col*4+1], mtx[col*4+2]
+		if l := x*x + y*y + z*z; l > scale {
+			scale = l
+		}
+	}
+	c := s.Center
+	return Sphere{
+		Center: [3]float32{
+			mtx[0]*c[0] + mtx[4]*c[1] + mtx[8]*c[2] + mtx[12],
+			mtx[1]*c[0] + mtx[5]*c[1] + mtx[9]*c[2] + mtx[13],
+			mtx[2]*c[0] + mtx[6]*c[1] + mtx[10]*c[2] + mtx[14],
+		},
+		Radius: s.Radius * float32(math.Sqrt(float64(scale))),
+	}
+}
+
+// union returns a sphere around both s and o.
+func (s Sphere) union(o Sphere) Sphere {
+	d := [3]float32{o.Center[0] - s.Center[0], o.Center[1] - s.Center[1], o.Center[2] - s.Center[2]}
+	dist := float32(math.Sqrt(float64(d[0]*d[0] + d[1]*d[1] + d[2]*d[2])))
+	if dist+o.Radius <= s.Radius {
+		return s
+	}
+	if dist+s.Radius <= o.Radius {
+		return o
+	}
+	r := (dist + s.Radius + o.Radius) / 2
+	f := (r - s.Radius) / dist
+	return Sphere{
+		Center: [3]float32{s.Center[0] + d[0]*f, s.Center[1] + d[1]*f, s.Center[2] + d[2]*f},
+		Radius: r,
+	}
+}
+
 type AABB struct {
 	Min, Max [3]float32
 }
@@ -109,6 +157,28 @@ type Mesh struct {
 	groups []group
 }
 
+// NewMesh returns a mesh of a single group drawing all of ib from vb,
+// with the given bounds. Unload destroys the buffers.
+func NewMesh(vb bgfx.VertexBuffer, ib bgfx.IndexBuffer, bounds Bounds) Mesh {
+	return Mesh{
+		groups: []group{{VB: vb, IB: ib, Bounds: bounds}},
+	}
+}
+
+// BoundingSphere returns a sphere around every group of the mesh, in
+// model space.
+func (m Mesh) BoundingSphere() Sphere {
+	var s Sphere
+	for i, g := range m.groups {
+		if i == 0 {
+			s = g.Sphere
+		} else {
+			s = s.union(g.Sphere)
+		}
+	}
+	return s
+}
+
 func LoadMesh(name string) Mesh {
 	f, err := Open(filepath.Join("meshes", name+".bin"))
 	if err != nil {
@@ -277,18 +347,90 @@ func readMesh(r io.ReadSeeker) Mesh {
 }
 
 func (m Mesh) Submit(view bgfx.ViewID, prog bgfx.Program, mtx [16]float32, state bgfx.State) {
+	m.submit(render.Default, view, prog, mtx, state, nil, nil)
+}
+
+// SubmitTo is like Submit, but issues the draws through r.
+func (m Mesh) SubmitTo(r render.Renderer, view bgfx.ViewID, prog bgfx.Program, mtx [16]float32, state bgfx.State) {
+	m.submit(r, view, prog, mtx, state, nil, nil)
+}
+
+// CullStats counts the groups and primitives tested by SubmitCulled,
+// and how many of them were outside the frustum.
+type CullStats struct {
+	Groups, GroupsCulled int
+	Prims, PrimsCulled   int
+}
+
+// SubmitCulled is like Submit, but skips groups that are outside the
+// frustum of viewProj. A group with several primitives is only drawn if
+// at least one of them is inside. If stats is not nil, the results are
+// added to it.
+func (m Mesh) SubmitCulled(view bgfx.ViewID, prog bgfx.Program, mtx [16]float32, state bgfx.State, viewProj [16]float32, stats *CullStats) {
+	m.SubmitCulledTo(render.Default, view, prog, mtx, state, viewProj, stats)
+}
+
+// SubmitCulledTo is like SubmitCulled, but issues the draws through r.
+func (m Mesh) SubmitCulledTo(r render.Renderer, view bgfx.ViewID, prog bgfx.Program, mtx [16]float32, state bgfx.State, viewProj [16]float32, stats *CullStats) {
+	// Test against the frustum in model space, where the bounds are.
+	f := NewFrustum(mat4.Mul(viewProj, mtx))
+	if stats == nil {
+		stats = new(CullStats)
+	}
+	m.submit(r, view, prog, mtx, state, &f, stats)
+}
+
+// visible reports whether any part of g is inside f.
+func (g *group) visible(f *Frustum, stats *CullStats) bool {
+	stats.Groups++
+	if !f.IntersectsBounds(g.Bounds) {
+		stats.GroupsCulled++
+		stats.Prims += len(g.Prims)
+		stats.PrimsCulled += len(g.Prims)
+		return false
+	}
+	if len(g.Prims) < 2 {
+		stats.Prims += len(g.Prims)
+		return true
+	}
+	visible := false
+	for i := range g.Prims {
+		stats.Prims++
+		if f.IntersectsBounds(g.Prims[i].Bounds) {
+			visible = true
+		} else {
+			stats.PrimsCulled++
+		}
+	}
+	if !visible {
+		stats.GroupsCulled++
+	}
+	return visible
+}
+
+func (m Mesh) submit(r render.Renderer, view bgfx.ViewID, prog bgfx.Program, mtx [16]float32, state bgfx.State, f *Frustum, stats *CullStats) {
+	state = meshState(state)
+	for i := range m.groups {
+		g := &m.groups[i]
+		if f != nil && !g.visible(f, stats) {
+			continue
+		}
+		r.SetTransform(mtx)
+		r.SetProgram(prog)
+		r.SetIndexBuffer(g.IB)
+		r.SetVertexBuffer(g.VB)
+		r.SetState(state)
+		r.Submit(view)
+	}
+}
+
+// meshState returns state, or the default state for meshes if it is 0.
+func meshState(state bgfx.State) bgfx.State {
 	if state == 0 {
 		state = bgfx.StateDefault | bgfx.StateCullCCW
 		state &= ^bgfx.StateCullCW
 	}
-	for _, g := range m.groups {
-		bgfx.SetTransform(mtx)
-		bgfx.SetProgram(prog)
-		bgfx.SetIndexBuffer(g.IB)
-		bgfx.SetVertexBuffer(g.VB)
-		bgfx.SetState(state)
-		bgfx.Submit(view)
-	}
+	return state
 }
 
 func (m Mesh) Unload() {
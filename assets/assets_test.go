@@ -0,0 +1,84 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+	"j4k.co/cgm/mat4"
+)
+
+func testMesh(groups int) Mesh {
+	var m Mesh
+	for i := 0; i < groups; i++ {
+		m.groups = append(m.groups, group{
+			Bounds: Bounds{
+				Sphere: Sphere{Center: [3]float32{float32(i) * 10, 0, 0}, Radius: 1},
+				AABB: AABB{
+					Min: [3]float32{float32(i)*10 - 1, -1, -1},
+					Max: [3]float32{float32(i)*10 + 1, 1, 1},
+				},
+			},
+		})
+	}
+	return m
+}
+
+func TestSubmitDrawsEachGroup(t *testing.T) {
+	var (
+		r    render.Recorder
+		mesh = testMesh(3)
+		mtx  = mat4.Identity()
+	)
+	mtx[12] = 5
+	mesh.SubmitTo(&r, 2, bgfx.Program{}, mtx, 0)
+	if len(r.Draws) != 3 {
+		t.Fatalf("%d draws for 3 groups", len(r.Draws))
+	}
+	want := bgfx.StateDefault&^bgfx.StateCullCW | bgfx.StateCullCCW
+	for i, d := range r.Draws {
+		if d.View != 2 {
+			t.Errorf("draw %d: view %d, want 2", i, d.View)
+		}
+		if d.State != want {
+			t.Errorf("draw %d: state %#x, want %#x", i, d.State, want)
+		}
+		if d.Transform != mtx {
+			t.Errorf("draw %d: transform %v, want %v", i, d.Transform, mtx)
+		}
+	}
+}
+
+func TestSubmitKeepsState(t *testing.T) {
+	var r render.Recorder
+	state := bgfx.StateRGBWrite | bgfx.StateDepthTestLess
+	testMesh(1).SubmitTo(&r, 0, bgfx.Program{}, mat4.Identity(), state)
+	if len(r.Draws) != 1 || r.Draws[0].State != state {
+		t.Fatalf("draws %+v, want one with state %#x", r.Draws, state)
+	}
+}
+
+func TestSubmitCulledSkipsGroups(t *testing.T) {
+	var (
+		r     render.Recorder
+		stats CullStats
+	)
+	// An orthographic box around the first group only.
+	viewProj := mat4.Scale(0.2, 0.2, 0.2)
+	testMesh(2).SubmitCulledTo(&r, 0, bgfx.Program{}, mat4.Identity(), 0, viewProj, &stats)
+	if len(r.Draws) != 1 {
+		t.Errorf("%d draws, want 1", len(r.Draws))
+	}
+	if stats.Groups != 2 || stats.GroupsCulled != 1 {
+		t.Errorf("stats %+v, want 2 groups with 1 culled", stats)
+	}
+}
+
+func TestSphereTransform(t *testing.T) {
+	mtx := mat4.Scale(2, 1, 3)
+	mtx[12], mtx[13], mtx[14] = 1, 2, 3
+	s := Sphere{Center: [3]float32{1, 1, 1}, Radius: 0.5}.Transform(mtx)
+	if s.Center != [3]float32{3, 3, 6} || s.Radius != 1.5 {
+		t.Errorf("got %+v, want center 3, 3, 6 and radius 1.5", s)
+	}
+}
@@ -0,0 +1,112 @@
+package assets
+
+import (
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+// Batcher collects mesh draws over a frame and submits draws of the same
+// mesh with the same material together. When the material has an
+// instanced program, each batch is drawn with instancing; otherwise its
+// draws are submitted one by one.
+type Batcher struct {
+	Stats BatchStats // for the last Flush
+
+	batches     []*batch
+	index       map[batchKey]*batch
+	instances   *render.InstanceBuffer[[16]float32]
+	submissions int
+	unbatched   int
+}
+
+// BatchStats counts what a Batcher flush submitted.
+type BatchStats struct {
+	Submissions int // calls to Add
+	Batches     int // distinct mesh and material pairs
+	Instanced   int // batches drawn with instancing
+	Draws       int // draws submitted
+	Unbatched   int // draws it would have taken without batching
+}
+
+// Saved returns how many draws batching saved.
+func (s BatchStats) Saved() int {
+	return s.Unbatched - s.Draws
+}
+
+type batchKey struct {
+	mesh *Mesh
+	mat  *Material
+}
+
+type batch struct {
+	batchKey
+	transforms [][16]float32
+}
+
+// Add queues a draw of mesh with mat. The mesh and material must stay
+// valid until the next Flush.
+func (b *Batcher) Add(mesh *Mesh, mat *Material, mtx [16]float32) {
+	if b.index == nil {
+		b.index = make(map[batchKey]*batch)
+	}
+	key := batchKey{mesh, mat}
+	bt, ok := b.index[key]
+	if !ok {
+		bt = &batch{batchKey: key}
+		b.index[key] = bt
+		b.batches = append(b.batches, bt)
+	}
+	bt.transforms = append(bt.transforms, mtx)
+	b.submissions++
+	b.unbatched += len(mesh.groups)
+}
+
+// Flush submits the queued draws to view and empties the batcher.
+func (b *Batcher) Flush(view bgfx.ViewID) {
+	b.FlushTo(render.Default, view)
+}
+
+// FlushTo is like Flush, but draws through r.
+func (b *Batcher) FlushTo(r render.Renderer, view bgfx.ViewID) {
+	if b.instances == nil {
+		b.instances = render.NewInstanceBuffer[[16]float32]()
+	}
+	stats := BatchStats{
+		Submissions: b.submissions,
+		Batches:     len(b.batches),
+		Unbatched:   b.unbatched,
+	}
+	for _, bt := range b.batches {
+		mat := bt.mat
+		if !mat.Instanced || len(bt.transforms) < 2 {
+			for _, mtx := range bt.transforms {
+				bt.mesh.SubmitMaterialTo(r, view, mat, mtx)
+				stats.Draws += len(bt.mesh.groups)
+			}
+			continue
+		}
+		stats.Instanced++
+		for i := range bt.mesh.groups {
+			g := &bt.mesh.groups[i]
+			for _, mtx := range bt.transforms {
+				b.instances.Add(mtx)
+			}
+			b.instances.Instanced = true
+			b.instances.SubmitTo(r, view, func(r render.Renderer) {
+				r.SetProgram(mat.InstancedProgram)
+				r.SetIndexBuffer(g.IB)
+				r.SetVertexBuffer(g.VB)
+				mat.ApplyTo(r)
+				r.SetState(meshState(mat.State))
+			})
+			stats.Draws += b.instances.Stats.Draws
+		}
+	}
+	b.Stats = stats
+	for _, bt := range b.batches {
+		delete(b.index, bt.batchKey)
+	}
+	b.batches = b.batches[:0]
+	b.submissions = 0
+	b.unbatched = 0
+}
@@ -0,0 +1,104 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+	"j4k.co/cgm/mat4"
+)
+
+func translate(x float32) [16]float32 {
+	mtx := mat4.Identity()
+	mtx[12] = x
+	return mtx
+}
+
+func TestBatcherFlush(t *testing.T) {
+	var (
+		r      render.Recorder
+		b      Batcher
+		one    = testMesh(1)
+		two    = testMesh(2)
+		plain  = &Material{State: bgfx.StateRGBWrite}
+		shared = &Material{State: bgfx.StateDepthWrite, Instanced: true}
+	)
+	b.Add(&one, plain, translate(0))
+	b.Add(&two, shared, translate(1))
+	b.Add(&one, shared, translate(2))
+	b.Add(&one, plain, translate(3))
+	b.Add(&two, shared, translate(4))
+	b.FlushTo(&r, 3)
+
+	// Batches are drawn in the order they were first added. A batch of
+	// one is drawn without instancing, as is a material without an
+	// instanced program; an instanced batch has a draw for each group.
+	want := []struct {
+		state     bgfx.State
+		instanced bool
+		x         float32
+	}{
+		{meshState(plain.State), false, 0},
+		{meshState(plain.State), false, 3},
+		{meshState(shared.State), true, 0},
+		{meshState(shared.State), true, 0},
+		{meshState(shared.State), false, 2},
+	}
+	if len(r.Draws) != len(want) {
+		t.Fatalf("%d draws, want %d", len(r.Draws), len(want))
+	}
+	for i, w := range want {
+		d := r.Draws[i]
+		if d.View != 3 || d.State != w.state || d.Instanced != w.instanced || d.Transform[12] != w.x {
+			t.Errorf("draw %d: view %d, state %#x, instanced %v, x %v; want state %#x, instanced %v, x %v",
+				i, d.View, d.State, d.Instanced, d.Transform[12], w.state, w.instanced, w.x)
+		}
+	}
+	allocs := r.CallsNamed("AllocInstanceDataBuffer")
+	if len(allocs) != 2 {
+		t.Fatalf("%d instance data allocations, want 2", len(allocs))
+	}
+	for i, c := range allocs {
+		if c.Args[0] != 2 {
+			t.Errorf("allocation %d: %v instances, want 2", i, c.Args[0])
+		}
+	}
+	wantStats := BatchStats{Submissions: 5, Batches: 3, Instanced: 1, Draws: 5, Unbatched: 7}
+	if b.Stats != wantStats {
+		t.Errorf("stats %+v, want %+v", b.Stats, wantStats)
+	}
+	if b.Stats.Saved() != 2 {
+		t.Errorf("saved %d draws, want 2", b.Stats.Saved())
+	}
+
+	// Flushing empties the batcher.
+	r.Reset()
+	b.FlushTo(&r, 3)
+	if len(r.Draws) != 0 || b.Stats != (BatchStats{}) {
+		t.Errorf("second flush: %d draws, stats %+v", len(r.Draws), b.Stats)
+	}
+}
+
+func TestBatcherWithoutInstancing(t *testing.T) {
+	var (
+		r   render.Recorder
+		b   Batcher
+		one = testMesh(1)
+		mat = &Material{}
+	)
+	for i := 0; i < 4; i++ {
+		b.Add(&one, mat, translate(float32(i)))
+	}
+	b.FlushTo(&r, 0)
+	if len(r.Draws) != 4 {
+		t.Fatalf("%d draws, want 4", len(r.Draws))
+	}
+	for i, d := range r.Draws {
+		if d.Instanced || d.Transform[12] != float32(i) {
+			t.Errorf("draw %d: instanced %v, x %v", i, d.Instanced, d.Transform[12])
+		}
+	}
+	if want := (BatchStats{Submissions: 4, Batches: 1, Draws: 4, Unbatched: 4}); b.Stats != want {
+		t.Errorf("stats %+v, want %+v", b.Stats, want)
+	}
+}
@@ -0,0 +1,375 @@
+package assets
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/hdrimage"
+)
+
+// CubeFaces are the suffixes of the six face images of a cube map, in
+// bgfx's face order.
+var CubeFaces = [6]string{"px", "nx", "py", "ny", "pz", "nz"}
+
+// Cubemap is a cube map of linear float32 RGBA texels, one image per
+// face in the order of CubeFaces.
+type Cubemap struct {
+	Size  int
+	Faces [6]*hdrimage.Image
+}
+
+// NewCubemap returns a black cube map with faces of size by size texels.
+func NewCubemap(size int) *Cubemap {
+	c := &Cubemap{Size: size}
+	for i := range c.Faces {
+		c.Faces[i] = hdrimage.New(size, size)
+	}
+	return c
+}
+
+// CubeDirection returns the direction, not normalized, through texel
+// coordinates u, v in [-1, 1] of face, with u to the right and v down,
+// as Direct3D and bgfx lay out cube maps.
+func CubeDirection(face int, u, v float32) [3]float32 {
+	switch face {
+	case 0:
+		return [3]float32{1, -v, -u}
+	case 1:
+		return [3]float32{-1, -v, u}
+	case 2:
+		return [3]float32{u, 1, v}
+	case 3:
+		return [3]float32{u, -1, -v}
+	case 4:
+		return [3]float32{u, -v, 1}
+	}
+	return [3]float32{-u, -v, -1}
+}
+
+// CubeFace returns the face that the direction dir points through, and
+// where, as for CubeDirection.
+func CubeFace(dir [3]float32) (face int, u, v float32) {
+	x, y, z := dir[0], dir[1], dir[2]
+	ax, ay, az := abs(x), abs(y), abs(z)
+	switch {
+	case ax >= ay && ax >= az:
+		if x > 0 {
+			return 0, -z / ax, -y / ax
+		}
+		return 1, z / ax, -y / ax
+	case ay >= az:
+		if y > 0 {
+			return 2, x / ay, z / ay
+		}
+		return 3, x / ay, -z / ay
+	case z > 0:
+		return 4, x / az, -y / az
+	}
+	return 5, -x / az, -y / az
+}
+
+// Sample returns the cube map bilinearly filtered in direction dir. It
+// does not filter across the edges of faces.
+func (c *Cubemap) Sample(dir [3]float32) [4]float32 {
+	face, u, v := CubeFace(dir)
+	img := c.Faces[face]
+	x := (u+1)/2*float32(c.Size) - 0.5
+	y := (v+1)/2*float32(c.Size) - 0.5
+	x0, y0 := int(floor(x)), int(floor(y))
+	fx, fy := x-float32(x0), y-float32(y0)
+	clamp := func(i int) int {
+		if i < 0 {
+			return 0
+		}
+		if i >= c.Size {
+			return c.Size - 1
+		}
+		return i
+	}
+	var (
+		c00 = img.At(clamp(x0), clamp(y0))
+		c10 = img.At(clamp(x0+1), clamp(y0))
+		c01 = img.At(clamp(x0), clamp(y0+1))
+		c11 = img.At(clamp(x0+1), clamp(y0+1))
+		col [4]float32
+	)
+	for i := range col {
+		top := c00[i] + (c10[i]-c00[i])*fx
+		bottom := c01[i] + (c11[i]-c01[i])*fx
+		col[i] = top + (bottom-top)*fy
+	}
+	return col
+}
+
+// Downsample returns the cube map at half the size, each texel the
+// average of four.
+func (c *Cubemap) Downsample() *Cubemap {
+	size := c.Size / 2
+	if size < 1 {
+		size = 1
+	}
+	d := NewCubemap(size)
+	for face, img := range c.Faces {
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				var sum [4]float32
+				for _, p := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+					sx, sy := x*2+p[0], y*2+p[1]
+					if sx >= c.Size {
+						sx = c.Size - 1
+					}
+					if sy >= c.Size {
+						sy = c.Size - 1
+					}
+					col := img.At(sx, sy)
+					for i := range sum {
+						sum[i] += col[i] / 4
+					}
+				}
+				d.Faces[face].Set(x, y, sum)
+			}
+		}
+	}
+	return d
+}
+
+// MipChain returns the cube map and each downsampled level of it, down to
+// 1 by 1 texels.
+func (c *Cubemap) MipChain() []*Cubemap {
+	chain := []*Cubemap{c}
+	for c.Size > 1 {
+		c = c.Downsample()
+		chain = append(chain, c)
+	}
+	return chain
+}
+
+// CubemapFromFunc returns a cube map with faces of size by size texels,
+// colored by f of the unit direction through each texel's center.
+func CubemapFromFunc(size int, f func(dir [3]float32) [4]float32) *Cubemap {
+	c := NewCubemap(size)
+	for face, img := range c.Faces {
+		for y := 0; y < size; y++ {
+			v := (float32(y)+0.5)/float32(size)*2 - 1
+			for x := 0; x < size; x++ {
+				u := (float32(x)+0.5)/float32(size)*2 - 1
+				img.Set(x, y, f(normalize(CubeDirection(face, u, v))))
+			}
+		}
+	}
+	return c
+}
+
+// CubemapFromEquirect returns a cube map with faces of size by size
+// texels, resampled from an equirectangular (latitude-longitude) image
+// with +Z at its center and +Y at its top.
+func CubemapFromEquirect(img *hdrimage.Image, size int) *Cubemap {
+	return CubemapFromFunc(size, func(dir [3]float32) [4]float32 {
+		u, v := EquirectUV(dir)
+		return img.Sample(u, v)
+	})
+}
+
+// EquirectUV returns where the unit direction dir falls in an
+// equirectangular image, from the top left.
+func EquirectUV(dir [3]float32) (u, v float32) {
+	lon := math.Atan2(float64(dir[0]), float64(dir[2]))
+	lat := math.Acos(math.Max(-1, math.Min(1, float64(dir[1]))))
+	return float32(lon/(2*math.Pi) + 0.5), float32(lat / math.Pi)
+}
+
+// CubemapFromFaces returns a cube map of six square images of the same
+// size, in the order of CubeFaces. Images that are not already linear,
+// such as PNGs and JPEGs, are taken to be sRGB.
+func CubemapFromFaces(faces [6]image.Image) (*Cubemap, error) {
+	size := faces[0].Bounds().Dx()
+	c := &Cubemap{Size: size}
+	for i, face := range faces {
+		b := face.Bounds()
+		if b.Dx() != size || b.Dy() != size {
+			return nil, fmt.Errorf("assets: cube face %s is %dx%d, not %dx%d",
+				CubeFaces[i], b.Dx(), b.Dy(), size, size)
+		}
+		img := hdrimage.New(size, size)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				r, g, b, a := face.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				img.Set(x, y, [4]float32{
+					srgbToLinear(r), srgbToLinear(g), srgbToLinear(b),
+					float32(a) / 0xffff,
+				})
+			}
+		}
+		c.Faces[i] = img
+	}
+	return c, nil
+}
+
+func srgbToLinear(c uint32) float32 {
+	v := float64(c) / 0xffff
+	if v <= 0.04045 {
+		return float32(v / 12.92)
+	}
+	return float32(math.Pow((v+0.055)/1.055, 2.4))
+}
+
+// Texture creates an RGBA16F cube texture of the cube map, without
+// mips.
+func (c *Cubemap) Texture(flags bgfx.TextureFlags) bgfx.Texture {
+	return CubemapTexture([]*Cubemap{c}, flags)
+}
+
+// CubemapTexture creates an RGBA16F cube texture with the mip levels
+// mips, from the largest down, each half the size of the one before.
+func CubemapTexture(mips []*Cubemap, flags bgfx.TextureFlags) bgfx.Texture {
+	var data []byte
+	for face := range mips[0].Faces {
+		for _, m := range mips {
+			data = append(data, m.Faces[face].RGBA16F()...)
+		}
+	}
+	return bgfx.CreateTextureCube(mips[0].Size, len(mips), bgfx.TextureFormatRGBA16F, flags, data)
+}
+
+// LoadCubemap loads a cube map texture from the textures directory. The
+// name may be a DDS cube map, an equirectangular Radiance (.hdr) or
+// OpenEXR (.exr) image, or a pattern with %s for the suffixes of six face images, such as
+// "sky_%s.png". It fails loudly if the texture can't be loaded.
+func LoadCubemap(name string, flags bgfx.TextureFlags) bgfx.Texture {
+	if strings.Contains(name, "%s") || isHDR(name) {
+		return LoadCubemapImage(name).Texture(flags)
+	}
+	return LoadTexture(name, flags)
+}
+
+// LoadCubemapImage loads a cube map from the textures directory into
+// memory, from an equirectangular image or six faces as for LoadCubemap.
+// DDS cube maps can only be loaded as textures.
+func LoadCubemapImage(name string) *Cubemap {
+	if strings.Contains(name, "%s") {
+		var faces [6]image.Image
+		for i, suffix := range CubeFaces {
+			faces[i] = loadImage(fmt.Sprintf(name, suffix))
+		}
+		c, err := CubemapFromFaces(faces)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return c
+	}
+	if !isHDR(name) {
+		log.Fatalf("assets: %s: want an .hdr or .exr image, or six faces", name)
+	}
+	img := LoadImageHDR(name)
+	return CubemapFromEquirect(img, EquirectFaceSize(img.Width))
+}
+
+// EquirectFaceSize returns the face size for a cube map resampled from
+// an equirectangular image width texels wide: a quarter of it, which
+// keeps about the same texel density at the horizon, and at least 1.
+func EquirectFaceSize(width int) int {
+	if width < 4 {
+		return 1
+	}
+	return width / 4
+}
+
+// ProceduralSky returns the radiance of a clear sky with a bright sun,
+// over dark ground, in the unit direction dir. It stands in for an
+// environment map that isn't in the repository.
+func ProceduralSky(dir [3]float32) [4]float32 {
+	sun := normalize([3]float32{0.4, 0.6, 0.7})
+	if dir[1] < 0 {
+		return [4]float32{0.15, 0.12, 0.1, 1}
+	}
+	t := 1 - dir[1]
+	c := [4]float32{0.2 + 0.8*t*t, 0.4 + 0.6*t*t, 1, 1}
+	cos := dir[0]*sun[0] + dir[1]*sun[1] + dir[2]*sun[2]
+	if cos > 0.9995 {
+		c[0], c[1], c[2] = 50, 45, 40
+	} else if cos > 0 {
+		glow := float32(math.Pow(float64(cos), 64)) * 4
+		c[0] += glow
+		c[1] += glow * 0.9
+		c[2] += glow * 0.7
+	}
+	return c
+}
+
+// LoadImageHDR decodes a Radiance (.hdr or .pic) or OpenEXR (.exr) image
+// from the textures directory.
+func LoadImageHDR(name string) *hdrimage.Image {
+	f, err := Open(filepath.Join("textures", name))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	decode := hdrimage.DecodeRadiance
+	if strings.EqualFold(filepath.Ext(name), ".exr") {
+		decode = hdrimage.DecodeEXR
+	}
+	img, err := decode(f)
+	if err != nil {
+		log.Fatalf("assets: %s: %v", name, err)
+	}
+	return img
+}
+
+// LoadTextureHDR loads a Radiance or OpenEXR image from the textures
+// directory as a 2D texture of format, which must be RGBA16F or RGBA32F.
+func LoadTextureHDR(name string, format bgfx.TextureFormat, flags bgfx.TextureFlags) bgfx.Texture {
+	return TextureFromImage(LoadImageHDR(name), format, flags)
+}
+
+// TextureFromImage creates a 2D texture of img, without mips, in format,
+// which must be RGBA16F or RGBA32F.
+func TextureFromImage(img *hdrimage.Image, format bgfx.TextureFormat, flags bgfx.TextureFlags) bgfx.Texture {
+	var data []byte
+	switch format {
+	case bgfx.TextureFormatRGBA16F:
+		data = img.RGBA16F()
+	case bgfx.TextureFormatRGBA32F:
+		data = img.RGBA32F()
+	default:
+		panic("assets: HDR textures must be RGBA16F or RGBA32F")
+	}
+	return bgfx.CreateTexture2D(img.Width, img.Height, 1, format, flags, data)
+}
+
+// isHDR reports whether name is an image LoadImageHDR decodes.
+func isHDR(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".hdr", ".pic", ".exr":
+		return true
+	}
+	return false
+}
+
+func loadImage(name string) image.Image {
+	f, err := Open(filepath.Join("textures", name))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		log.Fatalf("assets: %s: %v", name, err)
+	}
+	return img
+}
+
+func floor(f float32) float32 {
+	return float32(math.Floor(float64(f)))
+}
+
+func normalize(v [3]float32) [3]float32 {
+	l := float32(math.Sqrt(float64(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])))
+	return [3]float32{v[0] / l, v[1] / l, v[2] / l}
+}
@@ -0,0 +1,111 @@
+package assets
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james4k/go-bgfx-examples/hdrimage"
+)
+
+// axes are the directions through the centers of the faces, in order.
+var axes = [6][3]float32{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+
+func nearVec(a, b [3]float32) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCubeFaceCenters(t *testing.T) {
+	for face, axis := range axes {
+		if dir := CubeDirection(face, 0, 0); dir != axis {
+			t.Errorf("face %s: center is %v, want %v", CubeFaces[face], dir, axis)
+		}
+		if f, u, v := CubeFace(axis); f != face || u != 0 || v != 0 {
+			t.Errorf("%v: face %d at %v, %v; want %d at 0, 0", axis, f, u, v, face)
+		}
+	}
+}
+
+func TestCubeFaceRoundTrip(t *testing.T) {
+	// Away from the edges, where faces meet and either will do.
+	const n = 7
+	for face := range axes {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				u := (float32(i)+0.5)/n*2 - 1
+				v := (float32(j)+0.5)/n*2 - 1
+				dir := normalize(CubeDirection(face, u, v))
+				f, gu, gv := CubeFace(dir)
+				if f != face || math.Abs(float64(gu-u)) > 1e-5 || math.Abs(float64(gv-v)) > 1e-5 {
+					t.Errorf("face %d at %v, %v: back to face %d at %v, %v", face, u, v, f, gu, gv)
+				}
+				if back := normalize(CubeDirection(f, gu, gv)); !nearVec(back, dir) {
+					t.Errorf("face %d at %v, %v: %v back to %v", face, u, v, dir, back)
+				}
+			}
+		}
+	}
+}
+
+func TestCubeFaceOrientation(t *testing.T) {
+	// u runs right and v down on each face, seen from inside the cube
+	// with +Y up on the side faces, as Direct3D lays them out.
+	for _, tt := range []struct {
+		face        int
+		right, down [3]float32
+	}{
+		{0, [3]float32{0, 0, -1}, [3]float32{0, -1, 0}},
+		{1, [3]float32{0, 0, 1}, [3]float32{0, -1, 0}},
+		{2, [3]float32{1, 0, 0}, [3]float32{0, 0, 1}},
+		{3, [3]float32{1, 0, 0}, [3]float32{0, 0, -1}},
+		{4, [3]float32{1, 0, 0}, [3]float32{0, -1, 0}},
+		{5, [3]float32{-1, 0, 0}, [3]float32{0, -1, 0}},
+	} {
+		c := axes[tt.face]
+		right := CubeDirection(tt.face, 1, 0)
+		down := CubeDirection(tt.face, 0, 1)
+		for i := range c {
+			right[i] -= c[i]
+			down[i] -= c[i]
+		}
+		if right != tt.right || down != tt.down {
+			t.Errorf("face %s: right %v, down %v; want %v, %v", CubeFaces[tt.face], right, down, tt.right, tt.down)
+		}
+	}
+}
+
+func TestEquirectUV(t *testing.T) {
+	for _, tt := range []struct {
+		dir  [3]float32
+		u, v float32
+	}{
+		{[3]float32{0, 0, 1}, 0.5, 0.5},
+		{[3]float32{1, 0, 0}, 0.75, 0.5},
+		{[3]float32{-1, 0, 0}, 0.25, 0.5},
+		{[3]float32{0, 1, 0}, 0.5, 0},
+		{[3]float32{0, -1, 0}, 0.5, 1},
+	} {
+		u, v := EquirectUV(tt.dir)
+		if math.Abs(float64(u-tt.u)) > 1e-6 || math.Abs(float64(v-tt.v)) > 1e-6 {
+			t.Errorf("%v: %v, %v; want %v, %v", tt.dir, u, v, tt.u, tt.v)
+		}
+	}
+}
+
+func TestEquirectFaceSize(t *testing.T) {
+	for _, tt := range []struct{ width, size int }{
+		{1, 1}, {3, 1}, {4, 1}, {7, 1}, {8, 2}, {2048, 512},
+	} {
+		if got := EquirectFaceSize(tt.width); got != tt.size {
+			t.Errorf("%d wide: face size %d, want %d", tt.width, got, tt.size)
+		}
+	}
+	img := hdrimage.New(2, 1)
+	if c := CubemapFromEquirect(img, EquirectFaceSize(img.Width)); c.Size != 1 || c.Faces[0].Width != 1 {
+		t.Errorf("cube map of a 2x1 image is %d texels", c.Size)
+	}
+}
@@ -0,0 +1,127 @@
+package assets
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/james4k/go-bgfx-examples/hdrimage"
+)
+
+const (
+	ddsCaps        = 0x1
+	ddsHeight      = 0x2
+	ddsWidth       = 0x4
+	ddsPitch       = 0x8
+	ddsPixelFormat = 0x1000
+	ddsMipMapCount = 0x20000
+
+	ddsFourCC = 0x4
+
+	ddsCapsComplex = 0x8
+	ddsCapsTexture = 0x1000
+	ddsCapsMipMap  = 0x400000
+
+	ddsCaps2Cubemap = 0x200
+	ddsCaps2AllFace = 0xfc00
+
+	// The DX10 extension header follows the header when the FourCC is
+	// "DX10", and gives the format as a DXGI_FORMAT.
+	ddsFourCCDX10         = 'D' | 'X'<<8 | '1'<<16 | '0'<<24
+	dxgiFormatRGBA16F     = 10 // DXGI_FORMAT_R16G16B16A16_FLOAT
+	ddsDimensionTexture2D = 3
+	ddsMiscTextureCube    = 0x4
+)
+
+// WriteDDS writes an RGBA16F DDS texture, with a DX10 header, of mips,
+// the mip levels of a 2D image from the largest down, each half the size
+// of the one before.
+func WriteDDS(w io.Writer, mips ...*hdrimage.Image) error {
+	if len(mips) == 0 {
+		return errors.New("assets: no images to write")
+	}
+	bw := bufio.NewWriter(w)
+	writeDDSHeader(bw, mips[0].Width, mips[0].Height, len(mips), false)
+	for _, m := range mips {
+		bw.Write(m.RGBA16F())
+	}
+	return bw.Flush()
+}
+
+// WriteDDSCube writes an RGBA16F DDS cube texture, with a DX10 header,
+// of mips, the mip levels of a cube map as for CubemapTexture.
+func WriteDDSCube(w io.Writer, mips []*Cubemap) error {
+	if len(mips) == 0 {
+		return errors.New("assets: no cube maps to write")
+	}
+	bw := bufio.NewWriter(w)
+	writeDDSHeader(bw, mips[0].Size, mips[0].Size, len(mips), true)
+	for face := range mips[0].Faces {
+		for _, m := range mips {
+			bw.Write(m.Faces[face].RGBA16F())
+		}
+	}
+	return bw.Flush()
+}
+
+func writeDDSHeader(w io.Writer, width, height, mips int, cube bool) {
+	var (
+		flags = uint32(ddsCaps | ddsHeight | ddsWidth | ddsPitch | ddsPixelFormat)
+		caps  = uint32(ddsCapsTexture)
+		caps2 uint32
+		misc  uint32
+	)
+	if mips > 1 {
+		flags |= ddsMipMapCount
+		caps |= ddsCapsComplex | ddsCapsMipMap
+	}
+	if cube {
+		caps |= ddsCapsComplex
+		caps2 = ddsCaps2Cubemap | ddsCaps2AllFace
+		misc = ddsMiscTextureCube
+	}
+	header := struct {
+		Magic         [4]byte
+		Size          uint32
+		Flags         uint32
+		Height, Width uint32
+		Pitch         uint32
+		Depth         uint32
+		MipMapCount   uint32
+		Reserved1     [11]uint32
+		PFSize        uint32
+		PFFlags       uint32
+		FourCC        uint32
+		RGBBitCount   uint32
+		R, G, B, A    uint32
+		Caps, Caps2   uint32
+		Caps3, Caps4  uint32
+		Reserved2     uint32
+
+		DXGIFormat        uint32
+		ResourceDimension uint32
+		MiscFlag          uint32
+		ArraySize         uint32
+		MiscFlags2        uint32
+	}{
+		Magic:       [4]byte{'D', 'D', 'S', ' '},
+		Size:        124,
+		Flags:       flags,
+		Height:      uint32(height),
+		Width:       uint32(width),
+		Pitch:       uint32(width * 8),
+		MipMapCount: uint32(mips),
+		PFSize:      32,
+		PFFlags:     ddsFourCC,
+		FourCC:      ddsFourCCDX10,
+		Caps:        caps,
+		Caps2:       caps2,
+
+		DXGIFormat:        dxgiFormatRGBA16F,
+		ResourceDimension: ddsDimensionTexture2D,
+		MiscFlag:          misc,
+		ArraySize:         1,
+	}
+	binary.Write(w, binary.LittleEndian, &header)
+}
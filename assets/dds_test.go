@@ -0,0 +1,107 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/james4k/go-bgfx-examples/hdrimage"
+)
+
+// ddsHeader reads the header and DX10 header fields the writers set, by
+// their offsets from the start of the file.
+func ddsHeader(t *testing.T, data []byte) map[string]uint32 {
+	if len(data) < 148 {
+		t.Fatalf("%d bytes, too short for the headers", len(data))
+	}
+	if string(data[:4]) != "DDS " {
+		t.Fatalf("magic %q", data[:4])
+	}
+	fields := map[string]int{
+		"size": 4, "flags": 8, "height": 12, "width": 16, "pitch": 20, "mips": 28,
+		"pfsize": 76, "pfflags": 80, "fourcc": 84, "caps": 108, "caps2": 112,
+		"dxgi": 128, "dimension": 132, "misc": 136, "arraysize": 140,
+	}
+	h := make(map[string]uint32)
+	for name, off := range fields {
+		h[name] = binary.LittleEndian.Uint32(data[off:])
+	}
+	return h
+}
+
+func checkDDSHeader(t *testing.T, name string, got, want map[string]uint32) {
+	for field, v := range want {
+		if got[field] != v {
+			t.Errorf("%s: %s is %#x, want %#x", name, field, got[field], v)
+		}
+	}
+}
+
+func TestWriteDDS(t *testing.T) {
+	var buf bytes.Buffer
+	mips := []*hdrimage.Image{hdrimage.New(4, 2), hdrimage.New(2, 1), hdrimage.New(1, 1)}
+	if err := WriteDDS(&buf, mips...); err != nil {
+		t.Fatal(err)
+	}
+	checkDDSHeader(t, "2D", ddsHeader(t, buf.Bytes()), map[string]uint32{
+		"size":      124,
+		"flags":     ddsCaps | ddsHeight | ddsWidth | ddsPitch | ddsPixelFormat | ddsMipMapCount,
+		"height":    2,
+		"width":     4,
+		"pitch":     4 * 8,
+		"mips":      3,
+		"pfsize":    32,
+		"pfflags":   ddsFourCC,
+		"fourcc":    ddsFourCCDX10,
+		"caps":      ddsCapsTexture | ddsCapsComplex | ddsCapsMipMap,
+		"caps2":     0,
+		"dxgi":      dxgiFormatRGBA16F,
+		"dimension": ddsDimensionTexture2D,
+		"misc":      0,
+		"arraysize": 1,
+	})
+	if string(buf.Bytes()[84:88]) != "DX10" {
+		t.Errorf("FourCC %q, want DX10", buf.Bytes()[84:88])
+	}
+	if want := 148 + (8+2+1)*8; buf.Len() != want {
+		t.Errorf("%d bytes, want %d", buf.Len(), want)
+	}
+
+	buf.Reset()
+	WriteDDS(&buf, hdrimage.New(2, 2))
+	h := ddsHeader(t, buf.Bytes())
+	if h["flags"]&ddsMipMapCount != 0 || h["caps"] != ddsCapsTexture {
+		t.Errorf("one level: flags %#x, caps %#x", h["flags"], h["caps"])
+	}
+	if err := WriteDDS(&buf); err == nil {
+		t.Error("no error writing no images")
+	}
+}
+
+func TestWriteDDSCube(t *testing.T) {
+	var buf bytes.Buffer
+	mips := NewCubemap(4).MipChain()
+	if err := WriteDDSCube(&buf, mips); err != nil {
+		t.Fatal(err)
+	}
+	checkDDSHeader(t, "cube", ddsHeader(t, buf.Bytes()), map[string]uint32{
+		"size":      124,
+		"flags":     ddsCaps | ddsHeight | ddsWidth | ddsPitch | ddsPixelFormat | ddsMipMapCount,
+		"height":    4,
+		"width":     4,
+		"mips":      3,
+		"fourcc":    ddsFourCCDX10,
+		"caps":      ddsCapsTexture | ddsCapsComplex | ddsCapsMipMap,
+		"caps2":     ddsCaps2Cubemap | ddsCaps2AllFace,
+		"dxgi":      dxgiFormatRGBA16F,
+		"dimension": ddsDimensionTexture2D,
+		"misc":      ddsMiscTextureCube,
+		"arraysize": 1,
+	})
+	if want := 148 + 6*(16+4+1)*8; buf.Len() != want {
+		t.Errorf("%d bytes, want %d", buf.Len(), want)
+	}
+	if err := WriteDDSCube(&buf, nil); err == nil {
+		t.Error("no error writing no cube maps")
+	}
+}
@@ -0,0 +1,116 @@
+package assets
+
+import "math"
+
+// Frustum is a view frustum as six planes, in the order left, right,
+// bottom, top, near, far. Each plane (a, b, c, d) has a unit normal
+// pointing inward, so that ax+by+cz+d >= 0 for points inside.
+type Frustum [6][4]float32
+
+// NewFrustum extracts the frustum planes from a view-projection matrix.
+// The planes are in the space the matrix transforms from, so passing a
+// model-view-projection matrix gives planes in model space.
+func NewFrustum(viewProj [16]float32) Frustum {
+	m := &viewProj
+	row := func(i int) [4]float32 {
+		return [4]float32{m[i], m[4+i], m[8+i], m[12+i]}
+	}
+	var (
+		r0 = row(0)
+		r1 = row(1)
+		r2 = row(2)
+		r3 = row(3)
+		f  Frustum
+	)
+	for i := 0; i < 4; i++ {
+		f[0][i] = r3[i] + r0[i]
+		f[1][i] = r3[i] - r0[i]
+		f[2][i] = r3[i] + r1[i]
+		f[3][i] = r3[i] - r1[i]
+		// Assume a -1..1 depth range; for 0..1 this only keeps a
+		// little more than necessary in front of the near plane.
+		f[4][i] = r3[i] + r2[i]
+		f[5][i] = r3[i] - r2[i]
+	}
+	for i := range f {
+		p := &f[i]
+		l := float32(math.Sqrt(float64(p[0]*p[0] + p[1]*p[1] + p[2]*p[2])))
+		if l == 0 {
+			continue
+		}
+		p[0] /= l
+		p[1] /= l
+		p[2] /= l
+		p[3] /= l
+	}
+	return f
+}
+
+// IntersectsSphere reports whether s is at least partly inside f.
+func (f *Frustum) IntersectsSphere(s Sphere) bool {
+	for _, p := range f {
+		d := p[0]*s.Center[0] + p[1]*s.Center[1] + p[2]*s.Center[2] + p[3]
+		if d < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsAABB reports whether b is at least partly inside f. It is
+// conservative: boxes near the frustum's corners may be reported as
+// intersecting when they are not.
+func (f *Frustum) IntersectsAABB(b AABB) bool {
+	for _, p := range f {
+		// Test the corner furthest along the plane's normal.
+		var v [3]float32
+		for i := 0; i < 3; i++ {
+			v[i] = b.Min[i]
+			if p[i] >= 0 {
+				v[i] = b.Max[i]
+			}
+		}
+		if p[0]*v[0]+p[1]*v[1]+p[2]*v[2]+p[3] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsOBB reports whether b is at least partly inside f. Like
+// IntersectsAABB, it is conservative near the frustum's corners.
+func (f *Frustum) IntersectsOBB(b OBB) bool {
+	m := &b.Matrix
+	// The matrix maps the -1..1 cube onto the box, so its columns are
+	// the half extents along each axis and its translation the center.
+	for _, p := range f {
+		d := p[0]*m[12] + p[1]*m[13] + p[2]*m[14] + p[3]
+		r := abs(p[0]*m[0]+p[1]*m[1]+p[2]*m[2]) +
+			abs(p[0]*m[4]+p[1]*m[5]+p[2]*m[6]) +
+			abs(p[0]*m[8]+p[1]*m[9]+p[2]*m[10])
+		if d < -r {
+			return false
+		}
+	}
+	return true
+}
+
+// IntersectsBounds reports whether b is at least partly inside f. The
+// bounding sphere is tested first, then the oriented box if there is
+// one, or else the axis aligned box.
+func (f *Frustum) IntersectsBounds(b Bounds) bool {
+	if !f.IntersectsSphere(b.Sphere) {
+		return false
+	}
+	if b.OBB.Matrix != ([16]float32{}) {
+		return f.IntersectsOBB(b.OBB)
+	}
+	return f.IntersectsAABB(b.AABB)
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
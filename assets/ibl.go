@@ -0,0 +1,351 @@
+package assets
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+
+	"github.com/james4k/go-bgfx-examples/hdrimage"
+)
+
+// Image based lighting splits the light an environment reflects into a
+// diffuse part, the irradiance, and a specular part, which is the
+// environment blurred by a GGX lobe for each roughness, scaled and
+// biased by a lookup table of the rest of the BRDF (Karis, "Real Shading
+// in Unreal Engine 4", 2013). Everything here runs on the CPU, once, and
+// makes textures for shaders to sample.
+
+// SH9 are the coefficients of the first three bands of real spherical
+// harmonics, an RGB triple for each of the nine basis functions.
+type SH9 [9][3]float32
+
+// SHBasis returns the nine basis functions of SH9 at the unit direction
+// dir.
+func SHBasis(dir [3]float32) [9]float32 {
+	x, y, z := dir[0], dir[1], dir[2]
+	return [9]float32{
+		0.282095,
+		0.488603 * y,
+		0.488603 * z,
+		0.488603 * x,
+		1.092548 * x * y,
+		1.092548 * y * z,
+		0.315392 * (3*z*z - 1),
+		1.092548 * x * z,
+		0.546274 * (x*x - y*y),
+	}
+}
+
+// ProjectSH projects the cube map onto spherical harmonics, weighting
+// each texel by the solid angle it covers.
+func ProjectSH(c *Cubemap) SH9 {
+	var sum [9][3]float64
+	for face, img := range c.Faces {
+		for y := 0; y < c.Size; y++ {
+			v := (float32(y)+0.5)/float32(c.Size)*2 - 1
+			for x := 0; x < c.Size; x++ {
+				u := (float32(x)+0.5)/float32(c.Size)*2 - 1
+				w := texelSolidAngle(u, v, c.Size)
+				basis := SHBasis(normalize(CubeDirection(face, u, v)))
+				col := img.At(x, y)
+				for i, b := range basis {
+					for k := 0; k < 3; k++ {
+						sum[i][k] += float64(col[k] * b * w)
+					}
+				}
+			}
+		}
+	}
+	var sh SH9
+	for i := range sh {
+		for k := range sh[i] {
+			sh[i][k] = float32(sum[i][k])
+		}
+	}
+	return sh
+}
+
+// texelSolidAngle returns the solid angle of the texel of a face of size
+// texels centered on u, v.
+func texelSolidAngle(u, v float32, size int) float32 {
+	area := func(x, y float64) float64 {
+		return math.Atan2(x*y, math.Sqrt(x*x+y*y+1))
+	}
+	d := 1 / float64(size)
+	x0, x1 := float64(u)-d, float64(u)+d
+	y0, y1 := float64(v)-d, float64(v)+d
+	return float32(area(x0, y0) - area(x0, y1) - area(x1, y0) + area(x1, y1))
+}
+
+// Convolved returns the coefficients convolved with a clamped cosine lobe
+// and divided by π, so that evaluating them at a normal, as Eval does,
+// gives the light a white Lambertian surface reflects.
+func (sh SH9) Convolved() SH9 {
+	band := [9]float32{1, 2.0 / 3, 2.0 / 3, 2.0 / 3, 0.25, 0.25, 0.25, 0.25, 0.25}
+	for i := range sh {
+		for k := range sh[i] {
+			sh[i][k] *= band[i]
+		}
+	}
+	return sh
+}
+
+// Eval returns the function the coefficients describe at the unit
+// direction dir.
+func (sh SH9) Eval(dir [3]float32) [3]float32 {
+	var c [3]float32
+	for i, b := range SHBasis(dir) {
+		for k := range c {
+			c[k] += sh[i][k] * b
+		}
+	}
+	return c
+}
+
+// Irradiance returns the light a white Lambertian surface facing normal
+// reflects in an environment with the coefficients sh.
+func (sh SH9) Irradiance(normal [3]float32) [3]float32 {
+	c := sh.Convolved().Eval(normal)
+	for k := range c {
+		if c[k] < 0 {
+			c[k] = 0
+		}
+	}
+	return c
+}
+
+// Vec4s returns the coefficients padded to four components, for a
+// uniform array.
+func (sh SH9) Vec4s() [][4]float32 {
+	v := make([][4]float32, len(sh))
+	for i, c := range sh {
+		v[i] = [4]float32{c[0], c[1], c[2], 0}
+	}
+	return v
+}
+
+// IrradianceCubemap returns a cube map of the irradiance for the
+// environment sh, of faces of size by size texels.
+func IrradianceCubemap(sh SH9, size int) *Cubemap {
+	convolved := sh.Convolved()
+	return CubemapFromFunc(size, func(dir [3]float32) [4]float32 {
+		c := convolved.Eval(dir)
+		return [4]float32{max0(c[0]), max0(c[1]), max0(c[2]), 1}
+	})
+}
+
+// PrefilterGGX returns the mip levels of a cube map of faces of size by
+// size texels, down to 1 by 1, of the environment c blurred by the GGX
+// distribution for roughness going from 0 at the first level to 1 at the
+// last. Each texel takes samples importance sampled directions, read
+// from a mip level of c that matches the solid angle of the sample, to
+// avoid noise.
+func PrefilterGGX(c *Cubemap, size, samples int) []*Cubemap {
+	src := c.MipChain()
+	levels := 1
+	for s := size; s > 1; s /= 2 {
+		levels++
+	}
+	mips := make([]*Cubemap, levels)
+	for level := range mips {
+		s := size >> uint(level)
+		if s < 1 {
+			s = 1
+		}
+		roughness := float32(0)
+		if levels > 1 {
+			roughness = float32(level) / float32(levels-1)
+		}
+		m := NewCubemap(s)
+		var wg sync.WaitGroup
+		for face := range m.Faces {
+			wg.Add(1)
+			go func(face int) {
+				defer wg.Done()
+				img := m.Faces[face]
+				for y := 0; y < s; y++ {
+					v := (float32(y)+0.5)/float32(s)*2 - 1
+					for x := 0; x < s; x++ {
+						u := (float32(x)+0.5)/float32(s)*2 - 1
+						n := normalize(CubeDirection(face, u, v))
+						img.Set(x, y, prefilterTexel(src, n, s, roughness, samples))
+					}
+				}
+			}(face)
+		}
+		wg.Wait()
+		mips[level] = m
+	}
+	return mips
+}
+
+// prefilterTexel returns the environment src blurred by the GGX lobe of
+// roughness around n, for a texel of a face of size texels. It assumes
+// the view direction is n, as the split sum approximation does.
+func prefilterTexel(src []*Cubemap, n [3]float32, size int, roughness float32, samples int) [4]float32 {
+	// The level of src with texels about the size of the output's.
+	base := float32(math.Log2(float64(src[0].Size) / float64(size)))
+	if roughness == 0 {
+		return sampleLod(src, n, base)
+	}
+	var (
+		t, b    = tangentBasis(n)
+		a       = roughness * roughness
+		saTexel = 4 * math.Pi / (6 * float64(src[0].Size) * float64(src[0].Size))
+		sum     [4]float32
+		weight  float32
+	)
+	for i := 0; i < samples; i++ {
+		xi0, xi1 := Hammersley(i, samples)
+		h := sampleGGX(xi0, xi1, a)
+		hw := [3]float32{
+			t[0]*h[0] + b[0]*h[1] + n[0]*h[2],
+			t[1]*h[0] + b[1]*h[1] + n[1]*h[2],
+			t[2]*h[0] + b[2]*h[1] + n[2]*h[2],
+		}
+		ndoth := h[2]
+		l := [3]float32{
+			2*ndoth*hw[0] - n[0],
+			2*ndoth*hw[1] - n[1],
+			2*ndoth*hw[2] - n[2],
+		}
+		ndotl := dot(n, l)
+		if ndotl <= 0 {
+			continue
+		}
+		// With the view along n, the pdf of l is D/4.
+		pdf := float64(ggx(ndoth, a)) / 4
+		saSample := 1 / (float64(samples)*pdf + 1e-6)
+		lod := float32(0.5*math.Log2(saSample/saTexel)) + 1
+		if lod < base {
+			lod = base
+		}
+		col := sampleLod(src, l, lod)
+		for k := range sum {
+			sum[k] += col[k] * ndotl
+		}
+		weight += ndotl
+	}
+	for k := range sum {
+		sum[k] /= weight
+	}
+	return sum
+}
+
+// sampleLod samples the mip chain src trilinearly at level lod.
+func sampleLod(src []*Cubemap, dir [3]float32, lod float32) [4]float32 {
+	if lod <= 0 {
+		return src[0].Sample(dir)
+	}
+	if lod >= float32(len(src)-1) {
+		return src[len(src)-1].Sample(dir)
+	}
+	i := int(lod)
+	f := lod - float32(i)
+	c0, c1 := src[i].Sample(dir), src[i+1].Sample(dir)
+	for k := range c0 {
+		c0[k] += (c1[k] - c0[k]) * f
+	}
+	return c0
+}
+
+// BRDFLUT returns the split sum lookup table of size by size texels: for
+// the cosine of the view angle along x and the roughness along y, the
+// scale in red and the bias in green that the Fresnel reflectance at
+// normal incidence is multiplied by and added to. Each texel takes
+// samples importance sampled directions.
+func BRDFLUT(size, samples int) *hdrimage.Image {
+	m := hdrimage.New(size, size)
+	for y := 0; y < size; y++ {
+		roughness := (float32(y) + 0.5) / float32(size)
+		for x := 0; x < size; x++ {
+			ndotv := (float32(x) + 0.5) / float32(size)
+			scale, bias := IntegrateBRDF(ndotv, roughness, samples)
+			m.Set(x, y, [4]float32{scale, bias, 0, 1})
+		}
+	}
+	return m
+}
+
+// IntegrateBRDF returns the scale and bias of the split sum for the
+// cosine of the view angle ndotv and roughness, from samples directions.
+func IntegrateBRDF(ndotv, roughness float32, samples int) (scale, bias float32) {
+	var (
+		v = [3]float32{float32(math.Sqrt(float64(1 - ndotv*ndotv))), 0, ndotv}
+		a = roughness * roughness
+		k = a / 2
+	)
+	for i := 0; i < samples; i++ {
+		xi0, xi1 := Hammersley(i, samples)
+		h := sampleGGX(xi0, xi1, a)
+		vdoth := dot(v, h)
+		l := [3]float32{2*vdoth*h[0] - v[0], 2*vdoth*h[1] - v[1], 2*vdoth*h[2] - v[2]}
+		ndotl, ndoth := l[2], h[2]
+		if ndotl <= 0 {
+			continue
+		}
+		g := smithG1(ndotv, k) * smithG1(ndotl, k)
+		vis := g * vdoth / (ndoth * ndotv)
+		fc := float32(math.Pow(float64(1-vdoth), 5))
+		scale += (1 - fc) * vis
+		bias += fc * vis
+	}
+	return scale / float32(samples), bias / float32(samples)
+}
+
+// Hammersley returns point i of a Hammersley set of n points in the unit
+// square.
+func Hammersley(i, n int) (float32, float32) {
+	return float32(i) / float32(n), float32(bits.Reverse32(uint32(i))) / (1 << 32)
+}
+
+// sampleGGX returns the half vector around +Z, for the GGX distribution
+// of alpha a, that the point xi0, xi1 in the unit square maps to.
+func sampleGGX(xi0, xi1, a float32) [3]float32 {
+	phi := 2 * math.Pi * float64(xi0)
+	cos := math.Sqrt((1 - float64(xi1)) / (1 + float64(a*a-1)*float64(xi1)))
+	sin := math.Sqrt(1 - cos*cos)
+	return [3]float32{float32(sin * math.Cos(phi)), float32(sin * math.Sin(phi)), float32(cos)}
+}
+
+// ggx returns the GGX normal distribution of alpha a.
+func ggx(ndoth, a float32) float32 {
+	a2 := a * a
+	d := ndoth*ndoth*(a2-1) + 1
+	return a2 / (math.Pi * d * d)
+}
+
+// smithG1 is Schlick's approximation of Smith's masking function.
+func smithG1(ndotx, k float32) float32 {
+	return ndotx / (ndotx*(1-k) + k)
+}
+
+// tangentBasis returns two unit vectors perpendicular to n and to each
+// other.
+func tangentBasis(n [3]float32) (t, b [3]float32) {
+	up := [3]float32{0, 1, 0}
+	if abs(n[1]) > 0.999 {
+		up = [3]float32{1, 0, 0}
+	}
+	t = normalize(cross(up, n))
+	return t, cross(n, t)
+}
+
+func dot(a, b [3]float32) float32 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross(a, b [3]float32) [3]float32 {
+	return [3]float32{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func max0(f float32) float32 {
+	if f < 0 {
+		return 0
+	}
+	return f
+}
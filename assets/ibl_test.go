@@ -0,0 +1,87 @@
+package assets
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b, tolerance float32) bool {
+	return math.Abs(float64(a-b)) <= float64(tolerance)
+}
+
+func TestIrradianceOfConstant(t *testing.T) {
+	col := [4]float32{0.5, 1, 2, 1}
+	sh := ProjectSH(CubemapFromFunc(16, func([3]float32) [4]float32 { return col }))
+	dirs := append(axes[:], normalize([3]float32{1, 1, 1}), normalize([3]float32{-1, 2, -3}))
+	for _, dir := range dirs {
+		got := sh.Irradiance(dir)
+		for k := range got {
+			if !near(got[k], col[k], 0.01*col[k]) {
+				t.Errorf("%v: irradiance %v, want %v", dir, got, col[:3])
+				break
+			}
+		}
+	}
+	// Only the constant band is left.
+	for i := 1; i < len(sh); i++ {
+		for k := range sh[i] {
+			if !near(sh[i][k], 0, 1e-4) {
+				t.Errorf("coefficient %d is %v, want 0", i, sh[i])
+				break
+			}
+		}
+	}
+}
+
+func TestIntegrateBRDF(t *testing.T) {
+	// At roughness 0 the lobe is a mirror, and the split sum is just
+	// Schlick's Fresnel of the view angle.
+	for _, ndotv := range []float32{1, 0.5, 0.1} {
+		scale, bias := IntegrateBRDF(ndotv, 0, 64)
+		fc := float32(math.Pow(float64(1-ndotv), 5))
+		if !near(scale, 1-fc, 1e-4) || !near(bias, fc, 1e-4) {
+			t.Errorf("roughness 0, NdotV %v: %v, %v; want %v, %v", ndotv, scale, bias, 1-fc, fc)
+		}
+	}
+
+	// Karis's fitted approximation of the table ("Physically Based
+	// Shading on Mobile", 2014) is close enough to check against.
+	approx := func(ndotv, roughness float64) (float32, float32) {
+		r := [4]float64{1 - roughness, 0.0425 - 0.0275*roughness, 1.04 - 0.572*roughness, 0.022*roughness - 0.04}
+		a004 := math.Min(r[0]*r[0], math.Exp2(-9.28*ndotv))*r[0] + r[1]
+		return float32(-1.04*a004 + r[2]), float32(1.04*a004 + r[3])
+	}
+	scale, bias := IntegrateBRDF(0.5, 0.5, 1024)
+	wantScale, wantBias := approx(0.5, 0.5)
+	if !near(scale, wantScale, 0.03) || !near(bias, wantBias, 0.03) {
+		t.Errorf("roughness 0.5, NdotV 0.5: %v, %v; want about %v, %v", scale, bias, wantScale, wantBias)
+	}
+}
+
+func TestPrefilterGGX(t *testing.T) {
+	env := CubemapFromFunc(8, func(dir [3]float32) [4]float32 {
+		return [4]float32{dir[0] + 1, dir[1] + 1, dir[2] + 1, 1}
+	})
+	mips := PrefilterGGX(env, env.Size, 16)
+	if len(mips) != 4 {
+		t.Fatalf("%d levels, want 4", len(mips))
+	}
+	for i, m := range mips {
+		if want := env.Size >> uint(i); m.Size != want {
+			t.Errorf("level %d is %d texels, want %d", i, m.Size, want)
+		}
+	}
+	// The first level, at roughness 0, is the input.
+	for face, img := range env.Faces {
+		for y := 0; y < env.Size; y++ {
+			for x := 0; x < env.Size; x++ {
+				got, want := mips[0].Faces[face].At(x, y), img.At(x, y)
+				for k := range got {
+					if !near(got[k], want[k], 1e-4) {
+						t.Fatalf("face %d texel %d, %d: %v, want %v", face, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
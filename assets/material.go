@@ -0,0 +1,326 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+// Material is a program together with the textures, uniform values and
+// render state it is drawn with. Materials are described in JSON files
+// under materials/, for example:
+//
+//	{
+//		"vs": "vs_bump",
+//		"vs_instanced": "vs_bump_instanced",
+//		"fs": "fs_bump",
+//		"state": ["rgb_write", "alpha_write", "depth_write", "depth_test_less", "msaa"],
+//		"textures": [
+//			{"sampler": "u_texColor", "stage": 0, "file": "fieldstone-rgba.dds"},
+//			{"sampler": "u_texNormal", "stage": 1, "file": "fieldstone-n.dds", "flags": ["min_point"]}
+//		],
+//		"uniforms": [
+//			{"name": "u_tint", "type": "vec4", "value": [1, 1, 1, 1]}
+//		]
+//	}
+//
+// Unknown keys are rejected, samplers and uniforms are checked against
+// the shaders' uniform tables, and textures must exist when the material
+// is loaded.
+type Material struct {
+	Name    string
+	Program bgfx.Program
+
+	// InstancedProgram uses the instanced vertex shader, which reads
+	// a transform from instance data. It is only loaded if instancing
+	// is supported, as reported by Instanced.
+	InstancedProgram bgfx.Program
+	Instanced        bool
+	Textures         []MaterialTexture
+	Uniforms         []MaterialUniform
+	State            bgfx.State
+}
+
+// MaterialTexture is a texture bound to a sampler stage.
+type MaterialTexture struct {
+	Stage   uint8
+	Sampler bgfx.Uniform
+	Texture bgfx.Texture
+}
+
+// MaterialUniform is a uniform set to a fixed value.
+type MaterialUniform struct {
+	Uniform bgfx.Uniform
+	Value   []float32
+	Num     int
+}
+
+type materialDesc struct {
+	VS          string `json:"vs"`
+	VSInstanced string `json:"vs_instanced"`
+	FS          string `json:"fs"`
+	State       []string
+	Textures    []struct {
+		Sampler string
+		Stage   uint8
+		File    string
+		Flags   []string
+	}
+	Uniforms []struct {
+		Name  string
+		Type  string
+		Value []float32
+	}
+}
+
+var stateNames = map[string]bgfx.State{
+	"default":         bgfx.StateDefault,
+	"rgb_write":       bgfx.StateRGBWrite,
+	"alpha_write":     bgfx.StateAlphaWrite,
+	"depth_write":     bgfx.StateDepthWrite,
+	"depth_test_less": bgfx.StateDepthTestLess,
+	"cull_cw":         bgfx.StateCullCW,
+	"cull_ccw":        bgfx.StateCullCCW,
+	"msaa":            bgfx.StateMSAA,
+	"blend_alpha":     bgfx.StateBlendAlpha(),
+	"blend_add":       bgfx.StateBlendAdd(),
+}
+
+var textureFlagNames = map[string]bgfx.TextureFlags{
+	"u_mirror":        bgfx.TextureUMirror,
+	"u_clamp":         bgfx.TextureUClamp,
+	"v_mirror":        bgfx.TextureVMirror,
+	"v_clamp":         bgfx.TextureVClamp,
+	"w_mirror":        bgfx.TextureWMirror,
+	"w_clamp":         bgfx.TextureWClamp,
+	"min_point":       bgfx.TextureMinPoint,
+	"min_anisotropic": bgfx.TextureMinAnisotropic,
+	"mag_point":       bgfx.TextureMagPoint,
+	"mag_anisotropic": bgfx.TextureMagAnisotropic,
+	"mip_point":       bgfx.TextureMipPoint,
+}
+
+var uniformTypeNames = map[string]struct {
+	shader ShaderUniformType
+	bgfx   bgfx.UniformType
+}{
+	"float": {ShaderUniform1f, bgfx.Uniform1f},
+	"vec2":  {ShaderUniform2fv, bgfx.Uniform2fv},
+	"vec3":  {ShaderUniform3fv, bgfx.Uniform3fv},
+	"vec4":  {ShaderUniform4fv, bgfx.Uniform4fv},
+	"mat3":  {ShaderUniform3x3fv, bgfx.Uniform3x3fv},
+	"mat4":  {ShaderUniform4x4fv, bgfx.Uniform4x4fv},
+}
+
+// LoadMaterial loads materials/<name>.json, along with its programs and
+// textures. The instanced program is only loaded if there is an
+// instanced vertex shader and instancing is supported.
+func LoadMaterial(name string) *Material {
+	m, err := loadMaterial(name)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return m
+}
+
+func loadMaterial(name string) (*Material, error) {
+	c, err := checkMaterial(name, bgfx.Caps().Supported&bgfx.CapsInstancing != 0)
+	if err != nil {
+		return nil, err
+	}
+	m := &Material{Name: name, State: c.state, Instanced: c.instanced}
+	fsData := c.shaders[1]
+	m.Program = bgfx.CreateProgram(bgfx.CreateShader(c.shaders[0]), bgfx.CreateShader(fsData), true)
+	if m.Instanced {
+		m.InstancedProgram = bgfx.CreateProgram(bgfx.CreateShader(c.shaders[2]), bgfx.CreateShader(fsData), true)
+	}
+	for i, t := range c.desc.Textures {
+		m.Textures = append(m.Textures, MaterialTexture{
+			Stage:   t.Stage,
+			Sampler: bgfx.CreateUniform(t.Sampler, bgfx.Uniform1iv, 1),
+			Texture: LoadTexture(t.File, c.flags[i]),
+		})
+	}
+	for i, d := range c.desc.Uniforms {
+		typ := uniformTypeNames[d.Type]
+		m.Uniforms = append(m.Uniforms, MaterialUniform{
+			Uniform: bgfx.CreateUniform(d.Name, typ.bgfx, c.nums[i]),
+			Value:   d.Value,
+			Num:     c.nums[i],
+		})
+	}
+	return m, nil
+}
+
+// checkedMaterial is a material description that has been checked
+// against its shaders, with everything needed to create it.
+type checkedMaterial struct {
+	desc      materialDesc
+	shaders   [][]byte // vs, fs, and the instanced vs if instanced
+	instanced bool
+	state     bgfx.State
+	flags     []bgfx.TextureFlags
+	nums      []int
+}
+
+// checkMaterial reads materials/<name>.json and checks it against the
+// shaders' uniform tables and the textures directory, without creating
+// any resources.
+func checkMaterial(name string, instancing bool) (*checkedMaterial, error) {
+	f, err := Open(filepath.Join("materials", name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var c checkedMaterial
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c.desc); err != nil {
+		return nil, fmt.Errorf("assets: material %s: %v", name, err)
+	}
+	desc := &c.desc
+	bad := func(format string, args ...interface{}) error {
+		return fmt.Errorf("assets: material %s: %s", name, fmt.Sprintf(format, args...))
+	}
+
+	if desc.VS == "" || desc.FS == "" {
+		return nil, bad("needs both vs and fs")
+	}
+	names := []string{desc.VS, desc.FS}
+	if desc.VSInstanced != "" && instancing {
+		names = append(names, desc.VSInstanced)
+		c.instanced = true
+	}
+	table := make(map[string]ShaderUniform)
+	for _, s := range names {
+		data, err := readShader(s)
+		if err != nil {
+			return nil, err
+		}
+		uniforms, err := parseShaderUniforms(s, data)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range uniforms {
+			table[u.Name] = u
+		}
+		c.shaders = append(c.shaders, data)
+	}
+
+	for _, s := range desc.State {
+		state, ok := stateNames[s]
+		if !ok {
+			return nil, bad("unknown state %q", s)
+		}
+		c.state |= state
+	}
+
+	stages := make(map[uint8]string)
+	c.flags = make([]bgfx.TextureFlags, len(desc.Textures))
+	for i, t := range desc.Textures {
+		u, ok := table[t.Sampler]
+		if !ok {
+			return nil, bad("sampler %s is not used by the shaders", t.Sampler)
+		}
+		if !u.Type.IsSampler() {
+			return nil, bad("%s is a %v, not a sampler", t.Sampler, u.Type)
+		}
+		if other, ok := stages[t.Stage]; ok {
+			return nil, bad("%s and %s both use stage %d", other, t.Sampler, t.Stage)
+		}
+		stages[t.Stage] = t.Sampler
+		for _, s := range t.Flags {
+			flag, ok := textureFlagNames[s]
+			if !ok {
+				return nil, bad("unknown texture flag %q", s)
+			}
+			c.flags[i] |= flag
+		}
+		tf, err := Open(filepath.Join("textures", t.File))
+		if err != nil {
+			return nil, bad("%s: %v", t.Sampler, err)
+		}
+		tf.Close()
+	}
+	c.nums = make([]int, len(desc.Uniforms))
+	for i, d := range desc.Uniforms {
+		typ, ok := uniformTypeNames[d.Type]
+		if !ok {
+			return nil, bad("%s has unknown type %q", d.Name, d.Type)
+		}
+		u, ok := table[d.Name]
+		if !ok {
+			return nil, bad("uniform %s is not used by the shaders", d.Name)
+		}
+		if u.Type != typ.shader {
+			return nil, bad("%s is a %v in the shaders, not a %s", d.Name, u.Type, d.Type)
+		}
+		n := typ.shader.Floats()
+		if len(d.Value) == 0 || len(d.Value)%n != 0 {
+			return nil, bad("%s needs a multiple of %d values, not %d", d.Name, n, len(d.Value))
+		}
+		c.nums[i] = len(d.Value) / n
+		if c.nums[i] > u.Num {
+			return nil, bad("%s has %d elements in the shaders, not %d", d.Name, u.Num, c.nums[i])
+		}
+	}
+	return &c, nil
+}
+
+// Apply sets the material's textures and uniforms for the next draw.
+func (m *Material) Apply() {
+	m.ApplyTo(render.Default)
+}
+
+// ApplyTo sets the material's textures and uniforms on r.
+func (m *Material) ApplyTo(r render.Renderer) {
+	for _, t := range m.Textures {
+		r.SetTexture(t.Stage, t.Sampler, t.Texture)
+	}
+	for i := range m.Uniforms {
+		u := &m.Uniforms[i]
+		r.SetUniform(u.Uniform, u.Value, u.Num)
+	}
+}
+
+// Destroy destroys the material's program, textures and uniforms.
+func (m *Material) Destroy() {
+	bgfx.DestroyProgram(m.Program)
+	if m.Instanced {
+		bgfx.DestroyProgram(m.InstancedProgram)
+	}
+	for _, t := range m.Textures {
+		bgfx.DestroyUniform(t.Sampler)
+		bgfx.DestroyTexture(t.Texture)
+	}
+	for _, u := range m.Uniforms {
+		bgfx.DestroyUniform(u.Uniform)
+	}
+}
+
+// SubmitMaterial draws the mesh with mat's program and state, applying
+// the material for each of the mesh's draws.
+func (m Mesh) SubmitMaterial(view bgfx.ViewID, mat *Material, mtx [16]float32) {
+	m.SubmitMaterialTo(render.Default, view, mat, mtx)
+}
+
+// SubmitMaterialTo is like SubmitMaterial, but draws through r.
+func (m Mesh) SubmitMaterialTo(r render.Renderer, view bgfx.ViewID, mat *Material, mtx [16]float32) {
+	m.SubmitTo(materialRenderer{r, mat}, view, mat.Program, mtx, mat.State)
+}
+
+// materialRenderer applies a material whenever a program is set, which
+// Mesh does once per draw.
+type materialRenderer struct {
+	render.Renderer
+	mat *Material
+}
+
+func (r materialRenderer) SetProgram(prog bgfx.Program) {
+	r.Renderer.SetProgram(prog)
+	r.mat.ApplyTo(r.Renderer)
+}
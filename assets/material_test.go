@@ -0,0 +1,103 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useAssets searches only the assets directory of this package, and
+// dir before it if dir is not empty. It returns a function that
+// restores the directories searched before.
+func useAssets(dir string) (restore func()) {
+	old := dirs
+	dirs = []string{"."}
+	if dir != "" {
+		dirs = []string{dir, "."}
+	}
+	return func() { dirs = old }
+}
+
+// checkTestMaterial checks a material described by desc, using the
+// shipped shaders and textures.
+func checkTestMaterial(t *testing.T, desc string) error {
+	dir, err := ioutil.TempDir("", "material")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "materials"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "materials", "test.json"), []byte(desc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer useAssets(dir)()
+	_, err = checkMaterial("test", true)
+	return err
+}
+
+func TestShippedMaterials(t *testing.T) {
+	defer useAssets("")()
+	files, err := filepath.Glob("materials/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no materials: %v", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		for _, instancing := range []bool{false, true} {
+			c, err := checkMaterial(name, instancing)
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if want := instancing && c.desc.VSInstanced != ""; c.instanced != want {
+				t.Errorf("%s: instanced %v with instancing %v", name, c.instanced, instancing)
+			}
+		}
+	}
+}
+
+func TestMaterialUnknownKey(t *testing.T) {
+	err := checkTestMaterial(t, `{"vs": "vs_cubes", "fs": "fs_cubes", "stat": ["default"]}`)
+	if err == nil || !strings.Contains(err.Error(), "stat") {
+		t.Errorf("got %v, want an error for the unknown key", err)
+	}
+}
+
+func TestMaterialMissingTexture(t *testing.T) {
+	err := checkTestMaterial(t, `{
+		"vs": "vs_bump", "fs": "fs_bump",
+		"textures": [{"sampler": "u_texColor", "stage": 0, "file": "missing.dds"}]
+	}`)
+	if err == nil || !strings.Contains(err.Error(), "missing.dds") {
+		t.Errorf("got %v, want an error for the missing texture", err)
+	}
+}
+
+func TestMaterialUniformArity(t *testing.T) {
+	for _, value := range []string{
+		`[]`,
+		`[1, 2, 3]`,          // not a whole vec4
+		`[1, 2, 3, 4, 5, 6]`, // nor this
+		`[1, 2, 3, 4, 1, 2, 3, 4, 1, 2, 3, 4, 1, 2, 3, 4, 1, 2, 3, 4]`, // five of four
+	} {
+		err := checkTestMaterial(t, `{
+			"vs": "vs_bump", "fs": "fs_bump",
+			"uniforms": [{"name": "u_lightPosRadius", "type": "vec4", "value": `+value+`}]
+		}`)
+		if err == nil || !strings.Contains(err.Error(), "u_lightPosRadius") {
+			t.Errorf("value %s: got %v, want an error for u_lightPosRadius", value, err)
+		}
+	}
+	// Whole elements up to the array length are fine.
+	err := checkTestMaterial(t, `{
+		"vs": "vs_bump", "fs": "fs_bump",
+		"uniforms": [{"name": "u_lightPosRadius", "type": "vec4", "value": [1, 2, 3, 4, 5, 6, 7, 8]}]
+	}`)
+	if err != nil {
+		t.Error(err)
+	}
+}
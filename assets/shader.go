@@ -0,0 +1,138 @@
+package assets
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// ShaderUniform is an entry in a compiled shader's uniform table.
+type ShaderUniform struct {
+	Name string
+	Type ShaderUniformType
+	Num  int // array length
+}
+
+// ShaderUniformType is a uniform type as stored in compiled shaders.
+// It is not the same as bgfx.UniformType, which has no End.
+type ShaderUniformType uint8
+
+const (
+	ShaderUniform1i ShaderUniformType = iota
+	ShaderUniform1f
+	ShaderUniformEnd
+	ShaderUniform1iv
+	ShaderUniform1fv
+	ShaderUniform2fv
+	ShaderUniform3fv
+	ShaderUniform4fv
+	ShaderUniform3x3fv
+	ShaderUniform4x4fv
+)
+
+// Floats returns how many floats one element of the type holds, or 0
+// for the integer types.
+func (t ShaderUniformType) Floats() int {
+	switch t {
+	case ShaderUniform1f, ShaderUniform1fv:
+		return 1
+	case ShaderUniform2fv:
+		return 2
+	case ShaderUniform3fv:
+		return 3
+	case ShaderUniform4fv:
+		return 4
+	case ShaderUniform3x3fv:
+		return 9
+	case ShaderUniform4x4fv:
+		return 16
+	}
+	return 0
+}
+
+// IsSampler reports whether the type is one used for texture samplers.
+func (t ShaderUniformType) IsSampler() bool {
+	return t == ShaderUniform1i || t == ShaderUniform1iv
+}
+
+func (t ShaderUniformType) String() string {
+	switch t {
+	case ShaderUniform1i:
+		return "int"
+	case ShaderUniform1f:
+		return "float"
+	case ShaderUniform1iv:
+		return "int[]"
+	case ShaderUniform1fv:
+		return "float[]"
+	case ShaderUniform2fv:
+		return "vec2"
+	case ShaderUniform3fv:
+		return "vec3"
+	case ShaderUniform4fv:
+		return "vec4"
+	case ShaderUniform3x3fv:
+		return "mat3"
+	case ShaderUniform4x4fv:
+		return "mat4"
+	}
+	return fmt.Sprintf("ShaderUniformType(%d)", uint8(t))
+}
+
+// ShaderUniforms returns the uniform table of the named shader.
+func ShaderUniforms(name string) ([]ShaderUniform, error) {
+	data, err := readShader(name)
+	if err != nil {
+		return nil, err
+	}
+	return parseShaderUniforms(name, data)
+}
+
+func readShader(name string) ([]byte, error) {
+	f, err := Open(filepath.Join("shaders/glsl", name+".bin"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// parseShaderUniforms reads the uniform table at the start of a compiled
+// shader. The layout is a four byte magic ("VSH" or "FSH" and a
+// version), a 32 bit hash, a 16 bit count, and then for each uniform a
+// length prefixed name, its type, array length, and register index and
+// count.
+func parseShaderUniforms(name string, data []byte) ([]ShaderUniform, error) {
+	bad := func(what string) error {
+		return fmt.Errorf("assets: shader %s: %s", name, what)
+	}
+	if len(data) < 10 {
+		return nil, bad("too short")
+	}
+	magic := string(data[:3])
+	if magic != "VSH" && magic != "FSH" {
+		return nil, bad("not a compiled shader")
+	}
+	count := int(binary.LittleEndian.Uint16(data[8:]))
+	data = data[10:]
+	uniforms := make([]ShaderUniform, 0, count)
+	for i := 0; i < count; i++ {
+		if len(data) < 1 {
+			return nil, bad(io.ErrUnexpectedEOF.Error())
+		}
+		n := int(data[0])
+		if len(data) < 1+n+6 {
+			return nil, bad(io.ErrUnexpectedEOF.Error())
+		}
+		uniforms = append(uniforms, ShaderUniform{
+			Name: string(data[1 : 1+n]),
+			// Newer versions flag fragment shader uniforms with 0x10.
+			Type: ShaderUniformType(data[1+n] &^ 0x10),
+			Num:  int(data[2+n]),
+		})
+		data = data[1+n+6:]
+	}
+	return uniforms, nil
+}
@@ -0,0 +1,44 @@
+// hash: vs_hdr_lumavg
+// variant: fs_hdr_adapt_float HDR_FLOAT
+//
+// Moves the adapted luminance of the previous frame toward the scene's
+// average luminance, as hdr.Adaptation.Adapt does. u_adapt is how far to
+// move toward a brighter and a darker scene, and whether u_texAdapted
+// is valid. Both luminances are RGBE encoded, like the luminance chain,
+// or floats with HDR_FLOAT.
+varying vec2 v_texcoord0;
+uniform vec4 u_adapt;
+uniform sampler2D u_texLum;
+uniform sampler2D u_texAdapted;
+
+float decodeLum(vec4 c)
+{
+#ifdef HDR_FLOAT
+  return c.x;
+#else
+  return c.x * exp2(c.w * 255.0 - 128.0);
+#endif
+}
+
+vec4 encodeLum(float lum)
+{
+#ifdef HDR_FLOAT
+  return vec4(lum, 0.0, 0.0, 1.0);
+#else
+  float exponent = ceil(log2(lum));
+  return vec4(lum / exp2(exponent), 0.0, 0.0, (exponent + 128.0) / 255.0);
+#endif
+}
+
+void main()
+{
+  float lum = decodeLum(texture2D(u_texLum, v_texcoord0));
+  float adapted = lum;
+  if (u_adapt.z > 0.5)
+  {
+    float prev = decodeLum(texture2D(u_texAdapted, v_texcoord0));
+    float rate = lum > prev ? u_adapt.x : u_adapt.y;
+    adapted = prev + (lum - prev) * rate;
+  }
+  gl_FragColor = encodeLum(max(adapted, 1e-6));
+}
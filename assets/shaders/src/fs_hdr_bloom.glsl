@@ -0,0 +1,44 @@
+// hash: vs_hdr_lumavg
+// variant: fs_hdr_bloom_float HDR_FLOAT
+//
+// One step of the bloom chain: the weighted sum of up to 16 taps of
+// u_texSource, plus u_texBase scaled by u_bloomTaps.y. u_bloomOffset
+// holds each tap's offset and weight, and u_bloomTaps.x how many there
+// are. Colors are RGBE encoded, or floats with HDR_FLOAT.
+varying vec2 v_texcoord0;
+uniform vec4 u_bloomOffset[16];
+uniform vec4 u_bloomTaps;
+uniform sampler2D u_texSource;
+uniform sampler2D u_texBase;
+
+vec3 decodeHDR(vec4 c)
+{
+#ifdef HDR_FLOAT
+  return c.xyz;
+#else
+  return c.xyz * exp2(c.w * 255.0 - 128.0);
+#endif
+}
+
+vec4 encodeHDR(vec3 rgb)
+{
+#ifdef HDR_FLOAT
+  return vec4(rgb, 1.0);
+#else
+  float exponent = ceil(log2(max(max(max(rgb.x, rgb.y), rgb.z), 1e-6)));
+  return vec4(rgb / exp2(exponent), (exponent + 128.0) / 255.0);
+#endif
+}
+
+void main()
+{
+  vec3 sum = decodeHDR(texture2D(u_texBase, v_texcoord0)) * u_bloomTaps.y;
+  for (int i = 0; i < 16; i++)
+  {
+    if (float(i) >= u_bloomTaps.x)
+      break;
+    vec4 tap = u_bloomOffset[i];
+    sum += decodeHDR(texture2D(u_texSource, v_texcoord0 + tap.xy)) * tap.z;
+  }
+  gl_FragColor = encodeHDR(sum);
+}
@@ -0,0 +1,50 @@
+// hash: vs_hdr_lumavg
+// variant: fs_hdr_bloom_prefilter_float HDR_FLOAT
+//
+// The first step of the bloom chain: downsamples the scene like
+// fs_hdr_bloom, and keeps only what is brighter than the threshold,
+// u_bloom.x, easing in over a soft knee of width u_bloom.y.
+varying vec2 v_texcoord0;
+uniform vec4 u_bloomOffset[16];
+uniform vec4 u_bloomTaps;
+uniform vec4 u_bloom;
+uniform sampler2D u_texSource;
+
+vec3 decodeHDR(vec4 c)
+{
+#ifdef HDR_FLOAT
+  return c.xyz;
+#else
+  return c.xyz * exp2(c.w * 255.0 - 128.0);
+#endif
+}
+
+vec4 encodeHDR(vec3 rgb)
+{
+#ifdef HDR_FLOAT
+  return vec4(rgb, 1.0);
+#else
+  float exponent = ceil(log2(max(max(max(rgb.x, rgb.y), rgb.z), 1e-6)));
+  return vec4(rgb / exp2(exponent), (exponent + 128.0) / 255.0);
+#endif
+}
+
+void main()
+{
+  vec3 sum = vec3(0.0, 0.0, 0.0);
+  for (int i = 0; i < 16; i++)
+  {
+    if (float(i) >= u_bloomTaps.x)
+      break;
+    vec4 tap = u_bloomOffset[i];
+    sum += decodeHDR(texture2D(u_texSource, v_texcoord0 + tap.xy)) * tap.z;
+  }
+
+  float threshold = u_bloom.x;
+  float knee = u_bloom.y;
+  float brightness = max(max(sum.x, sum.y), sum.z);
+  float soft = clamp(brightness - threshold + knee, 0.0, 2.0 * knee);
+  soft = soft * soft / (4.0 * knee + 1e-5);
+  float contribution = max(soft, brightness - threshold) / max(brightness, 1e-5);
+  gl_FragColor = encodeHDR(sum * contribution);
+}
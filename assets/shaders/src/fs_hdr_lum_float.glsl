@@ -0,0 +1,18 @@
+// hash: vs_hdr_lum
+//
+// fs_hdr_lum for floating point targets: the average luminance of 3x3
+// texels of the scene, in the red channel.
+varying vec2 v_texcoord0;
+uniform vec4 u_offset[16];
+uniform sampler2D u_texColor;
+
+void main()
+{
+  float sum = 0.0;
+  for (int i = 0; i < 9; i++)
+  {
+    vec3 rgb = texture2D(u_texColor, v_texcoord0 + u_offset[i].xy).xyz;
+    sum += dot(rgb, vec3(0.212673, 0.715152, 0.072175));
+  }
+  gl_FragColor = vec4(sum / 9.0, 0.0, 0.0, 1.0);
+}
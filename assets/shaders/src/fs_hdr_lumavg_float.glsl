@@ -0,0 +1,17 @@
+// hash: vs_hdr_lumavg
+//
+// fs_hdr_lumavg for floating point targets: the average of 4x4 texels
+// of luminance, in the red channel.
+varying vec2 v_texcoord0;
+uniform vec4 u_offset[16];
+uniform sampler2D u_texColor;
+
+void main()
+{
+  float sum = 0.0;
+  for (int i = 0; i < 16; i++)
+  {
+    sum += texture2D(u_texColor, v_texcoord0 + u_offset[i].xy).x;
+  }
+  gl_FragColor = vec4(sum / 16.0, 0.0, 0.0, 1.0);
+}
@@ -0,0 +1,36 @@
+// hash: vs_hdr_mesh
+//
+// fs_hdr_mesh for floating point targets: the shaded mesh, unencoded.
+varying vec3 v_normal;
+varying vec3 v_pos;
+varying vec3 v_view;
+uniform float u_time;
+uniform samplerCube u_texCube;
+
+vec4 lit(float ndotl, float rdotv, float m)
+{
+  float diff = max(0.0, ndotl);
+  float spec = step(0.0, ndotl) * max(0.0, rdotv * m);
+  return vec4(1.0, diff, spec, 1.0);
+}
+
+void main()
+{
+  vec3 lightDir = vec3(0.0, 0.0, -1.0);
+  vec3 normal = normalize(v_normal);
+  vec3 view = normalize(v_view);
+  float ndotl = dot(normal, lightDir);
+  vec3 reflected = lightDir - 2.0 * ndotl * normal;
+  vec4 lc = lit(ndotl, dot(reflected, view), 1.0);
+  float fres = max(0.2 + 0.8 * pow(1.0 - ndotl, 5.0), 0.0);
+
+  float t = ((sin(v_pos.x * 3.0 + u_time) * 0.3 + 0.7)
+    + (cos(v_pos.y * 3.0 + u_time) * 0.4 + 0.6)
+    + (cos(v_pos.z * 3.0 + u_time) * 0.2 + 0.8)) * 3.14159;
+  vec3 color = vec3(sin(t * 8.0), sin(t * 4.0), sin(t * 2.0)) * 0.4 + 0.6;
+
+  vec3 n = -normal;
+  vec3 env = textureCube(u_texCube, view - 2.0 * dot(n, view) * n).xyz;
+  vec3 rgb = color * env * lc.y + fres * pow(lc.z, 128.0);
+  gl_FragColor = vec4(rgb, 1.0);
+}
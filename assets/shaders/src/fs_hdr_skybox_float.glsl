@@ -0,0 +1,13 @@
+// hash: vs_hdr_skybox
+//
+// fs_hdr_skybox for floating point targets: the cube map, unencoded.
+varying vec2 v_texcoord0;
+uniform samplerCube u_texCube;
+uniform mat4 u_mtx;
+
+void main()
+{
+  vec3 dir = vec3(v_texcoord0 * 2.0 - 1.0, 1.0);
+  dir = normalize((u_mtx * vec4(dir, 0.0)).xyz);
+  gl_FragColor = vec4(textureCube(u_texCube, dir).xyz, 1.0);
+}
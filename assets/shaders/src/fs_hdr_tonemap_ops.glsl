@@ -0,0 +1,131 @@
+// hash: vs_hdr_tonemap
+// variant: fs_hdr_tonemap_ops_float HDR_FLOAT
+//
+// Like the tonemap shader of bgfx's HDR example, with a choice of
+// operator and exposure, and the bloom chain's result added before
+// tonemapping. The scene, luminance and bloom are RGBE encoded, or
+// floats with HDR_FLOAT.
+// u_tonemap is middle gray and white squared.
+// u_tonemapOp is the operator, the exposure scale, and whether to scale
+// by the average luminance as well (auto exposure).
+// u_bloom.z is the bloom intensity. The blur offsets of vs_hdr_tonemap
+// are not used.
+varying vec2 v_texcoord0;
+varying vec4 v_texcoord1;
+varying vec4 v_texcoord2;
+varying vec4 v_texcoord3;
+varying vec4 v_texcoord4;
+uniform vec4 u_tonemap;
+uniform vec4 u_tonemapOp;
+uniform sampler2D u_texColor;
+uniform sampler2D u_texLum;
+uniform vec4 u_bloom;
+uniform sampler2D u_texBloom;
+
+vec3 decodeHDR(vec4 c)
+{
+#ifdef HDR_FLOAT
+  return c.xyz;
+#else
+  return c.xyz * exp2(c.w * 255.0 - 128.0);
+#endif
+}
+
+float luminance(vec3 rgb)
+{
+  return dot(rgb, vec3(0.212673, 0.715152, 0.072175));
+}
+
+// Reinhard et al., "Photographic Tone Reproduction for Digital Images",
+// on luminance, without and with a white point.
+vec3 reinhard(vec3 rgb)
+{
+  float l = luminance(rgb);
+  return rgb / (1.0 + l);
+}
+
+vec3 reinhardExtended(vec3 rgb, float white2)
+{
+  float l = luminance(rgb);
+  return rgb * (1.0 + l / white2) / (1.0 + l);
+}
+
+// Krzysztof Narkowicz's fit of the ACES filmic curve.
+vec3 acesFilmic(vec3 x)
+{
+  return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+// John Hable's filmic curve from Uncharted 2.
+vec3 hable(vec3 x)
+{
+  const float A = 0.15;
+  const float B = 0.50;
+  const float C = 0.10;
+  const float D = 0.20;
+  const float E = 0.02;
+  const float F = 0.30;
+  return (x * (A * x + C * B) + D * E) / (x * (A * x + B) + D * F) - E / F;
+}
+
+vec3 uncharted2(vec3 rgb)
+{
+  const float W = 11.2;
+  return hable(2.0 * rgb) / hable(vec3(W, W, W));
+}
+
+// Troy Sobotka's AgX, with Benjamin Wrensch's polynomial fit of the
+// default contrast curve.
+vec3 agxContrast(vec3 x)
+{
+  vec3 x2 = x * x;
+  vec3 x4 = x2 * x2;
+  return 15.5 * x4 * x2 - 40.14 * x4 * x + 31.96 * x4 - 6.868 * x2 * x
+    + 0.4298 * x2 + 0.1191 * x - 0.00232;
+}
+
+vec3 agx(vec3 rgb)
+{
+  const float minEV = -12.47393;
+  const float maxEV = 4.026069;
+  mat3 inset = mat3(
+    0.842479062253094, 0.0423282422610123, 0.0423756549057051,
+    0.0784335999999992, 0.878468636469772, 0.0784336,
+    0.0792237451477643, 0.0791661274605434, 0.879142973793104);
+  mat3 outset = mat3(
+    1.19687900512017, -0.0528968517574562, -0.0529716355144438,
+    -0.0980208811401368, 1.15190312990417, -0.0980434501171241,
+    -0.0990297440797205, -0.0989611768448433, 1.15107367264116);
+  vec3 x = clamp(log2(max(inset * rgb, 1e-10)), minEV, maxEV);
+  x = agxContrast((x - minEV) / (maxEV - minEV));
+  // Back to linear, so that it is gamma corrected like the others.
+  return pow(max(outset * x, 0.0), vec3(2.2, 2.2, 2.2));
+}
+
+vec3 tonemap(vec3 rgb, float op)
+{
+  if (op < 0.5)
+    return reinhard(rgb);
+  if (op < 1.5)
+    return reinhardExtended(rgb, u_tonemap.y);
+  if (op < 2.5)
+    return acesFilmic(rgb);
+  if (op < 3.5)
+    return uncharted2(rgb);
+  return agx(rgb);
+}
+
+void main()
+{
+  float exposure = u_tonemapOp.y;
+  if (u_tonemapOp.z > 0.5)
+  {
+    float lum = decodeHDR(texture2D(u_texLum, v_texcoord0)).x;
+    exposure *= u_tonemap.x / (clamp(lum, 0.1, 0.7) + 0.0001);
+  }
+  vec3 rgb = decodeHDR(texture2D(u_texColor, v_texcoord0));
+  rgb += decodeHDR(texture2D(u_texBloom, v_texcoord0)) * u_bloom.z;
+  rgb = tonemap(rgb * exposure, u_tonemapOp.x);
+
+  gl_FragColor = vec4(pow(abs(rgb), vec3(0.454545, 0.454545, 0.454545)), 1.0);
+}
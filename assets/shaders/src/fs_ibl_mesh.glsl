@@ -0,0 +1,61 @@
+// fs_ibl_mesh: a metallic-roughness material lit by an environment. The
+// diffuse light comes from spherical harmonics or an irradiance cube
+// map, and the specular light from a prefiltered cube map, scaled and
+// biased by the split sum BRDF table. The result is exposed, tonemapped
+// and gamma corrected for the backbuffer.
+#extension GL_ARB_shader_texture_lod : enable
+varying vec3 v_normal;
+varying vec3 v_wpos;
+uniform samplerCube u_texSpecular;
+uniform samplerCube u_texIrradiance;
+uniform sampler2D u_texBrdf;
+uniform vec4 u_camPos;
+uniform vec4 u_albedo;
+uniform vec4 u_material; // metallic, roughness
+uniform vec4 u_ibl; // exposure, last specular level, irradiance cube (1) or SH (0), sky level
+uniform vec4 u_sh[9];
+
+vec3 shIrradiance(vec3 n)
+{
+  vec3 e = u_sh[0].xyz * 0.282095
+    + u_sh[1].xyz * 0.488603 * n.y
+    + u_sh[2].xyz * 0.488603 * n.z
+    + u_sh[3].xyz * 0.488603 * n.x
+    + u_sh[4].xyz * 1.092548 * n.x * n.y
+    + u_sh[5].xyz * 1.092548 * n.y * n.z
+    + u_sh[6].xyz * 0.315392 * (3.0 * n.z * n.z - 1.0)
+    + u_sh[7].xyz * 1.092548 * n.x * n.z
+    + u_sh[8].xyz * 0.546274 * (n.x * n.x - n.y * n.y);
+  return max(e, 0.0);
+}
+
+// Narkowicz's fit of the ACES filmic curve.
+vec3 tonemap(vec3 c)
+{
+  c *= u_ibl.x;
+  c = clamp((c * (2.51 * c + 0.03)) / (c * (2.43 * c + 0.59) + 0.14), 0.0, 1.0);
+  return pow(c, vec3(1.0 / 2.2));
+}
+
+void main()
+{
+  float metallic = u_material.x;
+  float roughness = u_material.y;
+  vec3 albedo = u_albedo.xyz;
+
+  vec3 n = normalize(v_normal);
+  vec3 v = normalize(u_camPos.xyz - v_wpos);
+  float ndotv = max(dot(n, v), 1e-4);
+  vec3 r = reflect(-v, n);
+
+  vec3 f0 = mix(vec3(0.04), albedo, metallic);
+  vec3 f = f0 + (max(vec3(1.0 - roughness), f0) - f0) * pow(1.0 - ndotv, 5.0);
+  vec3 irradiance = mix(shIrradiance(n), textureCube(u_texIrradiance, n).xyz, u_ibl.z);
+  vec3 diffuse = irradiance * albedo * (1.0 - f) * (1.0 - metallic);
+
+  vec3 prefiltered = textureCubeLod(u_texSpecular, r, roughness * u_ibl.y).xyz;
+  vec2 brdf = texture2D(u_texBrdf, vec2(ndotv, roughness)).xy;
+  vec3 specular = prefiltered * (f0 * brdf.x + brdf.y);
+
+  gl_FragColor = vec4(tonemap(diffuse + specular), 1.0);
+}
@@ -0,0 +1,22 @@
+// hash: vs_hdr_skybox
+//
+// fs_ibl_skybox: the environment behind fs_ibl_mesh, at a level of the
+// prefiltered cube map, exposed and tonemapped the same way.
+#extension GL_ARB_shader_texture_lod : enable
+varying vec2 v_texcoord0;
+uniform samplerCube u_texCube;
+uniform mat4 u_mtx;
+uniform vec4 u_ibl; // exposure, last specular level, irradiance cube (1) or SH (0), sky level
+
+vec3 tonemap(vec3 c)
+{
+  c *= u_ibl.x;
+  c = clamp((c * (2.51 * c + 0.03)) / (c * (2.43 * c + 0.59) + 0.14), 0.0, 1.0);
+  return pow(c, vec3(1.0 / 2.2));
+}
+
+void main()
+{
+  vec3 dir = normalize((u_mtx * vec4(v_texcoord0 * 2.0 - 1.0, 1.0, 0.0)).xyz);
+  gl_FragColor = vec4(tonemap(textureCubeLod(u_texCube, dir, u_ibl.w).xyz), 1.0);
+}
@@ -0,0 +1,12 @@
+// fs_light_volume: a faint shell in the light's color, brightest where
+// it is seen edge on, for additive blending.
+varying vec3 v_normal;
+varying vec3 v_view;
+uniform vec4 u_color;
+
+void main()
+{
+  float facing = abs(dot(normalize(v_normal), normalize(v_view)));
+  float rim = 1.0 - facing;
+  gl_FragColor = vec4(u_color.xyz * u_color.w * (0.1 + rim * rim), 1.0);
+}
@@ -0,0 +1,133 @@
+// fs_pbr: a metallic-roughness material, as in glTF, lit by up to eight
+// directional, point and spot lights and a constant ambient light. The
+// specular term is GGX with Smith's height correlated visibility and
+// Schlick's Fresnel. Albedo, normal, metal-rough and occlusion maps
+// multiply the material's factors; the albedo map is sRGB, roughness is
+// in the green channel and metalness in the blue channel of the
+// metal-rough map, and occlusion in the red channel of its map. The
+// result is exposed, tonemapped and gamma corrected for the backbuffer.
+varying vec3 v_bitangent;
+varying vec3 v_normal;
+varying vec3 v_tangent;
+varying vec2 v_texcoord0;
+varying vec3 v_wpos;
+uniform sampler2D u_texAlbedo;
+uniform sampler2D u_texNormal;
+uniform sampler2D u_texMetalRough;
+uniform sampler2D u_texOcclusion;
+uniform vec4 u_albedo;
+uniform vec4 u_material; // metallic, roughness, occlusion strength
+uniform vec4 u_emissive;
+uniform vec4 u_camPos;
+uniform vec4 u_ambient; // rgb, exposure
+uniform vec4 u_lightPosType[8]; // position, type (0 directional, 1 point, 2 spot)
+uniform vec4 u_lightDirRange[8]; // direction, range
+uniform vec4 u_lightColor[8];
+uniform vec4 u_lightCone[8]; // cos outer, 1 / (cos inner - cos outer)
+uniform vec4 u_lightCount;
+
+#define PI 3.14159265
+
+float distributionGGX(float ndoth, float a)
+{
+  float a2 = a * a;
+  float d = ndoth * ndoth * (a2 - 1.0) + 1.0;
+  return a2 / (PI * d * d);
+}
+
+float visibilitySmithGGX(float ndotv, float ndotl, float a)
+{
+  float a2 = a * a;
+  float gv = ndotl * sqrt(ndotv * ndotv * (1.0 - a2) + a2);
+  float gl = ndotv * sqrt(ndotl * ndotl * (1.0 - a2) + a2);
+  return 0.5 / max(gv + gl, 1e-5);
+}
+
+vec3 fresnelSchlick(vec3 f0, float vdoth)
+{
+  return f0 + (1.0 - f0) * pow(1.0 - vdoth, 5.0);
+}
+
+// attenuation returns how much of light i reaches a point, and the unit
+// vector l to it.
+float attenuation(int i, vec3 wpos, out vec3 l)
+{
+  vec4 posType = u_lightPosType[i];
+  vec4 dirRange = u_lightDirRange[i];
+  if (posType.w < 0.5)
+  {
+    l = -dirRange.xyz;
+    return 1.0;
+  }
+  vec3 d = posType.xyz - wpos;
+  float dist2 = dot(d, d);
+  l = d * inversesqrt(dist2);
+  // Inverse square, windowed to reach zero at the range.
+  float r = dist2 / (dirRange.w * dirRange.w);
+  float window = clamp(1.0 - r * r, 0.0, 1.0);
+  float att = window * window / max(dist2, 1e-4);
+  if (posType.w > 1.5)
+  {
+    vec4 cone = u_lightCone[i];
+    float spot = clamp((dot(-l, dirRange.xyz) - cone.x) * cone.y, 0.0, 1.0);
+    att *= spot * spot;
+  }
+  return att;
+}
+
+// Narkowicz's fit of the ACES filmic curve.
+vec3 tonemap(vec3 c)
+{
+  c *= u_ambient.w;
+  c = clamp((c * (2.51 * c + 0.03)) / (c * (2.43 * c + 0.59) + 0.14), 0.0, 1.0);
+  return pow(c, vec3(1.0 / 2.2));
+}
+
+void main()
+{
+  vec4 albedoMap = texture2D(u_texAlbedo, v_texcoord0);
+  vec3 albedo = pow(albedoMap.xyz, vec3(2.2)) * u_albedo.xyz;
+  vec4 metalRough = texture2D(u_texMetalRough, v_texcoord0);
+  float metallic = clamp(u_material.x * metalRough.z, 0.0, 1.0);
+  float roughness = clamp(u_material.y * metalRough.y, 0.045, 1.0);
+  float occlusion = mix(1.0, texture2D(u_texOcclusion, v_texcoord0).x, u_material.z);
+
+  vec3 tn;
+  tn.xy = texture2D(u_texNormal, v_texcoord0).xy * 2.0 - 1.0;
+  tn.z = sqrt(max(1.0 - dot(tn.xy, tn.xy), 0.0));
+  mat3 tbn = mat3(normalize(v_tangent), normalize(v_bitangent), normalize(v_normal));
+  vec3 n = normalize(tbn * tn);
+  vec3 v = normalize(u_camPos.xyz - v_wpos);
+  float ndotv = max(dot(n, v), 1e-4);
+
+  float a = roughness * roughness;
+  vec3 f0 = mix(vec3(0.04), albedo, metallic);
+  vec3 diffuseColor = albedo * (1.0 - metallic);
+
+  vec3 color = vec3(0.0);
+  for (int i = 0; i < 8; i++)
+  {
+    if (float(i) >= u_lightCount.x)
+    {
+      break;
+    }
+    vec3 l;
+    float att = attenuation(i, v_wpos, l);
+    float ndotl = dot(n, l);
+    if (att <= 0.0 || ndotl <= 0.0)
+    {
+      continue;
+    }
+    vec3 h = normalize(l + v);
+    float ndoth = max(dot(n, h), 0.0);
+    float vdoth = max(dot(v, h), 0.0);
+    vec3 f = fresnelSchlick(f0, vdoth);
+    vec3 specular = f * distributionGGX(ndoth, a) * visibilitySmithGGX(ndotv, ndotl, a);
+    vec3 diffuse = (1.0 - f) * diffuseColor / PI;
+    color += (diffuse + specular) * u_lightColor[i].xyz * att * ndotl;
+  }
+  color += u_ambient.xyz * (diffuseColor + f0 * 0.25) * occlusion;
+  color += u_emissive.xyz;
+
+  gl_FragColor = vec4(tonemap(color), albedoMap.w * u_albedo.w);
+}
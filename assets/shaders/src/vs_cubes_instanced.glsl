@@ -0,0 +1,18 @@
+// vs_cubes_instanced: vs_cubes with the model transform read from
+// instance data, for drawing many cubes in one call.
+// hash: vs_cubes
+attribute vec4 a_color0;
+attribute vec3 a_position;
+attribute vec4 i_data0;
+attribute vec4 i_data1;
+attribute vec4 i_data2;
+attribute vec4 i_data3;
+varying vec4 v_color0;
+uniform mat4 u_viewProj;
+
+void main()
+{
+  mat4 model = mat4(i_data0, i_data1, i_data2, i_data3);
+  gl_Position = u_viewProj * (model * vec4(a_position, 1.0));
+  v_color0 = a_color0;
+}
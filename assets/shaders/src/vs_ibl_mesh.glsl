@@ -0,0 +1,16 @@
+// vs_ibl_mesh: a mesh with its position and normal in world space, for
+// image based lighting.
+attribute vec3 a_normal;
+attribute vec3 a_position;
+varying vec3 v_normal;
+varying vec3 v_wpos;
+uniform mat4 u_model[32];
+uniform mat4 u_viewProj;
+
+void main()
+{
+  vec4 wpos = u_model[0] * vec4(a_position, 1.0);
+  gl_Position = u_viewProj * wpos;
+  v_wpos = wpos.xyz;
+  v_normal = (u_model[0] * vec4(a_normal * 2.0 - 1.0, 0.0)).xyz;
+}
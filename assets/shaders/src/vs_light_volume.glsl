@@ -0,0 +1,17 @@
+// vs_light_volume: the volume a light reaches, with its position and
+// normal in view space.
+attribute vec4 a_normal;
+attribute vec3 a_position;
+varying vec3 v_normal;
+varying vec3 v_view;
+uniform mat4 u_view;
+uniform mat4 u_model[32];
+uniform mat4 u_viewProj;
+
+void main()
+{
+  vec4 wpos = u_model[0] * vec4(a_position, 1.0);
+  gl_Position = u_viewProj * wpos;
+  v_view = (u_view * wpos).xyz;
+  v_normal = (u_view * vec4((u_model[0] * vec4(a_normal.xyz * 2.0 - 1.0, 0.0)).xyz, 0.0)).xyz;
+}
@@ -0,0 +1,27 @@
+// vs_pbr: a mesh with its position and tangent frame in world space, for
+// normal mapped metallic-roughness materials.
+attribute vec4 a_normal;
+attribute vec3 a_position;
+attribute vec4 a_tangent;
+attribute vec2 a_texcoord0;
+varying vec3 v_bitangent;
+varying vec3 v_normal;
+varying vec3 v_tangent;
+varying vec2 v_texcoord0;
+varying vec3 v_wpos;
+uniform mat4 u_model[32];
+uniform mat4 u_viewProj;
+
+void main()
+{
+  vec4 wpos = u_model[0] * vec4(a_position, 1.0);
+  gl_Position = u_viewProj * wpos;
+  vec4 tangent = a_tangent * 2.0 - 1.0;
+  vec3 n = normalize((u_model[0] * vec4(a_normal.xyz * 2.0 - 1.0, 0.0)).xyz);
+  vec3 t = normalize((u_model[0] * vec4(tangent.xyz, 0.0)).xyz);
+  v_wpos = wpos.xyz;
+  v_normal = n;
+  v_tangent = t;
+  v_bitangent = cross(n, t) * tangent.w;
+  v_texcoord0 = a_texcoord0;
+}
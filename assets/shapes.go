@@ -0,0 +1,129 @@
+package assets
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// shapeVertex is laid out like the vertices of the meshes in meshes/,
+// with normals and tangents packed into bytes, and tangents carrying the
+// handedness of the bitangent in w.
+type shapeVertex struct {
+	X, Y, Z         float32
+	Normal, Tangent [4]uint8
+	U, V            float32
+}
+
+// SphereMesh returns a UV sphere of radius around the origin, of rings
+// from pole to pole and segments around. Texture coordinates go around
+// the sphere in u and from the top down in v, and tangents point along
+// u.
+func SphereMesh(radius float32, rings, segments int) Mesh {
+	numVertices := (rings + 1) * (segments + 1)
+	if rings < 2 || segments < 3 || numVertices > 1<<16 {
+		panic(fmt.Sprintf("assets: bad sphere of %d rings and %d segments", rings, segments))
+	}
+	vertices := make([]shapeVertex, 0, numVertices)
+	for r := 0; r <= rings; r++ {
+		v := float64(r) / float64(rings)
+		sinTheta, cosTheta := math.Sincos(v * math.Pi)
+		for s := 0; s <= segments; s++ {
+			u := float64(s) / float64(segments)
+			sinPhi, cosPhi := math.Sincos(u * 2 * math.Pi)
+			n := [3]float32{
+				float32(sinTheta * cosPhi),
+				float32(cosTheta),
+				float32(sinTheta * sinPhi),
+			}
+			vertices = append(vertices, shapeVertex{
+				X:       n[0] * radius,
+				Y:       n[1] * radius,
+				Z:       n[2] * radius,
+				Normal:  packUnit(n[0], n[1], n[2], 0),
+				Tangent: packUnit(float32(-sinPhi), 0, float32(cosPhi), 1),
+				U:       float32(u),
+				V:       float32(v),
+			})
+		}
+	}
+	indices := make([]uint16, 0, rings*segments*6)
+	for r := 0; r < rings; r++ {
+		for s := 0; s < segments; s++ {
+			a := uint16(r*(segments+1) + s)
+			b := a + uint16(segments+1)
+			indices = append(indices, a, a+1, b, a+1, b+1, b)
+		}
+	}
+	return NewMesh(
+		bgfx.CreateVertexBuffer(vertices, shapeDecl()),
+		bgfx.CreateIndexBuffer(indices),
+		Bounds{
+			Sphere: Sphere{Radius: radius},
+			AABB: AABB{
+				Min: [3]float32{-radius, -radius, -radius},
+				Max: [3]float32{radius, radius, radius},
+			},
+		},
+	)
+}
+
+// ConeMesh returns a cone with its apex at the origin and its base, of
+// radius 1 and closed, around (0, 0, 1), of segments around. Tangents
+// point around the cone, and texture coordinates are 0.
+func ConeMesh(segments int) Mesh {
+	if segments < 3 || segments*3+1 > 1<<16 {
+		panic(fmt.Sprintf("assets: bad cone of %d segments", segments))
+	}
+	// Each segment has a vertex at the apex, one on the rim with the
+	// side's normal, and one on the rim with the base's normal. The
+	// base's center is last.
+	const slant = math.Sqrt2 / 2 // of the normals of the sides, at 45°
+	vertices := make([]shapeVertex, 0, segments*3+1)
+	indices := make([]uint16, 0, segments*6)
+	center := uint16(segments * 3)
+	for s := 0; s < segments; s++ {
+		sin, cos := math.Sincos(float64(s) / float64(segments) * 2 * math.Pi)
+		x, y := float32(cos), float32(sin)
+		tangent := packUnit(-y, x, 0, 1)
+		side := packUnit(x*slant, y*slant, -slant, 0)
+		vertices = append(vertices,
+			shapeVertex{Normal: side, Tangent: tangent},
+			shapeVertex{X: x, Y: y, Z: 1, Normal: side, Tangent: tangent},
+			shapeVertex{X: x, Y: y, Z: 1, Normal: packUnit(0, 0, 1, 0), Tangent: tangent},
+		)
+		i := uint16(s * 3)
+		next := uint16((s + 1) % segments * 3)
+		indices = append(indices, i, next+1, i+1, center, i+2, next+2)
+	}
+	vertices = append(vertices, shapeVertex{Z: 1, Normal: packUnit(0, 0, 1, 0), Tangent: packUnit(1, 0, 0, 1)})
+	return NewMesh(
+		bgfx.CreateVertexBuffer(vertices, shapeDecl()),
+		bgfx.CreateIndexBuffer(indices),
+		Bounds{
+			Sphere: Sphere{Center: [3]float32{0, 0, 1}, Radius: 1},
+			AABB:   AABB{Min: [3]float32{-1, -1, 0}, Max: [3]float32{1, 1, 1}},
+		},
+	)
+}
+
+func shapeDecl() bgfx.VertexDecl {
+	var decl bgfx.VertexDecl
+	decl.Begin()
+	decl.Add(bgfx.AttribPosition, 3, bgfx.AttribTypeFloat, false, false)
+	decl.Add(bgfx.AttribNormal, 4, bgfx.AttribTypeUint8, true, true)
+	decl.Add(bgfx.AttribTangent, 4, bgfx.AttribTypeUint8, true, true)
+	decl.Add(bgfx.AttribTexcoord0, 2, bgfx.AttribTypeFloat, false, false)
+	decl.End()
+	return decl
+}
+
+// packUnit packs components from -1 to 1 into normalized bytes, which
+// shaders unpack with a * 2.0 - 1.0.
+func packUnit(x, y, z, w float32) [4]uint8 {
+	pack := func(f float32) uint8 {
+		return uint8(f*127.5 + 127.5)
+	}
+	return [4]uint8{pack(x), pack(y), pack(z), pack(w)}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/james4k/go-bgfx"
 	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
 	"github.com/james4k/go-bgfx-examples/example"
 	"j4k.co/cgm"
 	"j4k.co/cgm/mat4"
@@ -40,54 +41,37 @@ var indices = []uint16{
 }
 
 func main() {
-	app := example.Open()
-	defer app.Close()
-	bgfx.Init()
-	defer bgfx.Shutdown()
-
-	bgfx.Reset(app.Width, app.Height, bgfx.ResetVSync)
-	bgfx.SetDebug(bgfx.DebugText)
-	bgfx.SetViewClear(
-		0,
-		bgfx.ClearColor|bgfx.ClearDepth,
-		0x303030ff,
-		1.0,
-		0,
-	)
+	example.Run(example.Example{
+		Description: "Rendering simple static mesh.",
+		Setup:       setup,
+	})
+}
 
+func setup(app *example.Application) (frame, cleanup func()) {
 	var vd bgfx.VertexDecl
 	vd.Begin()
 	vd.Add(bgfx.AttribPosition, 3, bgfx.AttribTypeFloat, false, false)
 	vd.Add(bgfx.AttribColor0, 4, bgfx.AttribTypeUint8, true, false)
 	vd.End()
 	vb := bgfx.CreateVertexBuffer(vertices, vd)
-	defer bgfx.DestroyVertexBuffer(vb)
 	ib := bgfx.CreateIndexBuffer(indices)
-	defer bgfx.DestroyIndexBuffer(ib)
 	prog := assets.LoadProgram("vs_cubes", "fs_cubes")
-	defer bgfx.DestroyProgram(prog)
+	cleanup = func() {
+		bgfx.DestroyVertexBuffer(vb)
+		bgfx.DestroyIndexBuffer(ib)
+		bgfx.DestroyProgram(prog)
+	}
 
-	for app.Continue() {
+	cam := camera.New(
+		[3]float32{0, 0, -35.0},
+		[3]float32{0, 0, 0},
+		[3]float32{1, 0, 0},
+	)
+
+	frame = func() {
 		t := app.Time
-		dt := app.DeltaTime
-		var (
-			eye = [3]float32{0, 0, -35.0}
-			at  = [3]float32{0, 0, 0}
-			up  = [3]float32{1, 0, 0}
-		)
-		view := mat4.LookAtLH(eye, at, up)
-		proj := mat4.PerspectiveLH(
-			cgm.ToRadians(60),
-			float32(app.Width)/float32(app.Height),
-			0.1, 100,
-		)
-		bgfx.SetViewTransform(0, view, proj)
-		bgfx.SetViewRect(0, 0, 0, app.Width, app.Height)
-		bgfx.DebugTextClear()
-		bgfx.DebugTextPrintf(0, 1, 0x4f, app.Title)
-		bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: Rendering simple static mesh.")
-		bgfx.DebugTextPrintf(0, 3, 0x0f, "Frame: % 7.3f[ms]", dt*1000.0)
-		bgfx.Submit(0)
+		cam.Update(app)
+		bgfx.SetViewTransform(0, cam.View(), cam.Proj())
 
 		// Submit 11x11 cubes
 		for y := 0; y < 11; y++ {
@@ -109,7 +93,6 @@ func main() {
 				bgfx.Submit(0)
 			}
 		}
-
-		bgfx.Frame()
 	}
+	return frame, cleanup
 }
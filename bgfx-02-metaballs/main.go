@@ -4,6 +4,7 @@ import (
 	"math"
 
 	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/camera"
 	"github.com/james4k/go-bgfx-examples/example"
 	"j4k.co/cgm"
 	"j4k.co/cgm/mat4"
@@ -17,21 +18,13 @@ type PosNormalColorVertex struct {
 }
 
 func main() {
-	app := example.Open()
-	defer app.Close()
-	bgfx.Init()
-	defer bgfx.Shutdown()
-
-	bgfx.Reset(app.Width, app.Height, bgfx.ResetVSync)
-	bgfx.SetDebug(bgfx.DebugText)
-	bgfx.SetViewClear(
-		0,
-		bgfx.ClearColor|bgfx.ClearDepth,
-		0x303030ff,
-		1.0,
-		0,
-	)
+	example.Run(example.Example{
+		Description: "Rendering with transient buffers and embedded shaders.",
+		Setup:       setup,
+	})
+}
 
+func setup(app *example.Application) (frame, cleanup func()) {
 	var vd bgfx.VertexDecl
 	vd.Begin()
 	vd.Add(bgfx.AttribPosition, 3, bgfx.AttribTypeFloat, false, false)
@@ -41,7 +34,9 @@ func main() {
 	vsh := bgfx.CreateShader(vs_metaballs_glsl)
 	fsh := bgfx.CreateShader(fs_metaballs_glsl)
 	prog := bgfx.CreateProgram(vsh, fsh, true)
-	defer bgfx.DestroyProgram(prog)
+	cleanup = func() {
+		bgfx.DestroyProgram(prog)
+	}
 
 	const dim = 32
 	const ypitch = dim
@@ -49,21 +44,15 @@ func main() {
 	const invdim = 1.0 / (dim - 1)
 	var grid [dim * dim * dim]cell
 
-	for app.Continue() {
-		var (
-			eye = [3]float32{0, 0, -50.0}
-			at  = [3]float32{0, 0, 0}
-			up  = [3]float32{0, 1, 0}
-		)
-		view := mat4.LookAtLH(eye, at, up)
-		proj := mat4.PerspectiveLH(
-			cgm.ToRadians(60),
-			float32(app.Width)/float32(app.Height),
-			0.1, 100,
-		)
-		bgfx.SetViewTransform(0, view, proj)
-		bgfx.SetViewRect(0, 0, 0, app.Width, app.Height)
-		bgfx.Submit(0)
+	cam := camera.New(
+		[3]float32{0, 0, -50.0},
+		[3]float32{0, 0, 0},
+		[3]float32{0, 1, 0},
+	)
+
+	frame = func() {
+		cam.Update(app)
+		bgfx.SetViewTransform(0, cam.View(), cam.Proj())
 
 		// 32k vertices
 		const maxVertices = (32 << 10)
@@ -164,14 +153,10 @@ func main() {
 		}
 		profTriangulate = app.HighFreqTime() - profTriangulate
 
-		bgfx.DebugTextClear()
-		bgfx.DebugTextPrintf(0, 1, 0x4f, app.Title)
-		bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: Rendering with transient buffers and embedded shaders.")
 		bgfx.DebugTextPrintf(0, 4, 0x0f, "    Vertices: %d (%.2f%%)", numVertices, float32(numVertices*100)/maxVertices)
 		bgfx.DebugTextPrintf(0, 5, 0x0f, "      Update: % 7.3f[ms]", profUpdate*1000.0)
 		bgfx.DebugTextPrintf(0, 6, 0x0f, "Calc normals: % 7.3f[ms]", profNormal*1000.0)
 		bgfx.DebugTextPrintf(0, 7, 0x0f, " Triangulate: % 7.3f[ms]", profTriangulate*1000.0)
-		bgfx.DebugTextPrintf(0, 8, 0x0f, "       Frame: % 7.3f[ms]", app.DeltaTime*1000.0)
 
 		mtx := mat4.RotateXYZ(
 			cgm.Radians(app.Time)*0.67,
@@ -183,7 +168,6 @@ func main() {
 		bgfx.SetTransientVertexBuffer(tvb, 0, numVertices)
 		bgfx.SetState(bgfx.StateDefault)
 		bgfx.Submit(0)
-
-		bgfx.Frame()
 	}
+	return frame, cleanup
 }
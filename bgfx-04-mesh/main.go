@@ -3,62 +3,48 @@ package main
 import (
 	"github.com/james4k/go-bgfx"
 	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
 	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/render"
 	"j4k.co/cgm"
 	"j4k.co/cgm/mat4"
 )
 
 func main() {
-	app := example.Open()
-	defer app.Close()
-	bgfx.Init()
-	defer bgfx.Shutdown()
-
-	bgfx.Reset(app.Width, app.Height, bgfx.ResetVSync)
-	bgfx.SetDebug(bgfx.DebugText)
-	bgfx.SetViewClear(
-		0,
-		bgfx.ClearColor|bgfx.ClearDepth,
-		0x303030ff,
-		1.0,
-		0,
-	)
-
-	uTime := bgfx.CreateUniform("u_time", bgfx.Uniform1f, 1)
-	defer bgfx.DestroyUniform(uTime)
+	example.Run(example.Example{
+		Description: "Loading meshes.",
+		Setup:       setup,
+	})
+}
 
+func setup(app *example.Application) (frame, cleanup func()) {
+	uTime := render.NewUniform[float32]("u_time", 1)
 	prog := assets.LoadProgram("vs_mesh", "fs_mesh")
-	defer bgfx.DestroyProgram(prog)
-
 	mesh := assets.LoadMesh("bunny")
-	defer mesh.Unload()
+	cleanup = func() {
+		uTime.Destroy()
+		bgfx.DestroyProgram(prog)
+		mesh.Unload()
+	}
 
-	for app.Continue() {
-		bgfx.SetViewRect(0, 0, 0, app.Width, app.Height)
-		bgfx.DebugTextClear()
-		bgfx.DebugTextPrintf(0, 1, 0x4f, app.Title)
-		bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: Loading meshes.")
-		bgfx.DebugTextPrintf(0, 3, 0x0f, "Frame: % 7.3f[ms]", app.DeltaTime*1000.0)
-		bgfx.Submit(0)
+	cam := camera.New(
+		[3]float32{0, 1, -2.5},
+		[3]float32{0, 1, 0},
+		[3]float32{0, 1, 0},
+	)
+	cam.Controller = camera.NewOrbit(cam.At, 2.5)
 
-		bgfx.SetUniform(uTime, &app.Time, 1)
+	frame = func() {
+		uTime.Set(app.Time)
 
-		var (
-			eye = [3]float32{0, 1, -2.5}
-			at  = [3]float32{0, 1, 0}
-			up  = [3]float32{0, 1, 0}
-		)
-		view := mat4.LookAtLH(eye, at, up)
-		proj := mat4.PerspectiveLH(
-			cgm.ToRadians(60),
-			float32(app.Width)/float32(app.Height),
-			0.1, 100,
-		)
-		bgfx.SetViewTransform(0, view, proj)
+		cam.Update(app)
+		bgfx.SetViewTransform(0, cam.View(), cam.Proj())
 
 		mtx := mat4.RotateXYZ(0, cgm.Radians(app.Time)*0.37, 0)
-		mesh.Submit(0, prog, mtx, 0)
-
-		bgfx.Frame()
+		var stats assets.CullStats
+		mesh.SubmitCulled(0, prog, mtx, 0, cam.ViewProj(), &stats)
+		bgfx.DebugTextPrintf(0, 5, 0x0f, "Culled: %d/%d groups, %d/%d primitives",
+			stats.GroupsCulled, stats.Groups, stats.PrimsCulled, stats.Prims)
 	}
+	return frame, cleanup
 }
@@ -1,12 +1,13 @@
 package main
 
 import (
-	"encoding/binary"
 	"math"
 
 	"github.com/james4k/go-bgfx"
 	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
 	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/render"
 	"j4k.co/cgm"
 	"j4k.co/cgm/mat4"
 )
@@ -42,67 +43,54 @@ var indices = []uint16{
 	6, 3, 7,
 }
 
-func main() {
-	app := example.Open()
-	defer app.Close()
-	bgfx.Init()
-	defer bgfx.Shutdown()
+// instance is the per-instance data read by vs_instancing.
+type instance struct {
+	Mtx   [16]float32
+	Color [4]float32
+}
 
-	bgfx.Reset(app.Width, app.Height, bgfx.ResetVSync)
-	bgfx.SetDebug(bgfx.DebugText)
-	bgfx.SetViewClear(
-		0,
-		bgfx.ClearColor|bgfx.ClearDepth,
-		0x303030ff,
-		1.0,
-		0,
-	)
+func main() {
+	example.Run(example.Example{
+		Description: "Geometry instancing.",
+		Setup:       setup,
+	})
+}
 
+func setup(app *example.Application) (frame, cleanup func()) {
 	var vd bgfx.VertexDecl
 	vd.Begin()
 	vd.Add(bgfx.AttribPosition, 3, bgfx.AttribTypeFloat, false, false)
 	vd.Add(bgfx.AttribColor0, 4, bgfx.AttribTypeUint8, true, false)
 	vd.End()
 	vb := bgfx.CreateVertexBuffer(vertices, vd)
-	defer bgfx.DestroyVertexBuffer(vb)
 	ib := bgfx.CreateIndexBuffer(indices)
-	defer bgfx.DestroyIndexBuffer(ib)
 	prog := assets.LoadProgram("vs_instancing", "fs_instancing")
-	defer bgfx.DestroyProgram(prog)
+	cleanup = func() {
+		bgfx.DestroyVertexBuffer(vb)
+		bgfx.DestroyIndexBuffer(ib)
+		bgfx.DestroyProgram(prog)
+	}
+
+	cam := camera.New(
+		[3]float32{0, 0, -35.0},
+		[3]float32{0, 0, 0},
+		[3]float32{1, 0, 0},
+	)
 
-	caps := bgfx.Caps()
-	for app.Continue() {
-		var (
-			eye = [3]float32{0, 0, -35.0}
-			at  = [3]float32{0, 0, 0}
-			up  = [3]float32{1, 0, 0}
-		)
-		view := mat4.LookAtLH(eye, at, up)
-		proj := mat4.PerspectiveLH(
-			cgm.ToRadians(60),
-			float32(app.Width)/float32(app.Height),
-			0.1, 100,
-		)
-		bgfx.SetViewTransform(0, view, proj)
-		bgfx.SetViewRect(0, 0, 0, app.Width, app.Height)
-		bgfx.DebugTextClear()
-		bgfx.DebugTextPrintf(0, 1, 0x4f, app.Title)
-		bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: Geometry instancing.")
-		bgfx.DebugTextPrintf(0, 3, 0x0f, "Frame: % 7.3f[ms]", app.DeltaTime*1000.0)
-		bgfx.Submit(0)
+	instances := render.NewInstanceBuffer[instance]()
+	frame = func() {
+		cam.Update(app)
+		bgfx.SetViewTransform(0, cam.View(), cam.Proj())
 
-		if caps.Supported&bgfx.CapsInstancing == 0 {
+		if !instances.Instanced {
 			color := uint8(0x01)
 			if uint32(app.Time*2)&1 != 0 {
 				color = 0x1f
 			}
 			bgfx.DebugTextPrintf(0, 5, color, " Instancing is not supported by GPU. ")
-			bgfx.Frame()
-			continue
+			return
 		}
 
-		const stride = 80
-		idb := bgfx.AllocInstanceDataBuffer(11*11, stride)
 		// Submit 11x11 cubes
 		time64 := float64(app.Time)
 		for y := 0; y < 11; y++ {
@@ -115,24 +103,25 @@ func main() {
 				mtx[12] = -15 + float32(x)*3
 				mtx[13] = -15 + float32(y)*3
 				mtx[14] = 0
-				color := [4]float32{
-					float32(math.Sin(time64+float64(x)/11.0)*0.5 + 0.5),
-					float32(math.Cos(time64+float64(y)/11.0)*0.5 + 0.5),
-					float32(math.Sin(time64*3.0)*0.5 + 0.5),
-					1.0,
-				}
-				binary.Write(&idb, binary.LittleEndian, mtx)
-				binary.Write(&idb, binary.LittleEndian, color)
+				instances.Add(instance{
+					Mtx: mtx,
+					Color: [4]float32{
+						float32(math.Sin(time64+float64(x)/11.0)*0.5 + 0.5),
+						float32(math.Cos(time64+float64(y)/11.0)*0.5 + 0.5),
+						float32(math.Sin(time64*3.0)*0.5 + 0.5),
+						1.0,
+					},
+				})
 			}
 		}
-
-		bgfx.SetProgram(prog)
-		bgfx.SetVertexBuffer(vb)
-		bgfx.SetIndexBuffer(ib)
-		bgfx.SetInstanceDataBuffer(idb)
-		bgfx.SetState(bgfx.StateDefault)
-		bgfx.Submit(0)
-
-		bgfx.Frame()
+		instances.Submit(0, func(r render.Renderer) {
+			r.SetProgram(prog)
+			r.SetVertexBuffer(vb)
+			r.SetIndexBuffer(ib)
+			r.SetState(bgfx.StateDefault)
+		})
+		bgfx.DebugTextPrintf(0, 5, 0x0f, "Instances: %d in %d draws",
+			instances.Stats.Instances, instances.Stats.Draws)
 	}
+	return frame, cleanup
 }
@@ -1,12 +1,15 @@
 package main
 
 import (
-	"encoding/binary"
+	"flag"
 	"math"
 
 	"github.com/james4k/go-bgfx"
 	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
 	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/light"
+	"github.com/james4k/go-bgfx-examples/render"
 	"j4k.co/cgm"
 	"j4k.co/cgm/mat4"
 )
@@ -70,24 +73,50 @@ var indices = []uint16{
 	21, 23, 22,
 }
 
-func main() {
-	app := example.Open()
-	defer app.Close()
-	bgfx.Init()
-	defer bgfx.Shutdown()
-
-	bgfx.Reset(app.Width, app.Height, bgfx.ResetVSync)
-	bgfx.SetDebug(bgfx.DebugText)
-	bgfx.SetViewClear(
-		0,
-		bgfx.ClearColor|bgfx.ClearDepth,
-		0x303030ff,
-		1.0,
-		0,
-	)
+var numLights = flag.Int("lights", 12, "number of lights")
+
+// maxLights is the number of lights fs_bump takes, all point lights.
+const maxLights = 4
+
+type lights struct {
+	PosRadius [maxLights][4]float32 `uniform:"u_lightPosRadius"`
+	RgbInnerR [maxLights][4]float32 `uniform:"u_lightRgbInnerR"`
+}
+
+// packLights returns the uniforms of the point lights ls, of which there
+// are at most maxLights. Slots without a light are black.
+func packLights(ls []light.Light) lights {
+	var l lights
+	for i := range l.PosRadius {
+		l.PosRadius[i][3] = 1
+	}
+	for i, pl := range ls {
+		l.PosRadius[i] = [4]float32{pl.Position[0], pl.Position[1], pl.Position[2], pl.Range}
+		l.RgbInnerR[i] = [4]float32{
+			pl.Color[0] * pl.Intensity,
+			pl.Color[1] * pl.Intensity,
+			pl.Color[2] * pl.Intensity,
+			0.8,
+		}
+	}
+	return l
+}
 
-	instancingSupported := bgfx.Caps().Supported&bgfx.CapsInstancing != 0
+var lightColors = [][3]float32{
+	{1.0, 0.7, 0.2},
+	{0.7, 0.2, 1.0},
+	{0.2, 1.0, 0.7},
+	{1.0, 0.4, 0.2},
+}
+
+func main() {
+	example.Run(example.Example{
+		Description: "Loading textures.",
+		Setup:       setup,
+	})
+}
 
+func setup(app *example.Application) (frame, cleanup func()) {
 	var vd bgfx.VertexDecl
 	vd.Begin()
 	vd.Add(bgfx.AttribPosition, 3, bgfx.AttribTypeFloat, false, false)
@@ -97,114 +126,107 @@ func main() {
 	vd.End()
 	example.CalculateTangents(vertices, len(vertices), vd, indices)
 
-	vb := bgfx.CreateVertexBuffer(vertices, vd)
-	defer bgfx.DestroyVertexBuffer(vb)
-	ib := bgfx.CreateIndexBuffer(indices)
-	defer bgfx.DestroyIndexBuffer(ib)
-
-	const numLights = 4
-	uTexColor := bgfx.CreateUniform("u_texColor", bgfx.Uniform1iv, 1)
-	uTexNormal := bgfx.CreateUniform("u_texNormal", bgfx.Uniform1iv, 1)
-	uLightPosRadius := bgfx.CreateUniform("u_lightPosRadius", bgfx.Uniform4fv, numLights)
-	uLightRgbInnerR := bgfx.CreateUniform("u_lightRgbInnerR", bgfx.Uniform4fv, numLights)
+	mesh := assets.NewMesh(
+		bgfx.CreateVertexBuffer(vertices, vd),
+		bgfx.CreateIndexBuffer(indices),
+		assets.Bounds{
+			Sphere: assets.Sphere{Radius: 1.7321},
+			AABB:   assets.AABB{Min: [3]float32{-1, -1, -1}, Max: [3]float32{1, 1, 1}},
+		},
+	)
 
-	vsbump := "vs_bump"
-	if instancingSupported {
-		vsbump = "vs_bump_instanced"
+	lightUniforms := render.NewBlock[lights]()
+	volumes := light.NewDebugDrawer()
+	manager := &light.Manager{Lights: make([]light.Light, *numLights)}
+
+	// The batcher draws the cubes with the material's instanced
+	// program when instancing is supported, and one by one otherwise.
+	// Cubes are batched with the others that picked the same lights.
+	mat := assets.LoadMaterial("bump")
+	var batcher assets.Batcher
+	cleanup = func() {
+		mesh.Unload()
+		lightUniforms.Destroy()
+		volumes.Destroy()
+		mat.Destroy()
 	}
-	prog := assets.LoadProgram(vsbump, "fs_bump")
-	defer bgfx.DestroyProgram(prog)
-
-	textureColor := assets.LoadTexture("fieldstone-rgba.dds", 0)
-	textureNormal := assets.LoadTexture("fieldstone-n.dds", 0)
-
-	for app.Continue() {
-		var (
-			eye = [3]float32{0, 0, -7.0}
-			at  = [3]float32{0, 0, 0}
-			up  = [3]float32{1, 0, 0}
-		)
-		view := mat4.LookAtLH(eye, at, up)
-		proj := mat4.PerspectiveLH(
-			cgm.ToRadians(60),
-			float32(app.Width)/float32(app.Height),
-			0.1, 100.0,
-		)
-		bgfx.SetViewTransform(0, view, proj)
-		bgfx.SetViewRect(0, 0, 0, app.Width, app.Height)
-		bgfx.DebugTextClear()
-		bgfx.DebugTextPrintf(0, 1, 0x4f, app.Title)
-		bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: Loading textures.")
-		bgfx.DebugTextPrintf(0, 3, 0x0f, "Frame: % 7.3f[ms]", app.DeltaTime*1000.0)
-		bgfx.Submit(0)
 
-		const halfPi = math.Pi / 2
-		var lightPosRadius [4][4]float32
-		for i := 0; i < numLights; i++ {
-			fi := float32(i)
-			lightPosRadius[i][0] = float32(math.Sin(float64(app.Time*(0.1+fi*0.17)+fi*halfPi*1.37)) * 3.0)
-			lightPosRadius[i][1] = float32(math.Cos(float64(app.Time*(0.2+fi*0.29)+fi*halfPi*1.49)) * 3.0)
-			lightPosRadius[i][2] = -2.5
-			lightPosRadius[i][3] = 3.0
+	cam := camera.New(
+		[3]float32{0, 0, -7.0},
+		[3]float32{0, 0, 0},
+		[3]float32{1, 0, 0},
+	)
+
+	showVolumes := false
+	bounds := mesh.BoundingSphere()
+	var (
+		sets  []lights
+		cubes = make(map[lights][][16]float32)
+	)
+
+	frame = func() {
+		if app.KeyPressed(example.KeyV) {
+			showVolumes = !showVolumes
 		}
-		bgfx.SetUniform(uLightPosRadius, &lightPosRadius, numLights)
+		cam.Update(app)
+		bgfx.SetViewTransform(0, cam.View(), cam.Proj())
 
-		lightRgbInnerR := [4][4]float32{
-			{1.0, 0.7, 0.2, 0.8},
-			{0.7, 0.2, 1.0, 0.8},
-			{0.2, 1.0, 0.7, 0.8},
-			{1.0, 0.4, 0.2, 0.8},
+		const halfPi = math.Pi / 2
+		for i := range manager.Lights {
+			fi := float32(i)
+			manager.Lights[i] = light.Light{
+				Type: light.Point,
+				Position: [3]float32{
+					float32(math.Sin(float64(app.Time*(0.1+fi*0.17)+fi*halfPi*1.37)) * 3.0),
+					float32(math.Cos(float64(app.Time*(0.2+fi*0.29)+fi*halfPi*1.49)) * 3.0),
+					-2.5,
+				},
+				Color:     lightColors[i%len(lightColors)],
+				Intensity: 1,
+				Range:     3.0,
+			}
 		}
-		bgfx.SetUniform(uLightRgbInnerR, &lightRgbInnerR, numLights)
-
-		if instancingSupported {
-			for y := 0; y < 3; y++ {
-				const numInstances = 3
-				const instanceStride = 64
-				idb := bgfx.AllocInstanceDataBuffer(numInstances, instanceStride)
-				for x := 0; x < 3; x++ {
-					mtx := mat4.RotateXYZ(
-						cgm.Radians(app.Time)*0.023+cgm.Radians(x)*0.21,
-						cgm.Radians(app.Time)*0.03+cgm.Radians(y)*0.37,
-						0,
-					)
-					mtx[12] = -3 + float32(x)*3
-					mtx[13] = -3 + float32(y)*3
-					mtx[14] = 0
-					binary.Write(&idb, binary.LittleEndian, mtx)
+		manager.ResetStats()
+
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 3; x++ {
+				mtx := mat4.RotateXYZ(
+					cgm.Radians(app.Time)*0.023+cgm.Radians(x)*0.21,
+					cgm.Radians(app.Time)*0.03+cgm.Radians(y)*0.37,
+					0,
+				)
+				mtx[12] = -3 + float32(x)*3
+				mtx[13] = -3 + float32(y)*3
+				mtx[14] = 0
+				l := packLights(manager.Select(bounds.Transform(mtx), maxLights))
+				if _, ok := cubes[l]; !ok {
+					sets = append(sets, l)
 				}
-				bgfx.SetInstanceDataBuffer(idb)
-				bgfx.SetProgram(prog)
-				bgfx.SetVertexBuffer(vb)
-				bgfx.SetIndexBuffer(ib)
-				bgfx.SetTexture(0, uTexColor, textureColor)
-				bgfx.SetTexture(1, uTexNormal, textureNormal)
-				bgfx.SetState(bgfx.StateDefault & (^bgfx.StateCullMask))
-				bgfx.Submit(0)
+				cubes[l] = append(cubes[l], mtx)
 			}
-		} else {
-			for y := 0; y < 3; y++ {
-				for x := 0; x < 3; x++ {
-					mtx := mat4.RotateXYZ(
-						cgm.Radians(app.Time)*0.023+cgm.Radians(x)*0.21,
-						cgm.Radians(app.Time)*0.03+cgm.Radians(y)*0.37,
-						0,
-					)
-					mtx[12] = -3 + float32(x)*3
-					mtx[13] = -3 + float32(y)*3
-					mtx[14] = 0
-
-					bgfx.SetTransform([16]float32(mtx))
-					bgfx.SetProgram(prog)
-					bgfx.SetVertexBuffer(vb)
-					bgfx.SetIndexBuffer(ib)
-					bgfx.SetTexture(0, uTexColor, textureColor)
-					bgfx.SetTexture(1, uTexNormal, textureNormal)
-					bgfx.SetState(bgfx.StateDefault & (^bgfx.StateCullMask))
-					bgfx.Submit(0)
-				}
+		}
+		var stats assets.BatchStats
+		for i := range sets {
+			lightUniforms.Set(&sets[i])
+			for _, mtx := range cubes[sets[i]] {
+				batcher.Add(&mesh, mat, mtx)
 			}
+			batcher.Flush(0)
+			stats.Draws += batcher.Stats.Draws
+			stats.Submissions += batcher.Stats.Submissions
+			stats.Unbatched += batcher.Stats.Unbatched
+			delete(cubes, sets[i])
+		}
+		sets = sets[:0]
+		if showVolumes {
+			volumes.Draw(0, manager.Lights)
 		}
-		bgfx.Frame()
+
+		bgfx.DebugTextPrintf(0, 5, 0x0f, "Draw calls: %d for %d cubes (%d saved)",
+			stats.Draws, stats.Submissions, stats.Saved())
+		ls := manager.Stats
+		bgfx.DebugTextPrintf(0, 6, 0x0f, "Lights: %d, %d culled and %d dropped of %d tests; volumes %v [V]",
+			len(manager.Lights), ls.Culled, ls.Dropped, ls.Tested, showVolumes)
 	}
+	return frame, cleanup
 }
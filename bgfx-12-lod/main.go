@@ -5,8 +5,9 @@ import (
 
 	"github.com/james4k/go-bgfx"
 	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
 	"github.com/james4k/go-bgfx-examples/example"
-	"j4k.co/cgm"
+	"github.com/james4k/go-bgfx-examples/render"
 	"j4k.co/cgm/mat4"
 )
 
@@ -22,36 +23,23 @@ var knightTour = []knightPos{
 }
 
 func main() {
-	app := example.Open()
-	defer app.Close()
-	bgfx.Init()
-	defer bgfx.Shutdown()
-	bgfx.Reset(app.Width, app.Height, bgfx.ResetVSync)
-	bgfx.SetDebug(bgfx.DebugText)
-	bgfx.SetViewClear(
-		0,
-		bgfx.ClearColor|bgfx.ClearDepth,
-		0x303030ff,
-		1.0,
-		0,
-	)
+	example.Run(example.Example{
+		Description: "Mesh LOD transitions.",
+		Setup:       setup,
+	})
+}
 
+func setup(app *example.Application) (frame, cleanup func()) {
 	var (
 		uTexColor   = bgfx.CreateUniform("u_texColor", bgfx.Uniform1iv, 1)
-		uStipple    = bgfx.CreateUniform("u_stipple", bgfx.Uniform3fv, 1)
+		uStipple    = render.NewUniform[[3]float32]("u_stipple", 1)
 		uTexStipple = bgfx.CreateUniform("u_texStipple", bgfx.Uniform1iv, 1)
 	)
-	defer bgfx.DestroyUniform(uTexColor)
-	defer bgfx.DestroyUniform(uStipple)
-	defer bgfx.DestroyUniform(uTexStipple)
 
 	prog := assets.LoadProgram("vs_tree", "fs_tree")
-	defer bgfx.DestroyProgram(prog)
 
 	textureLeafs := assets.LoadTexture("leafs1.dds", 0)
 	textureBark := assets.LoadTexture("bark1.dds", 0)
-	defer bgfx.DestroyTexture(textureLeafs)
-	defer bgfx.DestroyTexture(textureBark)
 
 	stippleData := make([]byte, 8*4)
 	for i, v := range knightTour {
@@ -59,7 +47,6 @@ func main() {
 	}
 	textureStipple := bgfx.CreateTexture2D(8, 4, 1, bgfx.TextureFormatR8,
 		bgfx.TextureMinPoint|bgfx.TextureMagPoint, stippleData)
-	defer bgfx.DestroyTexture(textureStipple)
 
 	meshTop := [3]assets.Mesh{
 		assets.LoadMesh("tree1b_lod0_1"),
@@ -71,32 +58,42 @@ func main() {
 		assets.LoadMesh("tree1b_lod1_2"),
 		assets.LoadMesh("tree1b_lod2_2"),
 	}
-	for _, m := range meshTop {
-		defer m.Unload()
-	}
-	for _, m := range meshTrunk {
-		defer m.Unload()
+	cleanup = func() {
+		for _, m := range meshTop {
+			m.Unload()
+		}
+		for _, m := range meshTrunk {
+			m.Unload()
+		}
+		bgfx.DestroyTexture(textureStipple)
+		bgfx.DestroyTexture(textureLeafs)
+		bgfx.DestroyTexture(textureBark)
+		bgfx.DestroyProgram(prog)
+		bgfx.DestroyUniform(uTexColor)
+		uStipple.Destroy()
+		bgfx.DestroyUniform(uTexStipple)
 	}
 
+	cam := camera.New(
+		[3]float32{0, 1, -4},
+		[3]float32{0, 1, 0},
+		[3]float32{0, 1, 0},
+	)
+
 	var (
 		stateCommon      = bgfx.StateRGBWrite | bgfx.StateAlphaWrite | bgfx.StateDepthTestLess | bgfx.StateCullCCW | bgfx.StateMSAA
 		stateTransparent = stateCommon | bgfx.StateBlendAlpha()
 		stateOpaque      = stateCommon | bgfx.StateDepthWrite
 
+		queue example.RenderQueue
+
 		transitions     = true
 		transitionFrame = 0
 		currLOD         = 0
 		targetLOD       = 0
 	)
 
-	for app.Continue() {
-		bgfx.SetViewRect(0, 0, 0, app.Width, app.Height)
-		bgfx.Submit(0)
-		bgfx.DebugTextClear()
-		bgfx.DebugTextPrintf(0, 1, 0x4f, app.Title)
-		bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: Mesh LOD transitions.")
-		bgfx.DebugTextPrintf(0, 3, 0x0f, "Frame: % 7.3f[ms]", app.DeltaTime*1000.0)
-
+	frame = func() {
 		var (
 			currentLODframe = 32
 			mainLOD         = targetLOD
@@ -117,41 +114,39 @@ func main() {
 			(float32(transitionFrame) * 4 / 255) - (1.0 / 255),
 		}
 
-		var (
-			eye = [3]float32{0, 1, -distance}
-			at  = [3]float32{0, 1, 0}
-			up  = [3]float32{0, 1, 0}
-		)
-		view := mat4.LookAtLH(eye, at, up)
-		proj := mat4.PerspectiveLH(
-			cgm.ToRadians(60),
-			float32(app.Width)/float32(app.Height),
-			0.1, 100,
-		)
+		cam.Eye = [3]float32{0, 1, -distance}
+		cam.Update(app)
 		mtx := mat4.Scale(0.1, 0.1, 0.1)
-		bgfx.SetViewTransform(0, view, proj)
+		queue.SetViewTransform(0, cam.View(), cam.Proj())
 
-		bgfx.SetTexture(0, uTexColor, textureBark)
-		bgfx.SetTexture(1, uTexStipple, textureStipple)
-		bgfx.SetUniform(uStipple, &stipple, 1)
-		meshTrunk[mainLOD].Submit(0, prog, mtx, stateOpaque)
+		// The queue draws the opaque trunks before the transparent
+		// leaves, whatever order they are submitted in.
+		queue.SetTexture(0, uTexColor, textureLeafs)
+		queue.SetTexture(1, uTexStipple, textureStipple)
+		uStipple.SetTo(&queue, stipple)
+		meshTop[mainLOD].SubmitTo(&queue, 0, prog, mtx, stateTransparent)
 
-		bgfx.SetTexture(0, uTexColor, textureLeafs)
-		bgfx.SetTexture(1, uTexStipple, textureStipple)
-		bgfx.SetUniform(uStipple, &stipple, 1)
-		meshTop[mainLOD].Submit(0, prog, mtx, stateTransparent)
+		queue.SetTexture(0, uTexColor, textureBark)
+		queue.SetTexture(1, uTexStipple, textureStipple)
+		uStipple.SetTo(&queue, stipple)
+		meshTrunk[mainLOD].SubmitTo(&queue, 0, prog, mtx, stateOpaque)
 
 		if transitions && transitionFrame != 0 {
-			bgfx.SetTexture(0, uTexColor, textureBark)
-			bgfx.SetTexture(1, uTexStipple, textureStipple)
-			bgfx.SetUniform(uStipple, &stippleInv, 1)
-			meshTrunk[targetLOD].Submit(0, prog, mtx, stateOpaque)
-
-			bgfx.SetTexture(0, uTexColor, textureLeafs)
-			bgfx.SetTexture(1, uTexStipple, textureStipple)
-			bgfx.SetUniform(uStipple, &stippleInv, 1)
-			meshTop[targetLOD].Submit(0, prog, mtx, stateTransparent)
+			queue.SetTexture(0, uTexColor, textureLeafs)
+			queue.SetTexture(1, uTexStipple, textureStipple)
+			uStipple.SetTo(&queue, stippleInv)
+			meshTop[targetLOD].SubmitTo(&queue, 0, prog, mtx, stateTransparent)
+
+			queue.SetTexture(0, uTexColor, textureBark)
+			queue.SetTexture(1, uTexStipple, textureStipple)
+			uStipple.SetTo(&queue, stippleInv)
+			meshTrunk[targetLOD].SubmitTo(&queue, 0, prog, mtx, stateOpaque)
 		}
+		queue.Flush()
+		bgfx.DebugTextPrintf(0, 5, 0x0f, "Draws: %d (%d opaque, %d transparent)",
+			queue.Stats.Items, queue.Stats.Opaque, queue.Stats.Transparent)
+		bgfx.DebugTextPrintf(0, 6, 0x0f, "Program changes: %d, state changes: %d",
+			queue.Stats.ProgramChanges, queue.Stats.StateChanges)
 
 		lod := 0
 		if distance > 2.5 {
@@ -170,7 +165,6 @@ func main() {
 			currLOD = targetLOD
 			transitionFrame = 0
 		}
-
-		bgfx.Frame()
 	}
+	return frame, cleanup
 }
@@ -3,7 +3,9 @@ package main
 import (
 	"github.com/james4k/go-bgfx"
 	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
 	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/scene"
 	"j4k.co/cgm"
 	"j4k.co/cgm/mat4"
 )
@@ -40,39 +42,88 @@ var indices = []uint16{
 }
 
 func main() {
-	app := example.Open()
-	defer app.Close()
-	bgfx.Init()
-	defer bgfx.Shutdown()
-
-	bgfx.Reset(app.Width, app.Height, 0)
-	bgfx.SetDebug(bgfx.DebugText)
-	bgfx.SetViewClear(
-		0,
-		bgfx.ClearColor|bgfx.ClearDepth,
-		0x303030ff,
-		1.0,
-		0,
-	)
+	// Draw as many frames as possible.
+	example.DefaultOptions.VSync = false
+	example.Run(example.Example{
+		Description: "Draw stress, maximizing number of draw calls.",
+		Setup:       setup,
+	})
+}
 
+func setup(app *example.Application) (frame, cleanup func()) {
 	var vd bgfx.VertexDecl
 	vd.Begin()
 	vd.Add(bgfx.AttribPosition, 3, bgfx.AttribTypeFloat, false, false)
 	vd.Add(bgfx.AttribColor0, 4, bgfx.AttribTypeUint8, true, false)
 	vd.End()
-	vb := bgfx.CreateVertexBuffer(vertices, vd)
-	defer bgfx.DestroyVertexBuffer(vb)
-	ib := bgfx.CreateIndexBuffer(indices)
-	defer bgfx.DestroyIndexBuffer(ib)
+	cube := assets.NewMesh(
+		bgfx.CreateVertexBuffer(vertices, vd),
+		bgfx.CreateIndexBuffer(indices),
+		assets.Bounds{
+			Sphere: assets.Sphere{Radius: 1.7321},
+			AABB: assets.AABB{
+				Min: [3]float32{-1, -1, -1},
+				Max: [3]float32{1, 1, 1},
+			},
+		},
+	)
 	prog := assets.LoadProgram("vs_cubes", "fs_cubes")
-	defer bgfx.DestroyProgram(prog)
+
+	// With batching on, the cubes skip the scene's one draw per cube and
+	// go through the batcher, which draws them all with one instanced
+	// draw when instancing is supported.
+	mat := assets.LoadMaterial("cubes")
+	var batcher assets.Batcher
+	cleanup = func() {
+		cube.Unload()
+		bgfx.DestroyProgram(prog)
+		mat.Destroy()
+	}
+
+	cam := camera.New(
+		[3]float32{0, 0, -35.0},
+		[3]float32{0, 0, 0},
+		[3]float32{0, 1, 0},
+	)
+
+	// The cubes are children of a grid node centered in front of the
+	// camera, and are rebuilt only when dim changes.
+	const step = 0.6
+	var (
+		scn   = scene.New()
+		grid  = scene.NewNode("grid")
+		cubes []*scene.Node
+		built int
+	)
+	scn.Root.Add(grid)
+	build := func(dim int) {
+		grid.RemoveAll()
+		cubes = cubes[:0]
+		grid.SetTranslation(
+			-step*float32(dim)/2.0,
+			-step*float32(dim)/2.0,
+			-15,
+		)
+		for i := 0; i < dim*dim*dim; i++ {
+			n := scene.NewMeshNode("cube", &cube, prog)
+			n.State = bgfx.StateDefault
+			grid.Add(n)
+			cubes = append(cubes, n)
+		}
+		built = dim
+	}
 
 	var (
 		avgdt, totaldt float32
 		nframes        int
 		dim            = 12
+		batched        = false
 	)
-	for app.Continue() {
+
+	frame = func() {
+		if app.KeyPressed(example.KeyB) {
+			batched = !batched
+		}
 		dt := app.DeltaTime
 		if totaldt >= 1.0 {
 			avgdt = totaldt / float32(nframes)
@@ -87,34 +138,13 @@ func main() {
 		totaldt += dt
 		nframes++
 
-		var (
-			eye = [3]float32{0, 0, -35.0}
-			at  = [3]float32{0, 0, 0}
-			up  = [3]float32{0, 1, 0}
-		)
-		view := mat4.LookAtLH(eye, at, up)
-		proj := mat4.PerspectiveLH(
-			cgm.ToRadians(60),
-			float32(app.Width)/float32(app.Height),
-			0.1, 100.0,
-		)
-		bgfx.SetViewTransform(0, view, proj)
-		bgfx.SetViewRect(0, 0, 0, app.Width, app.Height)
-		bgfx.DebugTextClear()
-		bgfx.DebugTextPrintf(0, 1, 0x4f, app.Title)
-		bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: Draw stress, maximizing number of draw calls.")
-		bgfx.DebugTextPrintf(0, 3, 0x0f, "Frame: % 7.3f[ms]", dt*1000.0)
-		bgfx.DebugTextPrintf(0, 5, 0x0f, "Draw calls: %d", dim*dim*dim)
-		bgfx.DebugTextPrintf(0, 6, 0x0f, "Dim: %d", dim)
-		bgfx.DebugTextPrintf(0, 7, 0x0f, "AvgFrame: % 7.3f[ms]", avgdt*1000.0)
-		bgfx.Submit(0)
+		cam.Update(app)
+		bgfx.SetViewTransform(0, cam.View(), cam.Proj())
 
-		const step = 0.6
-		pos := [3]float32{
-			-step * float32(dim) / 2.0,
-			-step * float32(dim) / 2.0,
-			-15,
+		if built != dim {
+			build(dim)
 		}
+		i := 0
 		for z := 0; z < dim; z++ {
 			for y := 0; y < dim; y++ {
 				for x := 0; x < dim; x++ {
@@ -124,20 +154,38 @@ func main() {
 						cgm.Radians(app.Time)+cgm.Radians(z)*0.13,
 					)
 					mtx = mat4.Mul(mtx, mat4.Scale(0.25, 0.25, 0.25))
-					mtx[12] = pos[0] + float32(x)*step
-					mtx[13] = pos[1] + float32(y)*step
-					mtx[14] = pos[2] + float32(z)*step
-
-					bgfx.SetTransform(mtx)
-					bgfx.SetProgram(prog)
-					bgfx.SetVertexBuffer(vb)
-					bgfx.SetIndexBuffer(ib)
-					bgfx.SetState(bgfx.StateDefault)
-					bgfx.Submit(0)
+					mtx[12] = float32(x) * step
+					mtx[13] = float32(y) * step
+					mtx[14] = float32(z) * step
+					cubes[i].SetLocal(mtx)
+					i++
 				}
 			}
 		}
-
-		bgfx.Frame()
+		if batched {
+			frustum := cam.Frustum()
+			bounds := cube.BoundingSphere()
+			culled := 0
+			for _, n := range cubes {
+				world := n.World()
+				if !frustum.IntersectsSphere(bounds.Transform(world)) {
+					culled++
+					continue
+				}
+				batcher.Add(&cube, mat, world)
+			}
+			batcher.Flush(0)
+			bs := batcher.Stats
+			bgfx.DebugTextPrintf(0, 5, 0x0f, "Draw calls: %d, %d saved by batching [B]", bs.Draws, bs.Saved())
+			bgfx.DebugTextPrintf(0, 6, 0x0f, "Culled: %d", culled)
+		} else {
+			scn.Submit(0, cam)
+			stats := scn.Stats
+			bgfx.DebugTextPrintf(0, 5, 0x0f, "Draw calls: %d, unbatched [B]", stats.Groups-stats.GroupsCulled)
+			bgfx.DebugTextPrintf(0, 6, 0x0f, "Culled: %d", stats.GroupsCulled)
+		}
+		bgfx.DebugTextPrintf(0, 7, 0x0f, "Dim: %d", dim)
+		bgfx.DebugTextPrintf(0, 8, 0x0f, "AvgFrame: % 7.3f[ms]", avgdt*1000.0)
 	}
+	return frame, cleanup
 }
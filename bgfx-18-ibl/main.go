@@ -0,0 +1,190 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
+	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/graph"
+	"github.com/james4k/go-bgfx-examples/hdr"
+	"github.com/james4k/go-bgfx-examples/render"
+	"j4k.co/cgm/mat4"
+)
+
+var (
+	env          = flag.String("env", "", "environment in assets/textures: an equirectangular .hdr or .exr, or six faces named with %s for px, nx, py, ny, pz and nz; a procedural sky if empty")
+	specularSize = flag.Int("size", 128, "size of the faces of the prefiltered specular cube map")
+	samples      = flag.Int("samples", 64, "GGX samples per texel when prefiltering")
+)
+
+const (
+	irradianceSize = 32
+	lutSize        = 128
+	lutSamples     = 256
+)
+
+var albedos = []struct {
+	name  string
+	color [4]float32
+}{
+	{"gold", [4]float32{1, 0.78, 0.34, 1}},
+	{"silver", [4]float32{0.97, 0.96, 0.91, 1}},
+	{"copper", [4]float32{0.95, 0.64, 0.54, 1}},
+	{"red plastic", [4]float32{0.8, 0.1, 0.1, 1}},
+	{"white", [4]float32{0.9, 0.9, 0.9, 1}},
+}
+
+func main() {
+	example.Run(example.Example{
+		Description: "Image based lighting.",
+		Setup:       setup,
+	})
+}
+
+func setup(app *example.Application) (frame, cleanup func()) {
+	var src *assets.Cubemap
+	if *env == "" {
+		src = assets.CubemapFromFunc(256, assets.ProceduralSky)
+	} else {
+		src = assets.LoadCubemapImage(*env)
+	}
+	start := time.Now()
+	var (
+		sh         = assets.ProjectSH(src)
+		specular   = assets.PrefilterGGX(src, *specularSize, *samples)
+		irradiance = assets.IrradianceCubemap(sh, irradianceSize)
+		lut        = assets.BRDFLUT(lutSize, lutSamples)
+	)
+	log.Printf("ibl: baked %d specular levels in %v", len(specular), time.Since(start))
+
+	const clamp = bgfx.TextureUClamp | bgfx.TextureVClamp | bgfx.TextureWClamp
+	var (
+		specularTex   = assets.CubemapTexture(specular, clamp)
+		irradianceTex = irradiance.Texture(clamp)
+		lutTex        = assets.TextureFromImage(lut, bgfx.TextureFormatRGBA16F, clamp)
+	)
+
+	var (
+		prog    = assets.LoadProgram("vs_ibl_mesh", "fs_ibl_mesh")
+		skybox  = hdr.NewSkyboxProgram(assets.LoadProgram("vs_hdr_skybox", "fs_ibl_skybox"), specularTex)
+		mesh    = assets.LoadMesh("bunny")
+		shCoeff = sh.Convolved().Vec4s()
+	)
+
+	var (
+		uTexSpecular   = bgfx.CreateUniform("u_texSpecular", bgfx.Uniform1i, 1)
+		uTexIrradiance = bgfx.CreateUniform("u_texIrradiance", bgfx.Uniform1i, 1)
+		uTexBrdf       = bgfx.CreateUniform("u_texBrdf", bgfx.Uniform1i, 1)
+		uCamPos        = render.NewUniform[[4]float32]("u_camPos", 1)
+		uAlbedo        = render.NewUniform[[4]float32]("u_albedo", 1)
+		uMaterial      = render.NewUniform[[4]float32]("u_material", 1)
+		uIBL           = render.NewUniform[[4]float32]("u_ibl", 1)
+		uSH            = render.NewUniform[[4]float32]("u_sh", 9)
+	)
+
+	cam := camera.New(
+		[3]float32{0, 1, -2.5},
+		[3]float32{0, 1, 0},
+		[3]float32{0, 1, 0},
+	)
+	cam.Controller = camera.NewOrbit(cam.At, 2.5)
+
+	var (
+		albedo        = 0
+		metallic      = float32(1)
+		roughness     = float32(0.3)
+		ev            = float32(0)
+		skyLevel      = float32(0)
+		useIrradiance = false
+	)
+
+	g := graph.New()
+	g.Views = &app.Views
+	skybox.AddPass(g, nil, app.Options.Clear)
+	g.AddPass(&graph.Pass{
+		Name: "mesh",
+		Execute: func(c *graph.Context) {
+			bgfx.SetViewTransform(c.View, cam.View(), cam.Proj())
+			bgfx.SetTexture(0, uTexSpecular, specularTex)
+			bgfx.SetTexture(1, uTexIrradiance, irradianceTex)
+			bgfx.SetTexture(2, uTexBrdf, lutTex)
+			mesh.Submit(c.View, prog, mat4.Identity(), 0)
+		},
+	})
+
+	cleanup = func() {
+		g.Destroy()
+		skybox.Destroy()
+		mesh.Unload()
+		bgfx.DestroyProgram(prog)
+		bgfx.DestroyTexture(specularTex)
+		bgfx.DestroyTexture(irradianceTex)
+		bgfx.DestroyTexture(lutTex)
+		bgfx.DestroyUniform(uTexSpecular)
+		bgfx.DestroyUniform(uTexIrradiance)
+		bgfx.DestroyUniform(uTexBrdf)
+		uCamPos.Destroy()
+		uAlbedo.Destroy()
+		uMaterial.Destroy()
+		uIBL.Destroy()
+		uSH.Destroy()
+	}
+
+	frame = func() {
+		dt := app.DeltaTime
+		if app.KeyPressed(example.KeyB) {
+			albedo = (albedo + 1) % len(albedos)
+		}
+		if app.KeyPressed(example.KeyM) {
+			useIrradiance = !useIrradiance
+		}
+		metallic = clamp01(metallic + app.Axis(example.KeyI, example.KeyK)*dt)
+		roughness = clamp01(roughness + app.Axis(example.KeyO, example.KeyL)*dt)
+		ev += app.Axis(example.KeyEqual, example.KeyMinus) * 2 * dt
+		skyLevel += app.Axis(example.KeyRightBracket, example.KeyLeftBracket) * 2 * dt
+		lastLevel := float32(len(specular) - 1)
+		if skyLevel < 0 {
+			skyLevel = 0
+		} else if skyLevel > lastLevel {
+			skyLevel = lastLevel
+		}
+
+		cam.Update(app)
+		skybox.SetCamera(cam.View(), cam.Proj())
+		irradianceMode := float32(0)
+		if useIrradiance {
+			irradianceMode = 1
+		}
+		uCamPos.Set([4]float32{cam.Eye[0], cam.Eye[1], cam.Eye[2], 0})
+		uAlbedo.Set(albedos[albedo].color)
+		uMaterial.Set([4]float32{metallic, roughness, 0, 0})
+		uIBL.Set([4]float32{hdr.ExposureScale(ev), lastLevel, irradianceMode, skyLevel})
+		uSH.Set(shCoeff...)
+
+		g.Render(app.Width, app.Height)
+
+		diffuse := "spherical harmonics"
+		if useIrradiance {
+			diffuse = "irradiance cube map"
+		}
+		bgfx.DebugTextPrintf(0, 5, 0x0f, "Material: %s [B], metallic %.2f [I/K], roughness %.2f [O/L]",
+			albedos[albedo].name, metallic, roughness)
+		bgfx.DebugTextPrintf(0, 6, 0x0f, "Diffuse: %s [M]", diffuse)
+		bgfx.DebugTextPrintf(0, 7, 0x0f, "EV %+.1f [-/=], sky level %.1f [[/]]", ev, skyLevel)
+	}
+	return frame, cleanup
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"math"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
+	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/hdr"
+	"github.com/james4k/go-bgfx-examples/light"
+	"github.com/james4k/go-bgfx-examples/pbr"
+	"j4k.co/cgm"
+	"j4k.co/cgm/mat4"
+)
+
+// The spheres get rougher from left to right, and more metallic from
+// bottom to top.
+const (
+	gridSize    = 7
+	gridSpacing = 1.2
+)
+
+var albedos = []struct {
+	name  string
+	color [4]float32
+}{
+	{"gold", [4]float32{1, 0.78, 0.34, 1}},
+	{"red", [4]float32{0.8, 0.1, 0.1, 1}},
+	{"white", [4]float32{0.9, 0.9, 0.9, 1}},
+}
+
+func main() {
+	example.Run(example.Example{
+		Description: "Physically based shading.",
+		Setup:       setup,
+	})
+}
+
+func setup(app *example.Application) (frame, cleanup func()) {
+	var (
+		shader    = pbr.NewShader()
+		sphere    = assets.SphereMesh(0.5, 32, 48)
+		marker    = assets.SphereMesh(0.05, 8, 12)
+		texColor  = assets.LoadTexture("fieldstone-rgba.dds", 0)
+		texNormal = assets.LoadTexture("fieldstone-n.dds", 0)
+		volumes   = light.NewDebugDrawer()
+		manager   = new(light.Manager)
+	)
+	cleanup = func() {
+		shader.Destroy()
+		volumes.Destroy()
+		sphere.Unload()
+		marker.Unload()
+		bgfx.DestroyTexture(texColor)
+		bgfx.DestroyTexture(texNormal)
+	}
+
+	cam := camera.New(
+		[3]float32{0, 0, -9},
+		[3]float32{0, 0, 0},
+		[3]float32{0, 1, 0},
+	)
+	cam.Controller = camera.NewOrbit(cam.At, 9)
+
+	var (
+		albedo      = 0
+		textured    = false
+		showVolumes = false
+		ev          = float32(0)
+		bounds      = sphere.BoundingSphere()
+		enabled     = map[light.Type]bool{
+			light.Directional: true,
+			light.Point:       true,
+			light.Spot:        true,
+		}
+	)
+
+	frame = func() {
+		if app.KeyPressed(example.KeyB) {
+			albedo = (albedo + 1) % len(albedos)
+		}
+		if app.KeyPressed(example.KeyT) {
+			textured = !textured
+		}
+		if app.KeyPressed(example.KeyV) {
+			showVolumes = !showVolumes
+		}
+		for i, t := range []light.Type{light.Directional, light.Point, light.Spot} {
+			if app.KeyPressed(example.Key1 + example.Key(i)) {
+				enabled[t] = !enabled[t]
+			}
+		}
+		ev += app.Axis(example.KeyEqual, example.KeyMinus) * 2 * app.DeltaTime
+
+		cam.Update(app)
+		bgfx.SetViewTransform(0, cam.View(), cam.Proj())
+
+		manager.Lights = manager.Lights[:0]
+		for _, l := range sceneLights(app.Time) {
+			if enabled[l.Type] {
+				manager.Lights = append(manager.Lights, l)
+			}
+		}
+		manager.ResetStats()
+		shader.SetLighting(pbr.Lighting{
+			Eye:      cam.Eye,
+			Ambient:  [3]float32{0.03, 0.03, 0.04},
+			Exposure: hdr.ExposureScale(ev),
+		})
+
+		mat := pbr.Material{Albedo: albedos[albedo].color}
+		if textured {
+			mat.Maps = map[pbr.Slot]bgfx.Texture{
+				pbr.AlbedoMap: texColor,
+				pbr.NormalMap: texNormal,
+			}
+		}
+		const offset = (gridSize - 1) * gridSpacing / 2
+		for y := 0; y < gridSize; y++ {
+			mat.Metallic = float32(y) / (gridSize - 1)
+			for x := 0; x < gridSize; x++ {
+				mat.Roughness = float32(x) / (gridSize - 1)
+				mtx := mat4.Identity()
+				mtx[12] = float32(x)*gridSpacing - offset
+				mtx[13] = float32(y)*gridSpacing - offset
+				shader.SetLights(manager.Select(bounds.Transform(mtx), light.Max))
+				shader.Submit(0, &sphere, &mat, mtx)
+			}
+		}
+
+		// Mark the point and spot lights with small glowing spheres,
+		// which need no lights.
+		shader.SetLights(nil)
+		for _, l := range manager.Lights {
+			if l.Type == light.Directional {
+				continue
+			}
+			m := pbr.Material{Albedo: [4]float32{0, 0, 0, 1}, Emissive: l.Color}
+			mtx := mat4.Identity()
+			mtx[12], mtx[13], mtx[14] = l.Position[0], l.Position[1], l.Position[2]
+			shader.Submit(0, &marker, &m, mtx)
+		}
+		if showVolumes {
+			volumes.Draw(0, manager.Lights)
+		}
+
+		bgfx.DebugTextPrintf(0, 5, 0x0f, "Albedo: %s [B], textures %v [T]", albedos[albedo].name, textured)
+		bgfx.DebugTextPrintf(0, 6, 0x0f, "Lights: directional %v [1], point %v [2], spot %v [3]",
+			enabled[light.Directional], enabled[light.Point], enabled[light.Spot])
+		ls := manager.Stats
+		bgfx.DebugTextPrintf(0, 7, 0x0f, "%d lights, %.1f per sphere, %d culled, %d dropped; volumes %v [V]",
+			len(manager.Lights), float32(ls.Selected)/float32(ls.Objects), ls.Culled, ls.Dropped, showVolumes)
+		bgfx.DebugTextPrintf(0, 8, 0x0f, "EV %+.1f [-/=]; roughness increases to the right, metalness upwards", ev)
+	}
+	return frame, cleanup
+}
+
+// sceneLights returns a sun, two point lights circling the grid, a spot
+// light sweeping across it and a ring of small point lights in front of
+// it at time t. There are more than light.Max, so each sphere is lit by
+// the ones nearest to it.
+func sceneLights(t float32) []light.Light {
+	sin := func(f float32) float32 { return float32(math.Sin(float64(f))) }
+	cos := func(f float32) float32 { return float32(math.Cos(float64(f))) }
+	lights := []light.Light{
+		{
+			Type:      light.Directional,
+			Direction: [3]float32{-0.4, -0.6, 1},
+			Color:     [3]float32{1, 0.95, 0.85},
+			Intensity: 2,
+		},
+		{
+			Type:      light.Point,
+			Position:  [3]float32{4 * sin(t*0.7), 3 * cos(t*0.5), -2},
+			Color:     [3]float32{1, 0.5, 0.2},
+			Intensity: 20,
+			Range:     8,
+		},
+		{
+			Type:      light.Point,
+			Position:  [3]float32{4 * cos(t*0.6), 3 * sin(t*0.9), -2},
+			Color:     [3]float32{0.2, 0.5, 1},
+			Intensity: 20,
+			Range:     8,
+		},
+		{
+			Type:      light.Spot,
+			Position:  [3]float32{0, 0, -6},
+			Direction: [3]float32{2 * sin(t*0.4), 2 * cos(t*0.3), 6},
+			Color:     [3]float32{1, 1, 1},
+			Intensity: 60,
+			Range:     12,
+			Inner:     cgm.Radians(0.15),
+			Outer:     cgm.Radians(0.3),
+		},
+	}
+	const ringLights = 12
+	for i := 0; i < ringLights; i++ {
+		a := t*0.3 + float32(i)*2*math.Pi/ringLights
+		lights = append(lights, light.Light{
+			Type:      light.Point,
+			Position:  [3]float32{3.2 * cos(a), 3.2 * sin(a), -1},
+			Color:     [3]float32{0.5 + 0.5*cos(a), 0.5 + 0.5*cos(a+2), 0.5 + 0.5*cos(a+4)},
+			Intensity: 3,
+			Range:     2.5,
+		})
+	}
+	return lights
+}
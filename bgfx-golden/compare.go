@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// maxDelta is the largest possible value of yiqDelta, between red and
+// cyan.
+const maxDelta = 35215
+
+// compare returns the number of pixels in got that differ perceptually
+// from ref by more than threshold, along with an image that shows ref
+// faded to gray with the differing pixels in red.
+func compare(ref, got image.Image, threshold float64) (*image.RGBA, int, error) {
+	b := ref.Bounds()
+	if b.Size() != got.Bounds().Size() {
+		return nil, 0, errors.New("image size differs from reference")
+	}
+	var (
+		diff  = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		off   = got.Bounds().Min.Sub(b.Min)
+		limit = maxDelta * threshold * threshold
+		n     = 0
+	)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c0 := color.NRGBAModel.Convert(ref.At(x, y)).(color.NRGBA)
+			c1 := color.NRGBAModel.Convert(got.At(x+off.X, y+off.Y)).(color.NRGBA)
+			dx, dy := x-b.Min.X, y-b.Min.Y
+			if yiqDelta(c0, c1) > limit {
+				diff.SetRGBA(dx, dy, color.RGBA{0xff, 0, 0, 0xff})
+				n++
+				continue
+			}
+			l := uint8(255 - (255-luma(c0))/4)
+			diff.SetRGBA(dx, dy, color.RGBA{l, l, l, 0xff})
+		}
+	}
+	return diff, n, nil
+}
+
+func luma(c color.NRGBA) float64 {
+	return 0.29889531*float64(c.R) + 0.58662247*float64(c.G) + 0.11448223*float64(c.B)
+}
+
+// yiqDelta returns the squared distance between two colors in the YIQ
+// color space, weighted to approximate perceived difference. See "Measuring
+// perceived color difference using YIQ NTSC transmission color space in
+// mobile applications" by Kotsarenko and Ramos.
+func yiqDelta(c0, c1 color.NRGBA) float64 {
+	var (
+		r = float64(c0.R) - float64(c1.R)
+		g = float64(c0.G) - float64(c1.G)
+		b = float64(c0.B) - float64(c1.B)
+
+		y = r*0.29889531 + g*0.58662247 + b*0.11448223
+		i = r*0.59597799 - g*0.27417610 - b*0.32180189
+		q = r*0.21147017 - g*0.52261711 + b*0.31114694
+	)
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
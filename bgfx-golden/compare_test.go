@@ -0,0 +1,104 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func fill(r image.Rectangle, c color.NRGBA) *image.NRGBA {
+	m := image.NewNRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			m.SetNRGBA(x, y, c)
+		}
+	}
+	return m
+}
+
+func TestCompareIdentical(t *testing.T) {
+	white := color.NRGBA{255, 255, 255, 255}
+	ref := fill(image.Rect(0, 0, 4, 3), white)
+	diff, n, err := compare(ref, fill(image.Rect(0, 0, 4, 3), white), 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("%d pixels differ, want 0", n)
+	}
+	// Matching pixels are the reference faded towards white.
+	if got := diff.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("diff of white is %v", got)
+	}
+}
+
+func TestCompareThreshold(t *testing.T) {
+	gray := color.NRGBA{128, 128, 128, 255}
+	ref := fill(image.Rect(0, 0, 3, 1), gray)
+	got := fill(image.Rect(0, 0, 3, 1), gray)
+	got.SetNRGBA(1, 0, color.NRGBA{131, 128, 126, 255}) // barely visible
+	got.SetNRGBA(2, 0, color.NRGBA{255, 0, 0, 255})
+	diff, n, err := compare(ref, got, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("%d pixels differ, want 1", n)
+	}
+	red := color.RGBA{255, 0, 0, 255}
+	if c := diff.RGBAAt(2, 0); c != red {
+		t.Errorf("differing pixel is %v in the diff, want %v", c, red)
+	}
+	if c := diff.RGBAAt(1, 0); c == red {
+		t.Errorf("pixel under the threshold is marked as differing")
+	}
+
+	// With less tolerance, the small difference counts too.
+	if _, n, _ := compare(ref, got, 0.005); n != 2 {
+		t.Errorf("%d pixels differ at a threshold of 0.005, want 2", n)
+	}
+}
+
+func TestCompareBounds(t *testing.T) {
+	black := color.NRGBA{0, 0, 0, 255}
+	ref := fill(image.Rect(0, 0, 2, 2), black)
+	got := fill(image.Rect(10, 20, 12, 22), black)
+	got.SetNRGBA(11, 21, color.NRGBA{255, 255, 255, 255})
+	diff, n, err := compare(ref, got, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || diff.RGBAAt(1, 1).G != 0 {
+		t.Errorf("got %d differing pixels, diff at 1,1 %v; want the offset pixel", n, diff.RGBAAt(1, 1))
+	}
+	if diff.Bounds() != image.Rect(0, 0, 2, 2) {
+		t.Errorf("diff bounds %v", diff.Bounds())
+	}
+
+	if _, _, err := compare(ref, fill(image.Rect(0, 0, 3, 2), black), 0.1); err == nil {
+		t.Error("no error for images of different sizes")
+	}
+}
+
+func TestYIQDelta(t *testing.T) {
+	black := color.NRGBA{0, 0, 0, 255}
+	white := color.NRGBA{255, 255, 255, 255}
+	red := color.NRGBA{255, 0, 0, 255}
+	cyan := color.NRGBA{0, 255, 255, 255}
+	if d := yiqDelta(red, cyan); d < maxDelta-1 || d > maxDelta+1 {
+		t.Errorf("delta between red and cyan is %g, want %d", d, maxDelta)
+	}
+	if d := yiqDelta(black, white); d > maxDelta {
+		t.Errorf("delta between black and white is %g, above the maximum", d)
+	}
+	if d := yiqDelta(white, white); d != 0 {
+		t.Errorf("delta between equal colors is %g", d)
+	}
+	// Green is perceived as brighter than blue, so the same change
+	// counts for more.
+	g := yiqDelta(black, color.NRGBA{0, 64, 0, 255})
+	b := yiqDelta(black, color.NRGBA{0, 0, 64, 255})
+	if g <= b {
+		t.Errorf("green delta %g is not above blue delta %g", g, b)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Command bgfx-golden runs each example for a fixed amount of simulated
+time, captures its last frame and compares it against a reference image
+in assets/golden. When a frame differs by more than the tolerance, the
+captured frame and a diff image are written to the output directory.
+
+The examples are run as subprocesses with -headless, -frames, -step and
+-capture, so they must be installed (go get .../...) and in $PATH, or in
+the directory given by -bin.
+
+There is no GPU requirement beyond an OpenGL context, so on a headless
+Linux box a software rasterizer works fine:
+
+	$ LIBGL_ALWAYS_SOFTWARE=1 xvfb-run -s "-screen 0 1280x720x24" bgfx-golden
+
+Pass -update to write the captured frames as the new references. No
+references have been captured yet, so until they are committed to
+assets/golden the tool only fails, and checks nothing.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+var examples = []string{
+	"bgfx-00-helloworld",
+	"bgfx-01-cubes",
+	"bgfx-02-metaballs",
+	"bgfx-03-raymarch",
+	"bgfx-04-mesh",
+	"bgfx-05-instancing",
+	"bgfx-06-bump",
+	"bgfx-09-hdr",
+	"bgfx-12-lod",
+	"bgfx-17-drawstress",
+	"bgfx-18-ibl",
+	"bgfx-19-pbr",
+}
+
+var (
+	bin       = flag.String("bin", "", "directory containing the example binaries; empty searches $PATH")
+	refDir    = flag.String("ref", "assets/golden", "directory of reference images")
+	outDir    = flag.String("out", "golden-out", "directory for captured frames and diff images")
+	run       = flag.String("run", "", "only run examples matching this regular expression")
+	update    = flag.Bool("update", false, "write captured frames as the new reference images")
+	seconds   = flag.Float64("time", 2.0, "simulated seconds to run each example for")
+	step      = flag.Float64("step", 1.0/60, "simulated seconds per frame")
+	threshold = flag.Float64("threshold", 0.1, "perceptual color difference (0-1) above which a pixel differs")
+	maxDiff   = flag.Float64("maxdiff", 0.001, "fraction of differing pixels tolerated")
+)
+
+func main() {
+	flag.Parse()
+	var filter *regexp.Regexp
+	if *run != "" {
+		var err error
+		filter, err = regexp.Compile(*run)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if _, err := os.Stat(*refDir); os.IsNotExist(err) && !*update {
+		log.Fatalf("%s does not exist, so there is nothing to compare against; "+
+			"run with -update on a known good build and commit the references", *refDir)
+	}
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatalln(err)
+	}
+	failed := 0
+	for _, name := range examples {
+		if filter != nil && !filter.MatchString(name) {
+			continue
+		}
+		if err := check(name); err != nil {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("ok   %s\n", name)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func check(name string) error {
+	got, err := capture(name)
+	if err != nil {
+		return err
+	}
+	refPath := filepath.Join(*refDir, name+".png")
+	if *update {
+		return writePNG(refPath, got)
+	}
+	ref, err := readPNG(refPath)
+	if os.IsNotExist(err) {
+		if err := writePNG(filepath.Join(*outDir, name+".png"), got); err != nil {
+			return err
+		}
+		return fmt.Errorf("no reference %s; run with -update on a known good build to create it", refPath)
+	}
+	if err != nil {
+		return err
+	}
+	diff, n, err := compare(ref, got, *threshold)
+	if err != nil {
+		return err
+	}
+	b := ref.Bounds()
+	if float64(n) <= *maxDiff*float64(b.Dx()*b.Dy()) {
+		return nil
+	}
+	if err := writePNG(filepath.Join(*outDir, name+".png"), got); err != nil {
+		return err
+	}
+	if err := writePNG(filepath.Join(*outDir, name+"-diff.png"), diff); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d pixels differ from %s", n, refPath)
+}
+
+// capture runs the named example until the simulated time has elapsed
+// and returns its last frame.
+func capture(name string) (image.Image, error) {
+	path := name
+	if *bin != "" {
+		path = filepath.Join(*bin, name)
+	}
+	shot, err := filepath.Abs(filepath.Join(*outDir, name+".tga"))
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(shot)
+	frames := int(*seconds / *step)
+	if frames < 1 {
+		frames = 1
+	}
+	cmd := exec.Command(path,
+		"-headless",
+		"-frames", strconv.Itoa(frames),
+		"-step", strconv.FormatFloat(*step, 'g', -1, 64),
+		"-capture", shot,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(shot)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeTGA(f)
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, m image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+)
+
+// decodeTGA decodes the uncompressed true-color TGA files that bgfx
+// writes for screenshots.
+func decodeTGA(r io.Reader) (image.Image, error) {
+	var hdr struct {
+		IDLength     uint8
+		ColorMapType uint8
+		ImageType    uint8
+		ColorMap     [5]uint8
+		X, Y         uint16
+		Width        uint16
+		Height       uint16
+		Depth        uint8
+		Descriptor   uint8
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.ImageType != 2 || hdr.ColorMapType != 0 {
+		return nil, fmt.Errorf("tga: unsupported image type %d", hdr.ImageType)
+	}
+	if hdr.Depth != 24 && hdr.Depth != 32 {
+		return nil, fmt.Errorf("tga: unsupported depth %d", hdr.Depth)
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, int64(hdr.IDLength)); err != nil {
+		return nil, err
+	}
+	var (
+		w, h    = int(hdr.Width), int(hdr.Height)
+		bpp     = int(hdr.Depth) / 8
+		topDown = hdr.Descriptor&0x20 != 0
+		row     = make([]byte, w*bpp)
+		m       = image.NewNRGBA(image.Rect(0, 0, w, h))
+	)
+	for y := 0; y < h; y++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, err
+		}
+		dy := h - 1 - y
+		if topDown {
+			dy = y
+		}
+		pix := m.Pix[dy*m.Stride:]
+		for x := 0; x < w; x++ {
+			src := row[x*bpp:]
+			dst := pix[x*4:]
+			dst[0] = src[2]
+			dst[1] = src[1]
+			dst[2] = src[0]
+			dst[3] = 0xff // backbuffer alpha is meaningless here
+		}
+	}
+	return m, nil
+}
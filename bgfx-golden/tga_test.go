@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+// tga returns a true-color TGA file of w by h pixels with the given
+// pixel data, BGR(A) rows in file order.
+func tga(w, h int, depth, descriptor uint8, id string, pix []byte) []byte {
+	b := []byte{
+		byte(len(id)), 0, 2,
+		0, 0, 0, 0, 0,
+		0, 0, 0, 0,
+		byte(w), byte(w >> 8), byte(h), byte(h >> 8),
+		depth, descriptor,
+	}
+	b = append(b, id...)
+	return append(b, pix...)
+}
+
+func TestDecodeTGABottomUp(t *testing.T) {
+	// The first row in the file is the bottom of the image.
+	pix := []byte{
+		0, 0, 255, 0, 255, 0, // red, green
+		255, 0, 0, 255, 255, 255, // blue, white
+	}
+	m, err := decodeTGA(bytes.NewReader(tga(2, 2, 24, 0, "bgfx", pix)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [2][2]color.NRGBA{
+		{{0, 0, 255, 255}, {255, 255, 255, 255}},
+		{{255, 0, 0, 255}, {0, 255, 0, 255}},
+	}
+	for y := range want {
+		for x, c := range want[y] {
+			if got := m.At(x, y); got != c {
+				t.Errorf("pixel %d,%d is %v, want %v", x, y, got, c)
+			}
+		}
+	}
+}
+
+func TestDecodeTGATopDown(t *testing.T) {
+	pix := []byte{
+		0, 0, 255, 0, // red, with alpha 0
+		255, 0, 0, 0, // blue
+	}
+	m, err := decodeTGA(bytes.NewReader(tga(1, 2, 32, 0x20, "", pix)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.At(0, 0), (color.NRGBA{255, 0, 0, 255}); got != want {
+		t.Errorf("top pixel is %v, want %v", got, want)
+	}
+	if got, want := m.At(0, 1), (color.NRGBA{0, 0, 255, 255}); got != want {
+		t.Errorf("bottom pixel is %v, want %v", got, want)
+	}
+}
+
+func TestDecodeTGAErrors(t *testing.T) {
+	rle := tga(1, 1, 24, 0, "", []byte{0, 0, 0})
+	rle[2] = 10
+	for name, data := range map[string][]byte{
+		"rle":       rle,
+		"depth":     tga(1, 1, 16, 0, "", []byte{0, 0}),
+		"truncated": tga(2, 2, 24, 0, "", []byte{0, 0, 0, 0, 0, 0}),
+		"header":    {0, 0, 2},
+	} {
+		if _, err := decodeTGA(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Command bgfx-iblbake bakes the textures for image based lighting from an
+environment, as bgfx-18-ibl does when it starts, and writes them as
+RGBA16F DDS files that bgfx can load. Run it from the repository root:
+
+	$ bgfx-iblbake [-size 256] [-samples 256] [environment]
+
+The environment is an equirectangular .hdr or .exr image, or six faces
+named with %s, in assets/textures; without one, the procedural sky is
+baked and named sky. For an environment named <name>.hdr, it writes
+
+	<name>_specular.dds    GGX prefiltered cube map, roughness 0 to 1 down the mips
+	<name>_irradiance.dds  diffuse irradiance cube map
+	brdf_lut.dds           split sum BRDF table
+
+to assets/textures, and prints the spherical harmonics coefficients of
+the irradiance, already convolved, as shaders take them.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/hdrimage"
+)
+
+var (
+	outDir         = flag.String("out", "assets/textures", "directory to write textures to")
+	size           = flag.Int("size", 256, "size of the faces of the specular cube map")
+	samples        = flag.Int("samples", 256, "GGX samples per texel when prefiltering")
+	irradianceSize = flag.Int("irradiance-size", 32, "size of the faces of the irradiance cube map")
+	lutSize        = flag.Int("lut-size", 128, "size of the BRDF table")
+)
+
+func main() {
+	flag.Parse()
+	var (
+		env  *assets.Cubemap
+		name = "sky"
+	)
+	switch flag.NArg() {
+	case 0:
+		env = assets.CubemapFromFunc(*size*2, assets.ProceduralSky)
+	case 1:
+		env = assets.LoadCubemapImage(flag.Arg(0))
+		name = strings.TrimSuffix(filepath.Base(flag.Arg(0)), filepath.Ext(flag.Arg(0)))
+		name = strings.Replace(name, "%s", "", -1)
+		name = strings.Trim(name, "_-.")
+	default:
+		log.Fatalln("usage: bgfx-iblbake [flags] [environment]")
+	}
+
+	sh := assets.ProjectSH(env)
+	writeCube(name+"_specular.dds", assets.PrefilterGGX(env, *size, *samples))
+	writeCube(name+"_irradiance.dds", []*assets.Cubemap{assets.IrradianceCubemap(sh, *irradianceSize)})
+	write("brdf_lut.dds", assets.BRDFLUT(*lutSize, 1024))
+	for _, c := range sh.Convolved() {
+		fmt.Printf("%g %g %g\n", c[0], c[1], c[2])
+	}
+}
+
+func writeCube(name string, mips []*assets.Cubemap) {
+	create(name, func(f *os.File) error {
+		return assets.WriteDDSCube(f, mips)
+	})
+}
+
+func write(name string, img *hdrimage.Image) {
+	create(name, func(f *os.File) error {
+		return assets.WriteDDS(f, img)
+	})
+}
+
+func create(name string, write func(f *os.File) error) {
+	path := filepath.Join(*outDir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := write(f); err != nil {
+		log.Fatalln(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Fprintln(os.Stderr, path)
+}
@@ -0,0 +1,227 @@
+/*
+Command bgfx-shaderpack packs hand-written GLSL shaders into the binary
+format bgfx loads, for the shaders in assets/shaders/src that have no
+counterpart in the original bgfx examples. Run it from the repository
+root after editing a source:
+
+	$ bgfx-shaderpack
+
+It only packs for the OpenGL renderer, and refuses any other -renderer.
+It does not compile anything: the GLSL is stored as written, which only
+the OpenGL renderer accepts. Shaders for Direct3D or GLES have to be
+compiled from bgfx's sources with bgfx's shaderc instead.
+
+Each assets/shaders/src/<name>.glsl is written to
+assets/shaders/glsl/<name>.bin. Names starting with vs_ are vertex
+shaders, and all others fragment shaders.
+
+The binary starts with a uniform table, which is built from the
+source's uniform declarations, and a hash that bgfx compares between
+the vertex and fragment shader of a program. The hash is taken from the
+varying declarations, so shaders written here pair up when their
+varyings match. A fragment shader that pairs with one of the compiled
+shaders instead names it in a comment, and takes its hash:
+
+	// hash: vs_hdr_tonemap
+
+A source can also be packed more than once, with macros defined, for
+variants that differ in a few #ifdef blocks:
+
+	// variant: fs_hdr_adapt_float HDR_FLOAT
+*/
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/james4k/go-bgfx-examples/assets"
+)
+
+var (
+	srcDir   = flag.String("src", "assets/shaders/src", "directory of GLSL sources")
+	outDir   = flag.String("out", "assets/shaders/glsl", "directory to write packed shaders to")
+	renderer = flag.String("renderer", "gl", "renderer to pack for; only gl is supported")
+)
+
+func main() {
+	flag.Parse()
+	if *renderer != "gl" {
+		log.Fatalf("can only pack GLSL for the gl renderer, not %s; use bgfx's shaderc for other renderers", *renderer)
+	}
+	names := flag.Args()
+	if len(names) == 0 {
+		files, err := filepath.Glob(filepath.Join(*srcDir, "*.glsl"))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, f := range files {
+			names = append(names, strings.TrimSuffix(filepath.Base(f), ".glsl"))
+		}
+	}
+	if len(names) == 0 {
+		log.Fatalf("no shaders in %s", *srcDir)
+	}
+	for _, name := range names {
+		src, err := ioutil.ReadFile(filepath.Join(*srcDir, name+".glsl"))
+		if err != nil {
+			log.Fatalln(err)
+		}
+		outputs := map[string][]byte{name: src}
+		for _, v := range variants(src) {
+			var defined bytes.Buffer
+			for _, m := range v.macros {
+				fmt.Fprintf(&defined, "#define %s 1\n", m)
+			}
+			defined.Write(src)
+			outputs[v.name] = defined.Bytes()
+		}
+		for out, src := range outputs {
+			data, err := pack(out, src)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			path := filepath.Join(*outDir, out+".bin")
+			if err := ioutil.WriteFile(path, data, 0644); err != nil {
+				log.Fatalln(err)
+			}
+			fmt.Fprintln(os.Stderr, path)
+		}
+	}
+}
+
+type variant struct {
+	name   string
+	macros []string
+}
+
+// variants returns the variants declared in src.
+func variants(src []byte) []variant {
+	var vs []variant
+	for _, line := range strings.Split(string(src), "\n") {
+		m := variantRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		f := strings.Fields(m[1])
+		vs = append(vs, variant{name: f[0], macros: f[1:]})
+	}
+	return vs
+}
+
+var (
+	uniformRE = regexp.MustCompile(`^\s*uniform\s+(?:(?:lowp|mediump|highp)\s+)?(\w+)\s+(\w+)\s*(?:\[\s*(\d+)\s*\])?\s*;`)
+	varyingRE = regexp.MustCompile(`^\s*varying\s+(?:(?:lowp|mediump|highp)\s+)?\w+\s+\w+`)
+	hashRE    = regexp.MustCompile(`^\s*//\s*hash:\s*(\w+)`)
+	variantRE = regexp.MustCompile(`^\s*//\s*variant:\s*(\w+(?:\s+\w+)*)`)
+)
+
+var uniformTypes = map[string]assets.ShaderUniformType{
+	"int":         assets.ShaderUniform1i,
+	"sampler2D":   assets.ShaderUniform1i,
+	"sampler3D":   assets.ShaderUniform1i,
+	"samplerCube": assets.ShaderUniform1i,
+	"float":       assets.ShaderUniform1f,
+	"vec2":        assets.ShaderUniform2fv,
+	"vec3":        assets.ShaderUniform3fv,
+	"vec4":        assets.ShaderUniform4fv,
+	"mat3":        assets.ShaderUniform3x3fv,
+	"mat4":        assets.ShaderUniform4x4fv,
+}
+
+// pack returns the binary shader named out for the GLSL source src.
+func pack(out string, src []byte) ([]byte, error) {
+	bad := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %s", out, fmt.Sprintf(format, args...))
+	}
+	var (
+		uniforms []assets.ShaderUniform
+		varyings []string
+		hashFrom string
+	)
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if m := hashRE.FindStringSubmatch(text); m != nil {
+			hashFrom = m[1]
+			continue
+		}
+		if m := varyingRE.FindString(text); m != "" {
+			varyings = append(varyings, strings.Join(strings.Fields(m), " "))
+			continue
+		}
+		m := uniformRE.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		typ, ok := uniformTypes[m[1]]
+		if !ok {
+			return nil, bad("line %d: unsupported uniform type %s", line, m[1])
+		}
+		num := 1
+		if m[3] != "" {
+			num, _ = strconv.Atoi(m[3])
+			if num < 1 || num > 255 {
+				return nil, bad("line %d: bad array length %s", line, m[3])
+			}
+		}
+		uniforms = append(uniforms, assets.ShaderUniform{Name: m[2], Type: typ, Num: num})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, bad("%v", err)
+	}
+
+	var hash uint32
+	if hashFrom != "" {
+		data, err := ioutil.ReadFile(filepath.Join(*outDir, hashFrom+".bin"))
+		if err != nil {
+			return nil, bad("%v", err)
+		}
+		if len(data) < 8 || (string(data[:3]) != "VSH" && string(data[:3]) != "FSH") {
+			return nil, bad("%s is not a compiled shader", hashFrom)
+		}
+		hash = binary.LittleEndian.Uint32(data[4:])
+	} else {
+		sort.Strings(varyings)
+		hash = crc32.ChecksumIEEE([]byte(strings.Join(varyings, ";")))
+	}
+
+	var (
+		buf bytes.Buffer
+		le  = binary.LittleEndian
+	)
+	if strings.HasPrefix(out, "vs_") {
+		buf.WriteString("VSH\x03")
+	} else {
+		buf.WriteString("FSH\x03")
+	}
+	binary.Write(&buf, le, hash)
+	binary.Write(&buf, le, uint16(len(uniforms)))
+	for _, u := range uniforms {
+		if len(u.Name) > 255 {
+			return nil, bad("uniform name %s is too long", u.Name)
+		}
+		buf.WriteByte(byte(len(u.Name)))
+		buf.WriteString(u.Name)
+		buf.WriteByte(byte(u.Type))
+		buf.WriteByte(byte(u.Num))
+		binary.Write(&buf, le, uint16(0))     // register index
+		binary.Write(&buf, le, uint16(u.Num)) // register count
+	}
+	binary.Write(&buf, le, uint32(len(src)))
+	buf.Write(src)
+	buf.WriteByte(0)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,130 @@
+/*
+Package camera provides projections, view matrices and input driven
+camera controllers for the examples.
+*/
+package camera
+
+import (
+	"math"
+
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/example"
+	"j4k.co/cgm"
+	"j4k.co/cgm/mat4"
+)
+
+// Projection describes a left-handed perspective or orthographic
+// projection.
+type Projection struct {
+	Ortho bool
+	Fovy  cgm.Radians // vertical field of view, if perspective
+	Size  float32     // height of the view volume, if orthographic
+
+	Near, Far float32
+}
+
+// Perspective returns a perspective projection.
+func Perspective(fovy cgm.Radians, near, far float32) Projection {
+	return Projection{Fovy: fovy, Near: near, Far: far}
+}
+
+// Orthographic returns an orthographic projection size units high,
+// centered on the view direction.
+func Orthographic(size, near, far float32) Projection {
+	return Projection{Ortho: true, Size: size, Near: near, Far: far}
+}
+
+// Matrix returns the projection matrix for the given aspect ratio.
+func (p Projection) Matrix(aspect float32) [16]float32 {
+	if p.Ortho {
+		h := p.Size / 2
+		w := h * aspect
+		return mat4.OrthoLH(-w, w, -h, h, p.Near, p.Far)
+	}
+	return mat4.PerspectiveLH(p.Fovy, aspect, p.Near, p.Far)
+}
+
+// Camera computes view and projection matrices from an eye position, a
+// point to look at and an up vector. If it has a Controller, the
+// controller moves it on every Update.
+type Camera struct {
+	Eye, At, Up [3]float32
+	Projection  Projection
+	Controller  Controller
+
+	view, proj, viewProj [16]float32
+}
+
+// New returns a camera at eye looking at at, with the 60 degree
+// perspective projection from 0.1 to 100 that the examples use.
+func New(eye, at, up [3]float32) *Camera {
+	return &Camera{
+		Eye:        eye,
+		At:         at,
+		Up:         up,
+		Projection: Perspective(cgm.ToRadians(60), 0.1, 100),
+	}
+}
+
+// Update runs the controller, if any, and recomputes the matrices for
+// the current window size. Call it once per frame before using the
+// matrices.
+func (c *Camera) Update(app *example.Application) {
+	if c.Controller != nil {
+		c.Controller.Update(c, app)
+	}
+	aspect := float32(1)
+	if app.Height > 0 {
+		aspect = float32(app.Width) / float32(app.Height)
+	}
+	c.view = mat4.LookAtLH(c.Eye, c.At, c.Up)
+	c.proj = c.Projection.Matrix(aspect)
+	c.viewProj = mat4.Mul(c.proj, c.view)
+}
+
+// View returns the view matrix computed by the last Update.
+func (c *Camera) View() [16]float32 { return c.view }
+
+// Proj returns the projection matrix computed by the last Update.
+func (c *Camera) Proj() [16]float32 { return c.proj }
+
+// ViewProj returns Proj() * View().
+func (c *Camera) ViewProj() [16]float32 { return c.viewProj }
+
+// Frustum returns the world space view frustum.
+func (c *Camera) Frustum() assets.Frustum {
+	return assets.NewFrustum(c.viewProj)
+}
+
+// Forward returns the unit vector the camera looks along.
+func (c *Camera) Forward() [3]float32 {
+	return normalize(sub(c.At, c.Eye))
+}
+
+func add(a, b [3]float32) [3]float32 {
+	return [3]float32{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func sub(a, b [3]float32) [3]float32 {
+	return [3]float32{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func scale(a [3]float32, s float32) [3]float32 {
+	return [3]float32{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func cross(a, b [3]float32) [3]float32 {
+	return [3]float32{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func normalize(a [3]float32) [3]float32 {
+	l := float32(math.Sqrt(float64(a[0]*a[0] + a[1]*a[1] + a[2]*a[2])))
+	if l == 0 {
+		return a
+	}
+	return scale(a, 1/l)
+}
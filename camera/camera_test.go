@@ -0,0 +1,180 @@
+package camera
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james4k/go-bgfx-examples/example"
+	"j4k.co/cgm"
+	"j4k.co/cgm/mat4"
+)
+
+// input is canned keyboard and mouse state.
+type input struct {
+	keys    map[example.Key]bool
+	buttons map[example.MouseButton]bool
+}
+
+func (in input) KeyDown(k example.Key) bool           { return in.keys[k] }
+func (in input) MouseDown(b example.MouseButton) bool { return in.buttons[b] }
+
+// app returns an application for a 1280 by 720 window with the given
+// keys held, running frames of dt seconds.
+func app(dt float32, keys ...example.Key) *example.Application {
+	in := input{keys: make(map[example.Key]bool), buttons: make(map[example.MouseButton]bool)}
+	for _, k := range keys {
+		in.keys[k] = true
+	}
+	return &example.Application{Input: in, Width: 1280, Height: 720, DeltaTime: dt}
+}
+
+func near(a, b [3]float32) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-4 {
+			return false
+		}
+	}
+	return true
+}
+
+// project returns the normalized device coordinates of p.
+func project(m [16]float32, p [3]float32) [3]float32 {
+	v := mat4.Mul4(m, [4]float32{p[0], p[1], p[2], 1})
+	return [3]float32{v[0] / v[3], v[1] / v[3], v[2] / v[3]}
+}
+
+func TestView(t *testing.T) {
+	c := New([3]float32{1, 2, -3}, [3]float32{1, 2, 5}, [3]float32{0, 1, 0})
+	c.Update(app(0))
+	// The eye is at the origin of view space, looking along +Z with
+	// +Y up and +X to the right.
+	v := c.View()
+	for _, tt := range []struct{ world, view [3]float32 }{
+		{c.Eye, [3]float32{0, 0, 0}},
+		{c.At, [3]float32{0, 0, 8}},
+		{[3]float32{1, 3, -3}, [3]float32{0, 1, 0}},
+		{[3]float32{2, 2, -3}, [3]float32{1, 0, 0}},
+	} {
+		if got := mat4.Mul3(v, tt.world); !near(got, tt.view) {
+			t.Errorf("%v is %v in view space, want %v", tt.world, got, tt.view)
+		}
+	}
+	if !near(c.Forward(), [3]float32{0, 0, 1}) {
+		t.Errorf("Forward = %v", c.Forward())
+	}
+	if got, want := c.ViewProj(), mat4.Mul(c.Proj(), c.View()); got != want {
+		t.Errorf("ViewProj is not Proj * View")
+	}
+}
+
+func TestProj(t *testing.T) {
+	const aspect = 1280.0 / 720
+	c := New([3]float32{0, 0, 0}, [3]float32{0, 0, 1}, [3]float32{0, 1, 0})
+	c.Update(app(0))
+	// The edges of the 60 degree field of view, 10 units away, are at
+	// the edges of the screen.
+	y := float32(10 * math.Tan(math.Pi/6))
+	if got := project(c.Proj(), [3]float32{0, y, 10}); math.Abs(float64(got[1]-1)) > 1e-4 {
+		t.Errorf("top edge projects to %v", got)
+	}
+	if got := project(c.Proj(), [3]float32{y * aspect, 0, 10}); math.Abs(float64(got[0]-1)) > 1e-4 {
+		t.Errorf("right edge projects to %v", got)
+	}
+	nearZ := project(c.Proj(), [3]float32{0, 0, 0.1})[2]
+	farZ := project(c.Proj(), [3]float32{0, 0, 100})[2]
+	if !(nearZ < farZ) || math.Abs(float64(farZ-1)) > 1e-4 {
+		t.Errorf("depth %v at the near plane, %v at the far plane", nearZ, farZ)
+	}
+
+	c.Projection = Orthographic(4, 0.1, 100)
+	c.Update(app(0))
+	if got := project(c.Proj(), [3]float32{2 * aspect, 2, 50}); !near([3]float32{got[0], got[1], 0}, [3]float32{1, 1, 0}) {
+		t.Errorf("orthographic corner projects to %v", got)
+	}
+}
+
+func TestOrbit(t *testing.T) {
+	o := NewOrbit([3]float32{1, 0, 0}, 5)
+	o.Smoothing = 0
+	c := New([3]float32{}, [3]float32{}, [3]float32{0, 1, 0})
+	c.Controller = o
+	c.Update(app(1.0 / 60))
+	if !near(c.Eye, [3]float32{1, 0, -5}) || c.At != o.Target {
+		t.Fatalf("eye %v, at %v; want 1, 0, -5 looking at the target", c.Eye, c.At)
+	}
+
+	// Holding left for a second turns 1.5 radians around the target.
+	c.Update(app(1, example.KeyLeft))
+	s, co := math.Sincos(1.5)
+	if want := [3]float32{1 - 5*float32(s), 0, -5 * float32(co)}; !near(c.Eye, want) {
+		t.Errorf("after turning, eye %v, want %v", c.Eye, want)
+	}
+
+	// Scrolling zooms in, and pitch stops short of straight down.
+	a := app(10, example.KeyDown)
+	a.Scroll = 2
+	c.Update(a)
+	if math.Abs(float64(o.Distance-5*0.81)) > 1e-4 {
+		t.Errorf("distance %v after scrolling, want %v", o.Distance, 5*0.81)
+	}
+	if o.Pitch != maxPitch {
+		t.Errorf("pitch %v, want it clamped to %v", o.Pitch, maxPitch)
+	}
+}
+
+func TestOrbitSmoothing(t *testing.T) {
+	o := NewOrbit([3]float32{}, 5)
+	c := New([3]float32{}, [3]float32{}, [3]float32{0, 1, 0})
+	c.Controller = o
+	c.Update(app(1.0 / 60))
+	o.Distance = 10
+	c.Update(app(o.Smoothing))
+	// One smoothing time covers about 63% of the way.
+	want := 5 + 5*(1-float32(math.Exp(-1)))
+	if got := -c.Eye[2]; math.Abs(float64(got-want)) > 1e-3 {
+		t.Errorf("distance %v after one smoothing time, want %v", got, want)
+	}
+}
+
+func TestFly(t *testing.T) {
+	f := NewFly([3]float32{0, 1, 0})
+	f.Smoothing = 0
+	c := New([3]float32{}, [3]float32{}, [3]float32{0, 1, 0})
+	c.Controller = f
+
+	tests := []struct {
+		keys []example.Key
+		want [3]float32 // movement in half a second
+	}{
+		{nil, [3]float32{}},
+		{[]example.Key{example.KeyW}, [3]float32{0, 0, 2.5}},
+		{[]example.Key{example.KeyS}, [3]float32{0, 0, -2.5}},
+		{[]example.Key{example.KeyD}, [3]float32{2.5, 0, 0}},
+		{[]example.Key{example.KeyE}, [3]float32{0, 2.5, 0}},
+		{[]example.Key{example.KeyW, example.KeyLeftShift}, [3]float32{0, 0, 10}},
+	}
+	for _, tt := range tests {
+		before := f.Position
+		c.Update(app(0.5, tt.keys...))
+		if got := sub(f.Position, before); !near(got, tt.want) {
+			t.Errorf("keys %v moved %v, want %v", tt.keys, got, tt.want)
+		}
+		if c.Eye != f.Position || !near(sub(c.At, c.Eye), [3]float32{0, 0, 1}) {
+			t.Errorf("keys %v: eye %v, at %v", tt.keys, c.Eye, c.At)
+		}
+	}
+}
+
+func TestFirstPerson(t *testing.T) {
+	f := NewFirstPerson([3]float32{0, 1.7, 0})
+	f.Smoothing = 0
+	f.Pitch = float32(cgm.ToRadians(45))
+	c := New([3]float32{}, [3]float32{}, [3]float32{0, 1, 0})
+	c.Controller = f
+	// Walking forward while looking up stays at eye height, and E does
+	// not fly.
+	c.Update(app(0.5, example.KeyW, example.KeyE))
+	if !near(f.Position, [3]float32{0, 1.7, 2.5}) {
+		t.Errorf("position %v, want 0, 1.7, 2.5", f.Position)
+	}
+}
@@ -0,0 +1,217 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/james4k/go-bgfx-examples/example"
+)
+
+// Controller moves a camera in response to input and time.
+type Controller interface {
+	Update(c *Camera, app *example.Application)
+}
+
+const maxPitch = math.Pi/2 - 0.01
+
+// smooth moves cur toward target, covering about 63% of the distance
+// every t seconds. A t of 0 jumps straight to target.
+func smooth(cur, target, t, dt float32) float32 {
+	if t <= 0 {
+		return target
+	}
+	k := 1 - float32(math.Exp(float64(-dt/t)))
+	return cur + (target-cur)*k
+}
+
+func clamp(v, min, max float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// direction returns the unit vector for yaw around the Y axis, where 0
+// looks along +Z, and pitch above the XZ plane.
+func direction(yaw, pitch float32) [3]float32 {
+	sy, cy := math.Sincos(float64(yaw))
+	sp, cp := math.Sincos(float64(pitch))
+	return [3]float32{
+		float32(sy * cp),
+		float32(sp),
+		float32(cy * cp),
+	}
+}
+
+// Orbit circles a target point. Dragging with the left mouse button or
+// the arrow keys rotate around the target, and scrolling zooms.
+type Orbit struct {
+	Target     [3]float32
+	Distance   float32
+	Yaw, Pitch float32 // radians; a yaw of 0 looks along +Z
+
+	AutoRotate  float32 // yaw in radians per second
+	MinDistance float32
+	MaxDistance float32
+	Sensitivity float32 // radians per pixel dragged
+	Smoothing   float32 // seconds
+
+	yaw, pitch, distance float32
+	started              bool
+}
+
+// NewOrbit returns an orbit controller looking at target from distance
+// units away along -Z.
+func NewOrbit(target [3]float32, distance float32) *Orbit {
+	return &Orbit{
+		Target:      target,
+		Distance:    distance,
+		MinDistance: 0.1,
+		MaxDistance: 100,
+		Sensitivity: 0.005,
+		Smoothing:   0.1,
+	}
+}
+
+func (o *Orbit) Update(c *Camera, app *example.Application) {
+	const keySpeed = 1.5 // radians per second
+	dt := app.DeltaTime
+	o.Yaw += o.AutoRotate * dt
+	if app.MouseDown(example.MouseLeft) {
+		o.Yaw += app.MouseDX * o.Sensitivity
+		o.Pitch -= app.MouseDY * o.Sensitivity
+	}
+	if app.KeyDown(example.KeyLeft) {
+		o.Yaw += keySpeed * dt
+	}
+	if app.KeyDown(example.KeyRight) {
+		o.Yaw -= keySpeed * dt
+	}
+	if app.KeyDown(example.KeyUp) {
+		o.Pitch -= keySpeed * dt
+	}
+	if app.KeyDown(example.KeyDown) {
+		o.Pitch += keySpeed * dt
+	}
+	o.Distance *= float32(math.Pow(0.9, float64(app.Scroll)))
+	o.Distance = clamp(o.Distance, o.MinDistance, o.MaxDistance)
+	o.Pitch = clamp(o.Pitch, -maxPitch, maxPitch)
+
+	if !o.started {
+		o.yaw, o.pitch, o.distance = o.Yaw, o.Pitch, o.Distance
+		o.started = true
+	}
+	o.yaw = smooth(o.yaw, o.Yaw, o.Smoothing, dt)
+	o.pitch = smooth(o.pitch, o.Pitch, o.Smoothing, dt)
+	o.distance = smooth(o.distance, o.Distance, o.Smoothing, dt)
+
+	dir := direction(o.yaw, o.pitch)
+	c.At = o.Target
+	c.Eye = sub(o.Target, scale(dir, o.distance))
+	c.Up = [3]float32{0, 1, 0}
+}
+
+// Fly moves freely in the direction it looks. Dragging with the right
+// mouse button looks around, W/A/S/D move, Q/E move down and up, and
+// shift moves faster.
+type Fly struct {
+	Position   [3]float32
+	Yaw, Pitch float32 // radians; a yaw of 0 looks along +Z
+
+	Speed       float32 // units per second
+	Sensitivity float32 // radians per pixel dragged
+	Smoothing   float32 // seconds
+
+	velocity   [3]float32
+	yaw, pitch float32
+	started    bool
+}
+
+// NewFly returns a fly controller at pos looking along +Z.
+func NewFly(pos [3]float32) *Fly {
+	return &Fly{
+		Position:    pos,
+		Speed:       5,
+		Sensitivity: 0.005,
+		Smoothing:   0.1,
+	}
+}
+
+func (f *Fly) Update(c *Camera, app *example.Application) {
+	f.update(c, app, false)
+}
+
+func (f *Fly) update(c *Camera, app *example.Application, walk bool) {
+	dt := app.DeltaTime
+	if app.MouseDown(example.MouseRight) {
+		f.Yaw += app.MouseDX * f.Sensitivity
+		f.Pitch -= app.MouseDY * f.Sensitivity
+	}
+	f.Pitch = clamp(f.Pitch, -maxPitch, maxPitch)
+	if !f.started {
+		f.yaw, f.pitch = f.Yaw, f.Pitch
+		f.started = true
+	}
+	f.yaw = smooth(f.yaw, f.Yaw, f.Smoothing, dt)
+	f.pitch = smooth(f.pitch, f.Pitch, f.Smoothing, dt)
+
+	var (
+		up      = [3]float32{0, 1, 0}
+		look    = direction(f.yaw, f.pitch)
+		forward = look
+		right   = normalize(cross(up, look))
+		move    [3]float32
+	)
+	if walk {
+		forward = direction(f.yaw, 0)
+	}
+	if app.KeyDown(example.KeyW) {
+		move = add(move, forward)
+	}
+	if app.KeyDown(example.KeyS) {
+		move = sub(move, forward)
+	}
+	if app.KeyDown(example.KeyD) {
+		move = add(move, right)
+	}
+	if app.KeyDown(example.KeyA) {
+		move = sub(move, right)
+	}
+	if !walk && app.KeyDown(example.KeyE) {
+		move = add(move, up)
+	}
+	if !walk && app.KeyDown(example.KeyQ) {
+		move = sub(move, up)
+	}
+	speed := f.Speed
+	if app.KeyDown(example.KeyLeftShift) {
+		speed *= 4
+	}
+	target := scale(normalize(move), speed)
+	for i := range f.velocity {
+		f.velocity[i] = smooth(f.velocity[i], target[i], f.Smoothing, dt)
+	}
+	f.Position = add(f.Position, scale(f.velocity, dt))
+
+	c.Eye = f.Position
+	c.At = add(f.Position, look)
+	c.Up = up
+}
+
+// FirstPerson is like Fly, except that it walks on the horizontal plane
+// at a fixed eye height instead of moving where it looks.
+type FirstPerson struct {
+	Fly
+}
+
+// NewFirstPerson returns a first person controller at pos looking
+// along +Z. The eye height stays at pos[1].
+func NewFirstPerson(pos [3]float32) *FirstPerson {
+	return &FirstPerson{Fly: *NewFly(pos)}
+}
+
+func (f *FirstPerson) Update(c *Camera, app *example.Application) {
+	f.update(c, app, true)
+}
@@ -7,12 +7,15 @@ examples.
 package example
 
 import (
+	"flag"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 
 	glfw "github.com/go-gl/glfw3"
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
 	"github.com/james4k/go-bgfx/window/bgfx_glfw"
 )
 
@@ -38,17 +41,45 @@ func init() {
 type Application struct {
 	window *glfw.Window
 
+	Options Options
+
+	// Input is where KeyDown and MouseDown read keys and buttons from.
+	// Open sets it to the window.
+	Input Input
+
 	Title         string
 	Width, Height int
 
 	Time      float32
 	DeltaTime float32
+
+	// Views hands out view IDs by name; see Run.
+	Views Views
+
+	// Cursor position in window coordinates, its movement since the
+	// last frame, and the vertical scroll during the last frame.
+	MouseX, MouseY   float32
+	MouseDX, MouseDY float32
+	Scroll           float32
+
+	frame    int
+	scroll   float32
+	pressed  map[Key]bool // during the last frame
+	pressing map[Key]bool // during this frame so far
 }
 
 // Open opens a new example app window, and must be called from the main
-// goroutine. May only be called once.
+// goroutine. May only be called once. Command line flags are parsed if
+// they have not been already; see Options.
 func Open() *Application {
-	app := &Application{}
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	opts, err := parseOptions()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	app := &Application{Options: opts}
 	app.init()
 	return app
 }
@@ -63,27 +94,84 @@ func (a *Application) init() {
 		os.Exit(1)
 	}
 
-	a.Width = 1280
-	a.Height = 720
+	a.Width = a.Options.Width
+	a.Height = a.Options.Height
 	a.Title = filepath.Base(os.Args[0])
+	if a.Options.Assets != "" {
+		assets.SetRoot(a.Options.Assets)
+	}
 
 	// For now, force a fixed size window. bgfx currently breaks glfw
 	// events on OS X because it overrides the NSWindow's content view.
 	glfw.WindowHint(glfw.Resizable, 0)
-	var err error
-	a.window, err = glfw.CreateWindow(a.Width, a.Height, a.Title, nil, nil)
+	if a.Options.Headless {
+		glfw.WindowHint(glfw.Visible, 0)
+	}
+	var (
+		monitor *glfw.Monitor
+		err     error
+	)
+	if a.Options.Fullscreen {
+		monitor, err = glfw.GetPrimaryMonitor()
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	a.window, err = glfw.CreateWindow(a.Width, a.Height, a.Title, monitor, nil)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	a.window.SetScrollCallback(a.scrollCallback)
+	a.window.SetKeyCallback(a.keyCallback)
+	a.Input = windowInput{a.window}
 	bgfx_glfw.SetWindow(a.window)
 }
 
+// InitBgfx initializes bgfx, resets the backbuffer and sets up debug
+// output and the view 0 clear according to a.Options. Call
+// bgfx.Shutdown when done.
+func (a *Application) InitBgfx() {
+	rt, _ := a.Options.RendererType() // validated by Open
+	if rt == bgfx.RendererTypeCount {
+		bgfx.Init()
+	} else {
+		bgfx.InitRenderer(rt)
+	}
+	bgfx.Reset(a.Width, a.Height, a.Options.ResetFlags())
+	bgfx.SetDebug(a.Options.DebugFlags())
+	bgfx.SetViewClear(
+		0,
+		bgfx.ClearColor|bgfx.ClearDepth,
+		a.Options.Clear,
+		1.0,
+		0,
+	)
+}
+
+// SetTitle sets the title of the application and its window.
+func (a *Application) SetTitle(title string) {
+	a.Title = title
+	a.window.SetTitle(title)
+}
+
 func (a *Application) Continue() bool {
 	glfw.PollEvents()
 	if a.window.ShouldClose() {
 		return false
 	}
+	if a.Options.Frames > 0 && a.frame >= a.Options.Frames {
+		if a.Options.Capture != "" {
+			// The screenshot is taken while the last frame renders;
+			// submit one more so it has been written before shutdown.
+			bgfx.Frame()
+		}
+		return false
+	}
 	a.update()
+	a.frame++
+	if a.Options.Frames > 0 && a.frame == a.Options.Frames && a.Options.Capture != "" {
+		bgfx.SaveScreenShot(a.Options.Capture)
+	}
 	return true
 }
 
@@ -99,6 +187,12 @@ func (a *Application) Close() error {
 
 func (a *Application) update() {
 	a.Width, a.Height = a.window.GetSize()
+	a.updateInput()
+	if a.Options.Step > 0 {
+		a.DeltaTime = float32(a.Options.Step)
+		a.Time += a.DeltaTime
+		return
+	}
 	now := float32(glfw.GetTime())
 	a.DeltaTime = now - a.Time
 	a.Time = now
@@ -0,0 +1,126 @@
+package example
+
+import glfw "github.com/go-gl/glfw3"
+
+// Key is a keyboard key.
+type Key glfw.Key
+
+const (
+	KeySpace        = Key(glfw.KeySpace)
+	KeyA            = Key(glfw.KeyA)
+	KeyD            = Key(glfw.KeyD)
+	KeyE            = Key(glfw.KeyE)
+	KeyQ            = Key(glfw.KeyQ)
+	KeyS            = Key(glfw.KeyS)
+	KeyW            = Key(glfw.KeyW)
+	KeyUp           = Key(glfw.KeyUp)
+	KeyDown         = Key(glfw.KeyDown)
+	KeyLeft         = Key(glfw.KeyLeft)
+	KeyRight        = Key(glfw.KeyRight)
+	KeyLeftShift    = Key(glfw.KeyLeftShift)
+	KeyLeftControl  = Key(glfw.KeyLeftControl)
+	KeyM            = Key(glfw.KeyM)
+	KeyB            = Key(glfw.KeyB)
+	KeyI            = Key(glfw.KeyI)
+	KeyJ            = Key(glfw.KeyJ)
+	KeyK            = Key(glfw.KeyK)
+	KeyL            = Key(glfw.KeyL)
+	KeyO            = Key(glfw.KeyO)
+	KeyU            = Key(glfw.KeyU)
+	KeyT            = Key(glfw.KeyT)
+	KeyV            = Key(glfw.KeyV)
+	KeyTab          = Key(glfw.KeyTab)
+	Key1            = Key(glfw.Key1)
+	Key2            = Key(glfw.Key2)
+	Key3            = Key(glfw.Key3)
+	Key4            = Key(glfw.Key4)
+	Key5            = Key(glfw.Key5)
+	KeyMinus        = Key(glfw.KeyMinus)
+	KeyEqual        = Key(glfw.KeyEqual)
+	KeyLeftBracket  = Key(glfw.KeyLeftBracket)
+	KeyRightBracket = Key(glfw.KeyRightBracket)
+)
+
+// MouseButton is a mouse button.
+type MouseButton glfw.MouseButton
+
+const (
+	MouseLeft   = MouseButton(glfw.MouseButtonLeft)
+	MouseRight  = MouseButton(glfw.MouseButtonRight)
+	MouseMiddle = MouseButton(glfw.MouseButtonMiddle)
+)
+
+// Input reports which keys and mouse buttons are held down. Controllers
+// read it through an Application, so tests can replace it with canned
+// input.
+type Input interface {
+	KeyDown(k Key) bool
+	MouseDown(b MouseButton) bool
+}
+
+type windowInput struct {
+	window *glfw.Window
+}
+
+func (in windowInput) KeyDown(k Key) bool {
+	return in.window.GetKey(glfw.Key(k)) != glfw.Release
+}
+
+func (in windowInput) MouseDown(b MouseButton) bool {
+	return in.window.GetMouseButton(glfw.MouseButton(b)) != glfw.Release
+}
+
+// KeyDown reports whether k is currently held down.
+func (a *Application) KeyDown(k Key) bool {
+	return a.Input.KeyDown(k)
+}
+
+// Axis returns 1 if up is held, -1 if down is, or 0 if neither or both
+// are, for settings that keys raise and lower over time.
+func (a *Application) Axis(up, down Key) float32 {
+	var v float32
+	if a.KeyDown(up) {
+		v++
+	}
+	if a.KeyDown(down) {
+		v--
+	}
+	return v
+}
+
+// KeyPressed reports whether k was pressed during the last frame. Unlike
+// KeyDown, it reports a held key only once.
+func (a *Application) KeyPressed(k Key) bool {
+	return a.pressed[k]
+}
+
+// MouseDown reports whether b is currently held down.
+func (a *Application) MouseDown(b MouseButton) bool {
+	return a.Input.MouseDown(b)
+}
+
+func (a *Application) scrollCallback(w *glfw.Window, xoff, yoff float64) {
+	a.scroll += float32(yoff)
+}
+
+func (a *Application) keyCallback(w *glfw.Window, k glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if action != glfw.Press {
+		return
+	}
+	if a.pressing == nil {
+		a.pressing = make(map[Key]bool)
+	}
+	a.pressing[Key(k)] = true
+}
+
+// updateInput updates the mouse and key state for a new frame.
+func (a *Application) updateInput() {
+	x, y := a.window.GetCursorPosition()
+	if a.frame > 0 {
+		a.MouseDX = float32(x) - a.MouseX
+		a.MouseDY = float32(y) - a.MouseY
+	}
+	a.MouseX, a.MouseY = float32(x), float32(y)
+	a.Scroll, a.scroll = a.scroll, 0
+	a.pressed, a.pressing = a.pressing, nil
+}
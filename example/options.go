@@ -0,0 +1,175 @@
+package example
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// Options configure an example's window and bgfx. Open sets them from
+// DefaultOptions, then the JSON file named by the -config flag, if any,
+// then any command line flags that were set explicitly.
+type Options struct {
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Fullscreen bool   `json:"fullscreen"`
+	VSync      bool   `json:"vsync"`
+	MSAA       int    `json:"msaa"`     // 0, 2, 4, 8 or 16 samples
+	Renderer   string `json:"renderer"` // see RendererType
+	Text       bool   `json:"text"`     // debug text overlay
+	Stats      bool   `json:"stats"`    // bgfx stats overlay
+	Views      bool   `json:"views"`    // table of views in the debug text
+	Clear      uint32 `json:"clear"`    // view 0 clear color, 0xRRGGBBAA
+	Assets     string `json:"assets"`   // asset root, replacing $GOPATH lookup
+
+	// Used to run examples unattended, such as by bgfx-golden.
+	Headless bool    `json:"headless"` // hide the window
+	Frames   int     `json:"frames"`   // exit after this many frames
+	Step     float64 `json:"step"`     // fixed time step in seconds
+	Capture  string  `json:"capture"`  // TGA screenshot of the last frame
+}
+
+// DefaultOptions are the options used for anything not set by a config
+// file or flag. Examples may change them before calling Open.
+var DefaultOptions = Options{
+	Width:  1280,
+	Height: 720,
+	VSync:  true,
+	Text:   true,
+	Clear:  0x303030ff,
+}
+
+var (
+	configPath string
+	cmdline    = DefaultOptions
+)
+
+func init() {
+	o := &cmdline
+	flag.StringVar(&configPath, "config", "", "JSON file of options; flags take precedence")
+	flag.IntVar(&o.Width, "width", o.Width, "window width")
+	flag.IntVar(&o.Height, "height", o.Height, "window height")
+	flag.BoolVar(&o.Fullscreen, "fullscreen", o.Fullscreen, "open a fullscreen window on the primary monitor")
+	flag.BoolVar(&o.VSync, "vsync", o.VSync, "wait for vertical sync")
+	flag.IntVar(&o.MSAA, "msaa", o.MSAA, "multisample anti-aliasing samples: 0, 2, 4, 8 or 16")
+	flag.StringVar(&o.Renderer, "renderer", o.Renderer, "bgfx renderer: null, d3d9, d3d11, gles or gl; empty picks the platform default")
+	flag.BoolVar(&o.Text, "text", o.Text, "show the debug text overlay")
+	flag.BoolVar(&o.Stats, "stats", o.Stats, "show the bgfx stats overlay")
+	flag.BoolVar(&o.Views, "views", o.Views, "list the views in use in the debug text")
+	flag.Var((*hexFlag)(&o.Clear), "clear", "clear color as 0xRRGGBBAA")
+	flag.StringVar(&o.Assets, "assets", o.Assets, "asset root directory")
+	flag.BoolVar(&o.Headless, "headless", o.Headless, "hide the window")
+	flag.IntVar(&o.Frames, "frames", o.Frames, "exit after this many frames; 0 runs until the window is closed")
+	flag.Float64Var(&o.Step, "step", o.Step, "advance time by a fixed step in seconds each frame instead of by the clock")
+	flag.StringVar(&o.Capture, "capture", o.Capture, "save a screenshot (TGA) of the last frame to this path; requires -frames")
+}
+
+type hexFlag uint32
+
+func (h *hexFlag) String() string { return fmt.Sprintf("0x%08x", uint32(*h)) }
+
+func (h *hexFlag) Set(s string) error {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return err
+	}
+	*h = hexFlag(v)
+	return nil
+}
+
+// parseOptions resolves the options from DefaultOptions, the config
+// file and the command line. flag.Parse must have been called.
+func parseOptions() (Options, error) {
+	opts := DefaultOptions
+	if configPath != "" {
+		f, err := os.Open(configPath)
+		if err != nil {
+			return opts, err
+		}
+		err = json.NewDecoder(f).Decode(&opts)
+		f.Close()
+		if err != nil {
+			return opts, fmt.Errorf("%s: %v", configPath, err)
+		}
+	}
+	var (
+		dst = reflect.ValueOf(&opts).Elem()
+		src = reflect.ValueOf(&cmdline).Elem()
+		typ = dst.Type()
+	)
+	flag.Visit(func(f *flag.Flag) {
+		for i := 0; i < typ.NumField(); i++ {
+			if typ.Field(i).Tag.Get("json") == f.Name {
+				dst.Field(i).Set(src.Field(i))
+			}
+		}
+	})
+	switch opts.MSAA {
+	case 0, 2, 4, 8, 16:
+	default:
+		return opts, fmt.Errorf("unsupported msaa level %d", opts.MSAA)
+	}
+	if _, err := opts.RendererType(); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// ResetFlags returns the flags to pass to bgfx.Reset.
+func (o Options) ResetFlags() bgfx.ResetFlags {
+	var flags bgfx.ResetFlags
+	if o.VSync {
+		flags |= bgfx.ResetVSync
+	}
+	if o.Fullscreen {
+		flags |= bgfx.ResetFullscreen
+	}
+	switch o.MSAA {
+	case 2:
+		flags |= bgfx.ResetMSAAX2
+	case 4:
+		flags |= bgfx.ResetMSAAX4
+	case 8:
+		flags |= bgfx.ResetMSAAX8
+	case 16:
+		flags |= bgfx.ResetMSAAX16
+	}
+	return flags
+}
+
+// DebugFlags returns the flags to pass to bgfx.SetDebug.
+func (o Options) DebugFlags() bgfx.DebugFlags {
+	var flags bgfx.DebugFlags
+	if o.Text {
+		flags |= bgfx.DebugText
+	}
+	if o.Stats {
+		flags |= bgfx.DebugStats
+	}
+	return flags
+}
+
+var rendererTypes = map[string]bgfx.RendererType{
+	"":      bgfx.RendererTypeCount,
+	"null":  bgfx.RendererTypeNull,
+	"d3d9":  bgfx.RendererTypeDirect3D9,
+	"d3d11": bgfx.RendererTypeDirect3D11,
+	"gles":  bgfx.RendererTypeOpenGLES,
+	"gl":    bgfx.RendererTypeOpenGL,
+}
+
+// RendererType returns the renderer named by o.Renderer, or
+// bgfx.RendererTypeCount to let bgfx choose.
+func (o Options) RendererType() (bgfx.RendererType, error) {
+	rt, ok := rendererTypes[strings.ToLower(o.Renderer)]
+	if !ok {
+		return rt, fmt.Errorf("unknown renderer %q", o.Renderer)
+	}
+	return rt, nil
+}
@@ -0,0 +1,18 @@
+package example
+
+import "testing"
+
+func TestHexFlag(t *testing.T) {
+	h := hexFlag(0x303030ff)
+	if err := h.Set("0x112233ff"); err != nil || h != 0x112233ff {
+		t.Fatalf("Set: %v, value %v", err, h.String())
+	}
+	for _, bad := range []string{"red", "0x1ffffffff", "-1"} {
+		if err := h.Set(bad); err == nil {
+			t.Errorf("no error for %q", bad)
+		}
+		if h != 0x112233ff {
+			t.Errorf("%q changed the value to %v", bad, h.String())
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package example
+
+import (
+	"sort"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+// RenderQueue collects draws instead of submitting them right away, and
+// submits them sorted when flushed. Within each view, opaque draws come
+// first, grouped by program and then front to back, followed by
+// transparent draws back to front. A draw is transparent if its state
+// has any blending enabled. Programs are grouped in the order they were
+// first queued since the last Reset.
+//
+// RenderQueue is a render.Renderer, so anything that draws through one,
+// such as assets.Mesh.SubmitTo, can draw into a queue. View setup and
+// instance data allocation calls are passed straight through. Uniform
+// values are read when the queue is flushed, so they must not change
+// before then.
+type RenderQueue struct {
+	Renderer render.Renderer // nil uses render.Default
+	Stats    QueueStats      // for the last Flush
+
+	items    []DrawItem
+	pending  DrawItem
+	uniforms []UniformValue // in effect for the next draw
+	views    map[bgfx.ViewID][16]float32
+
+	// programs orders programs by when they were first queued, since
+	// handles have no exported ordering of their own.
+	programs map[bgfx.Program]int
+}
+
+// QueueStats counts what a RenderQueue flush submitted.
+type QueueStats struct {
+	Items          int
+	Opaque         int
+	Transparent    int
+	ProgramChanges int
+	StateChanges   int
+}
+
+// DrawItem is everything needed for one draw.
+type DrawItem struct {
+	View        bgfx.ViewID
+	Program     bgfx.Program
+	VB          bgfx.VertexBuffer
+	IB          bgfx.IndexBuffer
+	Textures    []TextureBinding
+	Uniforms    []UniformValue
+	State       bgfx.State
+	Transform   [16]float32
+	Depth       float32 // view space distance, for sorting
+	Transparent bool
+
+	hasVB, hasIB bool
+	tvb          *transientVB
+	tib          *transientIB
+	idb          *bgfx.InstanceDataBuffer
+}
+
+// TextureBinding binds either a texture or a frame buffer's texture to
+// a sampler stage.
+type TextureBinding struct {
+	Stage       uint8
+	Sampler     bgfx.Uniform
+	Texture     bgfx.Texture
+	FrameBuffer bgfx.FrameBuffer
+	FromFB      bool
+}
+
+// UniformValue is a pointer to a uniform's value, as for
+// bgfx.SetUniform.
+type UniformValue struct {
+	Uniform bgfx.Uniform
+	Value   interface{}
+	Num     int
+}
+
+type transientVB struct {
+	tvb        bgfx.TransientVertexBuffer
+	start, num int
+}
+
+type transientIB struct {
+	tib        bgfx.TransientIndexBuffer
+	start, num int
+}
+
+// ViewDepth returns the view space depth of mtx's translation.
+func ViewDepth(view, mtx [16]float32) float32 {
+	return view[2]*mtx[12] + view[6]*mtx[13] + view[10]*mtx[14] + view[14]
+}
+
+// Add queues a draw of item's vertex and index buffers.
+func (q *RenderQueue) Add(item DrawItem) {
+	item.hasVB = true
+	item.hasIB = true
+	q.addProgram(item.Program)
+	q.items = append(q.items, item)
+}
+
+// Reset drops any queued draws and forgets the order of the programs
+// queued so far, for example after destroying programs.
+func (q *RenderQueue) Reset() {
+	q.items = q.items[:0]
+	q.pending = DrawItem{}
+	q.uniforms = q.uniforms[:0]
+	q.programs = nil
+}
+
+func (q *RenderQueue) addProgram(p bgfx.Program) {
+	if q.programs == nil {
+		q.programs = make(map[bgfx.Program]int)
+	}
+	if _, ok := q.programs[p]; !ok {
+		q.programs[p] = len(q.programs)
+	}
+}
+
+func (q *RenderQueue) renderer() render.Renderer {
+	if q.Renderer == nil {
+		return render.Default
+	}
+	return q.Renderer
+}
+
+// Flush submits the queued draws in sorted order and empties the queue.
+func (q *RenderQueue) Flush() {
+	items := q.items
+	sort.SliceStable(items, func(i, j int) bool {
+		return q.less(&items[i], &items[j])
+	})
+	var (
+		r           = q.renderer()
+		stats       QueueStats
+		lastProgram bgfx.Program
+		lastState   bgfx.State
+	)
+	for i := range items {
+		it := &items[i]
+		stats.Items++
+		if it.Transparent {
+			stats.Transparent++
+		} else {
+			stats.Opaque++
+		}
+		if i == 0 || it.Program != lastProgram {
+			stats.ProgramChanges++
+			lastProgram = it.Program
+		}
+		if i == 0 || it.State != lastState {
+			stats.StateChanges++
+			lastState = it.State
+		}
+		for _, u := range it.Uniforms {
+			r.SetUniform(u.Uniform, u.Value, u.Num)
+		}
+		r.SetTransform(it.Transform)
+		r.SetProgram(it.Program)
+		if it.hasVB {
+			r.SetVertexBuffer(it.VB)
+		}
+		if it.hasIB {
+			r.SetIndexBuffer(it.IB)
+		}
+		if it.tvb != nil {
+			r.SetTransientVertexBuffer(it.tvb.tvb, it.tvb.start, it.tvb.num)
+		}
+		if it.tib != nil {
+			r.SetTransientIndexBuffer(it.tib.tib, it.tib.start, it.tib.num)
+		}
+		if it.idb != nil {
+			r.SetInstanceDataBuffer(*it.idb)
+		}
+		for _, t := range it.Textures {
+			if t.FromFB {
+				r.SetTextureFromFrameBuffer(t.Stage, t.Sampler, t.FrameBuffer)
+			} else {
+				r.SetTexture(t.Stage, t.Sampler, t.Texture)
+			}
+		}
+		r.SetState(it.State)
+		r.Submit(it.View)
+	}
+	q.Stats = stats
+	q.items = items[:0]
+	q.pending = DrawItem{}
+	q.uniforms = q.uniforms[:0]
+}
+
+func (q *RenderQueue) less(a, b *DrawItem) bool {
+	if a.View != b.View {
+		return a.View < b.View
+	}
+	if a.Transparent != b.Transparent {
+		return !a.Transparent
+	}
+	if a.Transparent {
+		return a.Depth > b.Depth
+	}
+	if a.Program != b.Program {
+		return q.programs[a.Program] < q.programs[b.Program]
+	}
+	return a.Depth < b.Depth
+}
+
+func (q *RenderQueue) SetViewClear(view bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32, depth float32, stencil uint8) {
+	q.renderer().SetViewClear(view, flags, rgba, depth, stencil)
+}
+
+func (q *RenderQueue) SetViewRect(view bgfx.ViewID, x, y, w, h int) {
+	q.renderer().SetViewRect(view, x, y, w, h)
+}
+
+func (q *RenderQueue) SetViewTransform(view bgfx.ViewID, mtxView, mtxProj [16]float32) {
+	if q.views == nil {
+		q.views = make(map[bgfx.ViewID][16]float32)
+	}
+	q.views[view] = mtxView
+	q.renderer().SetViewTransform(view, mtxView, mtxProj)
+}
+
+func (q *RenderQueue) SetViewFrameBuffer(view bgfx.ViewID, fb bgfx.FrameBuffer) {
+	q.renderer().SetViewFrameBuffer(view, fb)
+}
+
+func (q *RenderQueue) SetTransform(mtx [16]float32) { q.pending.Transform = mtx }
+
+func (q *RenderQueue) SetProgram(prog bgfx.Program) { q.pending.Program = prog }
+
+func (q *RenderQueue) SetVertexBuffer(vb bgfx.VertexBuffer) {
+	q.pending.VB = vb
+	q.pending.hasVB = true
+}
+
+func (q *RenderQueue) SetIndexBuffer(ib bgfx.IndexBuffer) {
+	q.pending.IB = ib
+	q.pending.hasIB = true
+}
+
+func (q *RenderQueue) SetTransientVertexBuffer(tvb bgfx.TransientVertexBuffer, start, num int) {
+	q.pending.tvb = &transientVB{tvb, start, num}
+}
+
+func (q *RenderQueue) SetTransientIndexBuffer(tib bgfx.TransientIndexBuffer, start, num int) {
+	q.pending.tib = &transientIB{tib, start, num}
+}
+
+func (q *RenderQueue) SetInstanceDataBuffer(idb bgfx.InstanceDataBuffer) {
+	q.pending.idb = &idb
+}
+
+func (q *RenderQueue) CheckAvailInstanceDataBuffer(num, stride int) bool {
+	return q.renderer().CheckAvailInstanceDataBuffer(num, stride)
+}
+
+func (q *RenderQueue) AllocInstanceDataBuffer(num, stride int) bgfx.InstanceDataBuffer {
+	return q.renderer().AllocInstanceDataBuffer(num, stride)
+}
+
+func (q *RenderQueue) SetState(state bgfx.State) {
+	q.pending.State = state
+	q.pending.Transparent = state&bgfx.StateBlendMask != 0
+}
+
+func (q *RenderQueue) SetUniform(u bgfx.Uniform, ptr interface{}, num int) {
+	v := UniformValue{u, ptr, num}
+	for i := range q.uniforms {
+		if q.uniforms[i].Uniform == u {
+			q.uniforms[i] = v
+			return
+		}
+	}
+	q.uniforms = append(q.uniforms, v)
+}
+
+func (q *RenderQueue) SetTexture(stage uint8, u bgfx.Uniform, tex bgfx.Texture) {
+	q.pending.Textures = append(q.pending.Textures, TextureBinding{
+		Stage:   stage,
+		Sampler: u,
+		Texture: tex,
+	})
+}
+
+func (q *RenderQueue) SetTextureFromFrameBuffer(stage uint8, u bgfx.Uniform, fb bgfx.FrameBuffer) {
+	q.pending.Textures = append(q.pending.Textures, TextureBinding{
+		Stage:       stage,
+		Sampler:     u,
+		FrameBuffer: fb,
+		FromFB:      true,
+	})
+}
+
+// Submit queues the draw set up since the last Submit.
+func (q *RenderQueue) Submit(view bgfx.ViewID) {
+	it := q.pending
+	it.View = view
+	if v, ok := q.views[view]; ok {
+		it.Depth = ViewDepth(v, it.Transform)
+	}
+	// Like bgfx, uniforms stay set for later draws.
+	it.Uniforms = append([]UniformValue(nil), q.uniforms...)
+	q.addProgram(it.Program)
+	q.items = append(q.items, it)
+	q.pending = DrawItem{}
+}
@@ -0,0 +1,145 @@
+package example
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+	"j4k.co/cgm/mat4"
+)
+
+// program returns a distinct program handle without a renderer to
+// create one. Handles are a 16 bit index.
+func program(t *testing.T, i uint16) bgfx.Program {
+	var p bgfx.Program
+	if unsafe.Sizeof(p) != unsafe.Sizeof(i) {
+		t.Skip("program handles are not 16 bit indices")
+	}
+	*(*uint16)(unsafe.Pointer(&p)) = i
+	return p
+}
+
+// draw queues a draw through q as a renderer, at depth z.
+func draw(q *RenderQueue, view bgfx.ViewID, prog bgfx.Program, state bgfx.State, z float32) {
+	mtx := mat4.Identity()
+	mtx[14] = z
+	q.SetTransform(mtx)
+	q.SetProgram(prog)
+	q.SetState(state)
+	q.Submit(view)
+}
+
+func TestQueueOrder(t *testing.T) {
+	var (
+		r       render.Recorder
+		q       = RenderQueue{Renderer: &r}
+		a, b    = program(t, 1), program(t, 2)
+		opaque  = bgfx.StateDefault
+		blended = bgfx.StateDefault | bgfx.StateBlendAlpha()
+	)
+	q.SetViewTransform(0, mat4.Identity(), mat4.Identity())
+	q.SetViewTransform(1, mat4.Identity(), mat4.Identity())
+	draw(&q, 1, a, opaque, 5)
+	draw(&q, 0, b, opaque, 3)
+	draw(&q, 0, a, opaque, 9)
+	draw(&q, 0, b, opaque, 1)
+	draw(&q, 0, a, blended, 2)
+	draw(&q, 0, a, blended, 8)
+	draw(&q, 0, a, opaque, 4)
+	if len(r.Draws) != 0 {
+		t.Fatalf("%d draws submitted before Flush", len(r.Draws))
+	}
+	q.Flush()
+
+	// By view; opaque by program in the order they were first queued,
+	// then front to back; then transparent back to front.
+	want := []struct {
+		view bgfx.ViewID
+		prog bgfx.Program
+		z    float32
+	}{
+		{0, a, 4}, {0, a, 9}, {0, b, 1}, {0, b, 3},
+		{0, a, 8}, {0, a, 2},
+		{1, a, 5},
+	}
+	if len(r.Draws) != len(want) {
+		t.Fatalf("%d draws, want %d", len(r.Draws), len(want))
+	}
+	for i, w := range want {
+		d := r.Draws[i]
+		if d.View != w.view || d.Program != w.prog || d.Transform[14] != w.z {
+			t.Errorf("draw %d: view %d, depth %v; want view %d, depth %v",
+				i, d.View, d.Transform[14], w.view, w.z)
+		}
+	}
+	wantStats := QueueStats{Items: 7, Opaque: 5, Transparent: 2, ProgramChanges: 3, StateChanges: 3}
+	if q.Stats != wantStats {
+		t.Errorf("stats %+v, want %+v", q.Stats, wantStats)
+	}
+
+	// The queue is empty after a flush.
+	r.Reset()
+	q.Flush()
+	if len(r.Draws) != 0 || q.Stats != (QueueStats{}) {
+		t.Errorf("second flush: %d draws, stats %+v", len(r.Draws), q.Stats)
+	}
+}
+
+func TestQueueProgramOrder(t *testing.T) {
+	a, b := program(t, 1), program(t, 2)
+	// Each queue orders programs by when it first saw them.
+	order := func(q *RenderQueue, first, second bgfx.Program) []bgfx.Program {
+		var r render.Recorder
+		q.Renderer = &r
+		draw(q, 0, first, 0, 0)
+		draw(q, 0, second, 0, 0)
+		draw(q, 0, first, 0, 0)
+		q.Flush()
+		var progs []bgfx.Program
+		for _, d := range r.Draws {
+			progs = append(progs, d.Program)
+		}
+		return progs
+	}
+	var q1, q2 RenderQueue
+	if got := order(&q1, a, b); got[0] != a || got[1] != a || got[2] != b {
+		t.Errorf("first queue: %v", got)
+	}
+	if got := order(&q2, b, a); got[0] != b || got[1] != b || got[2] != a {
+		t.Errorf("second queue: %v", got)
+	}
+	// The order is kept across flushes, and forgotten by Reset.
+	if got := order(&q1, b, a); got[0] != a {
+		t.Errorf("after a flush: %v", got)
+	}
+	q1.Reset()
+	if len(q1.programs) != 0 {
+		t.Errorf("%d programs after Reset", len(q1.programs))
+	}
+	if got := order(&q1, b, a); got[0] != b {
+		t.Errorf("after Reset: %v", got)
+	}
+}
+
+func TestQueueUniforms(t *testing.T) {
+	var (
+		r render.Recorder
+		q = RenderQueue{Renderer: &r}
+		u bgfx.Uniform
+		v = [4]float32{1, 2, 3, 4}
+	)
+	// Like bgfx, a uniform stays set for later draws.
+	q.SetUniform(u, &v, 1)
+	draw(&q, 0, bgfx.Program{}, 0, 0)
+	draw(&q, 0, bgfx.Program{}, 0, 0)
+	q.Flush()
+	for i, d := range r.Draws {
+		if got, ok := d.Uniforms[u].(*[4]float32); !ok || *got != v {
+			t.Errorf("draw %d: uniform %v", i, d.Uniforms[u])
+		}
+	}
+	if n := len(r.CallsNamed("SetUniform")); n != 2 {
+		t.Errorf("%d SetUniform calls, want 2", n)
+	}
+}
@@ -0,0 +1,84 @@
+package example
+
+import "github.com/james4k/go-bgfx"
+
+// Example describes an example program for Run.
+type Example struct {
+	Title       string // defaults to the program name
+	Description string
+
+	// Setup is called once bgfx has been initialized, to create the
+	// example's resources. It returns the function to call every frame,
+	// and a function to release the resources before bgfx is shut
+	// down. Either may be nil.
+	Setup func(app *Application) (frame, cleanup func())
+}
+
+// Run opens the application, initializes bgfx and runs ex until the
+// window is closed. Each frame, the views that are not persistent are
+// freed, the persistent "main" view, which is always view 0, is set to
+// cover the window, and the standard header of title, description and
+// frame time is drawn before ex's frame function is called, and
+// bgfx.Frame after.
+func Run(ex Example) {
+	app := Open()
+	defer app.Close()
+	if ex.Title != "" {
+		app.SetTitle(ex.Title)
+	}
+	app.InitBgfx()
+	defer bgfx.Shutdown()
+
+	var frame, cleanup func()
+	if ex.Setup != nil {
+		frame, cleanup = ex.Setup(app)
+	}
+	if cleanup != nil {
+		defer cleanup()
+	}
+	var (
+		width  = app.Width
+		height = app.Height
+		main   = app.Views.Get("main")
+	)
+	main.Persistent = true
+	main.SetClear(bgfx.ClearColor|bgfx.ClearDepth, app.Options.Clear)
+	for app.Continue() {
+		if width != app.Width || height != app.Height {
+			width, height = app.Width, app.Height
+			bgfx.Reset(width, height, app.Options.ResetFlags())
+		}
+		app.Views.Reset()
+		main.SetRect(0, 0, app.Width, app.Height)
+		// Submit an empty draw so view 0 is cleared even if nothing
+		// else is drawn to it.
+		bgfx.Submit(main.ID)
+		app.DrawHeader(ex.Description)
+		if frame != nil {
+			frame()
+		}
+		if app.Options.Views {
+			app.DrawViews()
+		}
+		bgfx.Frame()
+	}
+}
+
+// DrawViews prints the table of views in use at the bottom of the
+// debug text.
+func (a *Application) DrawViews() {
+	y := a.Height/16 - a.Views.Len() - 2
+	if y < 10 {
+		y = 10
+	}
+	a.Views.DrawTable(y)
+}
+
+// DrawHeader clears the debug text and prints the title, description
+// and last frame time on the first three lines.
+func (a *Application) DrawHeader(description string) {
+	bgfx.DebugTextClear()
+	bgfx.DebugTextPrintf(0, 1, 0x4f, a.Title)
+	bgfx.DebugTextPrintf(0, 2, 0x6f, "Description: %s", description)
+	bgfx.DebugTextPrintf(0, 3, 0x0f, "Frame: % 7.3f[ms]", a.DeltaTime*1000.0)
+}
@@ -0,0 +1,158 @@
+package example
+
+import "github.com/james4k/go-bgfx"
+
+// ScreenVertex is the vertex format of screen space geometry.
+type ScreenVertex struct {
+	X, Y, Z float32
+	ABGR    uint32
+	U, V    float32
+}
+
+// Screen draws screen space geometry for a renderer, offsetting texture
+// coordinates by half a texel where the renderer samples texel corners
+// (Direct3D 9), and flipping them where textures have their origin at
+// the bottom left (OpenGL).
+type Screen struct {
+	TexelHalf        float32
+	OriginBottomLeft bool
+	Decl             bgfx.VertexDecl
+}
+
+// NewScreen returns a Screen for the current renderer. bgfx must be
+// initialized.
+func NewScreen() Screen {
+	s := ScreenFor(bgfx.Caps().RendererType)
+	s.Decl.Begin()
+	s.Decl.Add(bgfx.AttribPosition, 3, bgfx.AttribTypeFloat, false, false)
+	s.Decl.Add(bgfx.AttribColor0, 4, bgfx.AttribTypeUint8, true, false)
+	s.Decl.Add(bgfx.AttribTexcoord0, 2, bgfx.AttribTypeFloat, false, false)
+	s.Decl.End()
+	return s
+}
+
+// ScreenFor returns the texel offset and texture origin of a renderer,
+// without a vertex declaration.
+func ScreenFor(rt bgfx.RendererType) Screen {
+	var s Screen
+	switch rt {
+	case bgfx.RendererTypeDirect3D9:
+		s.TexelHalf = 0.5
+	case bgfx.RendererTypeOpenGL, bgfx.RendererTypeOpenGLES:
+		s.OriginBottomLeft = true
+	}
+	return s
+}
+
+// TriangleVertices returns a single triangle that covers the unit
+// square, as projected by mat4.OrthoLH(0, 1, 1, 0, 0, 100), with texture
+// coordinates mapping a texture of the given size onto it.
+func (s Screen) TriangleVertices(textureWidth, textureHeight float32) [3]ScreenVertex {
+	const (
+		z    = 0
+		minx = -1.0
+		maxx = 1.0
+		miny = 0
+		maxy = 1.0 * 2
+	)
+	var (
+		texelHalfW = s.TexelHalf / textureWidth
+		texelHalfH = s.TexelHalf / textureHeight
+
+		minu = -1.0 + texelHalfW
+		maxu = 1.0 + texelHalfW
+		minv = texelHalfH
+		maxv = 2.0 + texelHalfH
+	)
+	if s.OriginBottomLeft {
+		minv, maxv = maxv-1, minv-1
+	}
+	return [3]ScreenVertex{
+		{minx, miny, z, 0xffffffff, minu, minv},
+		{maxx, miny, z, 0xffffffff, maxu, minv},
+		{maxx, maxy, z, 0xffffffff, maxu, maxv},
+	}
+}
+
+// SetTriangle sets a transient vertex buffer of TriangleVertices for the
+// next draw. It reports false if there was no transient buffer space.
+func (s Screen) SetTriangle(textureWidth, textureHeight float32) bool {
+	if !bgfx.CheckAvailTransientVertexBuffer(3, s.Decl) {
+		return false
+	}
+	var vertices []ScreenVertex
+	vb := bgfx.AllocTransientVertexBuffer(&vertices, 3, s.Decl)
+	v := s.TriangleVertices(textureWidth, textureHeight)
+	copy(vertices, v[:])
+	bgfx.SetTransientVertexBuffer(vb, 0, 3)
+	return true
+}
+
+// Rect is a screen space rectangle in view units.
+type Rect struct {
+	X, Y, Width, Height float32
+
+	// UV are the texture coordinates at (X, Y) and the opposite
+	// corner: min u, min v, max u, max v.
+	UV [4]float32
+
+	// Colors are the vertex colors at the corners, counterclockwise
+	// from (X, Y).
+	Colors [4]uint32
+
+	// If TextureWidth and TextureHeight are set, UV are texture
+	// coordinates into a texture of that size and are adjusted for the
+	// renderer like those of TriangleVertices. Otherwise they are
+	// passed through unchanged.
+	TextureWidth, TextureHeight float32
+}
+
+// RectIndices are the indices of the two triangles of RectVertices.
+var RectIndices = [6]uint16{0, 2, 1, 0, 3, 2}
+
+// RectVertices returns the corners of r, counterclockwise from (X, Y).
+func (s Screen) RectVertices(r Rect) [4]ScreenVertex {
+	const z = 0
+	var (
+		minx = r.X
+		maxx = r.X + r.Width
+		miny = r.Y
+		maxy = r.Y + r.Height
+
+		minu, minv, maxu, maxv = r.UV[0], r.UV[1], r.UV[2], r.UV[3]
+	)
+	if r.TextureWidth != 0 && r.TextureHeight != 0 {
+		du := s.TexelHalf / r.TextureWidth
+		dv := s.TexelHalf / r.TextureHeight
+		minu, maxu = minu+du, maxu+du
+		minv, maxv = minv+dv, maxv+dv
+		if s.OriginBottomLeft {
+			minv, maxv = 1-minv, 1-maxv
+		}
+	}
+	return [4]ScreenVertex{
+		{minx, miny, z, r.Colors[0], minu, minv},
+		{maxx, miny, z, r.Colors[1], maxu, minv},
+		{maxx, maxy, z, r.Colors[2], maxu, maxv},
+		{minx, maxy, z, r.Colors[3], minu, maxv},
+	}
+}
+
+// SetRect sets transient vertex and index buffers of r for the next
+// draw. It reports false if there was no transient buffer space.
+func (s Screen) SetRect(r Rect) bool {
+	var (
+		vertices []ScreenVertex
+		indices  []uint16
+	)
+	tvb, tib, ok := bgfx.AllocTransientBuffers(&vertices, &indices, s.Decl, 4, 6)
+	if !ok {
+		return false
+	}
+	v := s.RectVertices(r)
+	copy(vertices, v[:])
+	copy(indices, RectIndices[:])
+	bgfx.SetTransientVertexBuffer(tvb, 0, 4)
+	bgfx.SetTransientIndexBuffer(tib, 0, 6)
+	return true
+}
@@ -0,0 +1,75 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+)
+
+func TestScreenFor(t *testing.T) {
+	tests := []struct {
+		rt        bgfx.RendererType
+		texelHalf float32
+		flip      bool
+	}{
+		{bgfx.RendererTypeDirect3D9, 0.5, false},
+		{bgfx.RendererTypeDirect3D11, 0, false},
+		{bgfx.RendererTypeOpenGL, 0, true},
+		{bgfx.RendererTypeOpenGLES, 0, true},
+	}
+	for _, tt := range tests {
+		s := ScreenFor(tt.rt)
+		if s.TexelHalf != tt.texelHalf || s.OriginBottomLeft != tt.flip {
+			t.Errorf("renderer %d: texel half %v, bottom left %v; want %v, %v",
+				tt.rt, s.TexelHalf, s.OriginBottomLeft, tt.texelHalf, tt.flip)
+		}
+	}
+}
+
+func TestTriangleVertices(t *testing.T) {
+	// Direct3D 9 offsets by half a texel of the 256x128 texture.
+	v := ScreenFor(bgfx.RendererTypeDirect3D9).TriangleVertices(256, 128)
+	if v[0].U != -1+0.5/256 || v[0].V != 0.5/128 || v[2].U != 1+0.5/256 || v[2].V != 2+0.5/128 {
+		t.Errorf("Direct3D 9: uv %v,%v to %v,%v", v[0].U, v[0].V, v[2].U, v[2].V)
+	}
+
+	// OpenGL flips v, so the bottom of the screen samples the top of
+	// the texture.
+	v = ScreenFor(bgfx.RendererTypeOpenGL).TriangleVertices(256, 128)
+	if v[0].U != -1 || v[0].V != 1 || v[2].U != 1 || v[2].V != -1 {
+		t.Errorf("OpenGL: uv %v,%v to %v,%v", v[0].U, v[0].V, v[2].U, v[2].V)
+	}
+	if v[0].X != -1 || v[0].Y != 0 || v[1].X != 1 || v[2].Y != 2 {
+		t.Errorf("positions %v", v)
+	}
+}
+
+func TestRectVertices(t *testing.T) {
+	r := Rect{
+		X: 10, Y: 20, Width: 30, Height: 40,
+		UV:     [4]float32{0, 0, 1, 1},
+		Colors: [4]uint32{1, 2, 3, 4},
+	}
+	want := [4]ScreenVertex{
+		{10, 20, 0, 1, 0, 0},
+		{40, 20, 0, 2, 1, 0},
+		{40, 60, 0, 3, 1, 1},
+		{10, 60, 0, 4, 0, 1},
+	}
+	// Without a texture size, UV are not adjusted for the renderer.
+	for _, rt := range []bgfx.RendererType{bgfx.RendererTypeDirect3D9, bgfx.RendererTypeOpenGL} {
+		if got := ScreenFor(rt).RectVertices(r); got != want {
+			t.Errorf("renderer %d:\ngot  %v\nwant %v", rt, got, want)
+		}
+	}
+
+	r.TextureWidth, r.TextureHeight = 4, 2
+	got := ScreenFor(bgfx.RendererTypeDirect3D9).RectVertices(r)
+	if got[0].U != 0.125 || got[0].V != 0.25 || got[2].U != 1.125 || got[2].V != 1.25 {
+		t.Errorf("Direct3D 9: %v", got)
+	}
+	got = ScreenFor(bgfx.RendererTypeOpenGL).RectVertices(r)
+	if got[0].U != 0 || got[0].V != 1 || got[2].U != 1 || got[2].V != 0 {
+		t.Errorf("OpenGL: %v", got)
+	}
+}
@@ -0,0 +1,191 @@
+package example
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// MaxViews is the number of views bgfx supports.
+const MaxViews = 32
+
+// InvalidFrameBuffer is bgfx's invalid frame buffer handle. A view set
+// to draw to it draws to the backbuffer.
+var InvalidFrameBuffer = invalidFrameBuffer()
+
+// invalidFrameBuffer makes the handle by hand, since go-bgfx does not
+// export one. Handles are 16 bit indices, and the invalid one is all
+// ones.
+func invalidFrameBuffer() bgfx.FrameBuffer {
+	var fb bgfx.FrameBuffer
+	if unsafe.Sizeof(fb) != 2 {
+		panic("example: frame buffer handles are not 16 bit indices")
+	}
+	*(*uint16)(unsafe.Pointer(&fb)) = 0xffff
+	return fb
+}
+
+// Views hands out view IDs by name, so that separate parts of an example
+// don't have to agree on numbers, and keeps track of how each view is
+// set up for the debug overlay.
+//
+// Views are freed by Reset, which Run calls at the start of every frame,
+// unless they are marked Persistent. Asking for the same names in the
+// same order every frame gives the same IDs.
+type Views struct {
+	byID   [MaxViews]*View
+	byName map[string]*View
+}
+
+// View is the state of one view, as set through its methods or those of
+// Views.
+type View struct {
+	ID         bgfx.ViewID
+	Name       string
+	Persistent bool // kept by Reset
+
+	X, Y, Width, Height int
+	FrameBuffer         bgfx.FrameBuffer
+	HasFrameBuffer      bool // false for the backbuffer
+	Clear               bgfx.ClearFlags
+	ClearColor          uint32
+	Mtx, Proj           [16]float32
+}
+
+// Get returns the view named name, allocating the lowest free ID for it
+// if it doesn't have one yet. It panics if all views are in use.
+func (vs *Views) Get(name string) *View {
+	if v, ok := vs.byName[name]; ok {
+		return v
+	}
+	if vs.byName == nil {
+		vs.byName = make(map[string]*View)
+	}
+	for id, v := range vs.byID {
+		if v != nil {
+			continue
+		}
+		v = &View{ID: bgfx.ViewID(id), Name: name}
+		vs.byID[id] = v
+		vs.byName[name] = v
+		return v
+	}
+	panic(fmt.Sprintf("example: no view left for %s", name))
+}
+
+// View returns the ID of the view named name, as Get.
+func (vs *Views) View(name string) bgfx.ViewID {
+	return vs.Get(name).ID
+}
+
+// Reset frees every view that is not persistent. bgfx keeps a freed
+// view's setup, such as its frame buffer, so whoever gets its ID next
+// should set the view up fully.
+func (vs *Views) Reset() {
+	for id, v := range vs.byID {
+		if v != nil && !v.Persistent {
+			vs.byID[id] = nil
+			if vs.byName[v.Name] == v {
+				delete(vs.byName, v.Name)
+			}
+		}
+	}
+}
+
+// SetRect sets the view's rectangle.
+func (v *View) SetRect(x, y, w, h int) {
+	v.X, v.Y, v.Width, v.Height = x, y, w, h
+	bgfx.SetViewRect(v.ID, x, y, w, h)
+}
+
+// SetFrameBuffer makes the view draw to fb, or to the backbuffer if fb
+// is InvalidFrameBuffer.
+func (v *View) SetFrameBuffer(fb bgfx.FrameBuffer) {
+	v.FrameBuffer = fb
+	v.HasFrameBuffer = fb != InvalidFrameBuffer
+	bgfx.SetViewFrameBuffer(v.ID, fb)
+}
+
+// SetClear sets how the view is cleared, with a depth of 1 and a stencil
+// of 0.
+func (v *View) SetClear(flags bgfx.ClearFlags, rgba uint32) {
+	v.Clear, v.ClearColor = flags, rgba
+	bgfx.SetViewClear(v.ID, flags, rgba, 1, 0)
+}
+
+// SetTransform sets the view and projection matrices.
+func (v *View) SetTransform(mtx, proj [16]float32) {
+	v.Mtx, v.Proj = mtx, proj
+	bgfx.SetViewTransform(v.ID, mtx, proj)
+}
+
+// The following set up views by ID, for code that is handed IDs.
+
+func (vs *Views) SetViewRect(id bgfx.ViewID, x, y, w, h int) {
+	vs.byIDOrNew(id).SetRect(x, y, w, h)
+}
+
+func (vs *Views) SetViewFrameBuffer(id bgfx.ViewID, fb bgfx.FrameBuffer) {
+	vs.byIDOrNew(id).SetFrameBuffer(fb)
+}
+
+func (vs *Views) SetViewClear(id bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32) {
+	vs.byIDOrNew(id).SetClear(flags, rgba)
+}
+
+func (vs *Views) SetViewTransform(id bgfx.ViewID, mtx, proj [16]float32) {
+	vs.byIDOrNew(id).SetTransform(mtx, proj)
+}
+
+// byIDOrNew returns the view with id, taking it without a name if it
+// was not handed out, so that it can't be found by Get. It panics if id
+// is not a valid view.
+func (vs *Views) byIDOrNew(id bgfx.ViewID) *View {
+	if int(id) >= MaxViews {
+		panic(fmt.Sprintf("example: view %d is out of range", id))
+	}
+	if v := vs.byID[id]; v != nil {
+		return v
+	}
+	v := &View{ID: id}
+	vs.byID[id] = v
+	return v
+}
+
+// DrawTable prints a table of the views in use to the debug text,
+// starting at row y.
+func (vs *Views) DrawTable(y int) {
+	bgfx.DebugTextPrintf(0, y, 0x0f, " ID  %-24s %-19s %-10s %s", "Name", "Rect", "Target", "Clear")
+	for _, v := range vs.byID {
+		if v == nil {
+			continue
+		}
+		y++
+		name := v.Name
+		if name == "" {
+			name = "-" // set up by ID only
+		}
+		target := "backbuffer"
+		if v.HasFrameBuffer {
+			target = "frame buf"
+		}
+		clear := "-"
+		if v.Clear != 0 {
+			clear = fmt.Sprintf("0x%08x", v.ClearColor)
+		}
+		bgfx.DebugTextPrintf(0, y, 0x0f, " %2d  %-24.24s %4d,%-4d %4dx%-4d %-10s %s",
+			v.ID, name, v.X, v.Y, v.Width, v.Height, target, clear)
+	}
+}
+
+// Len returns the number of views in use.
+func (vs *Views) Len() int {
+	n := 0
+	for _, v := range vs.byID {
+		if v != nil {
+			n++
+		}
+	}
+	return n
+}
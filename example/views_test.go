@@ -0,0 +1,113 @@
+package example
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestViewsLowestFree(t *testing.T) {
+	var vs Views
+	for i, name := range []string{"a", "b", "c"} {
+		if id := vs.View(name); int(id) != i {
+			t.Errorf("%s: view %d, want %d", name, id, i)
+		}
+	}
+	if id := vs.View("b"); id != 1 {
+		t.Errorf("b again: view %d, want 1", id)
+	}
+	vs.Get("b").Persistent = true
+	vs.Reset()
+	// 0 and 2 are free again, and b keeps 1.
+	for _, tt := range []struct {
+		name string
+		id   int
+	}{{"d", 0}, {"b", 1}, {"e", 2}, {"f", 3}} {
+		if id := vs.View(tt.name); int(id) != tt.id {
+			t.Errorf("after Reset, %s: view %d, want %d", tt.name, id, tt.id)
+		}
+	}
+}
+
+func TestViewsStableAcrossReset(t *testing.T) {
+	var vs Views
+	frame := func() []int {
+		vs.Reset()
+		var ids []int
+		for _, name := range []string{"shadow", "scene", "bloom", "ui"} {
+			ids = append(ids, int(vs.View(name)))
+		}
+		return ids
+	}
+	first := fmt.Sprint(frame())
+	for i := 0; i < 3; i++ {
+		if got := fmt.Sprint(frame()); got != first {
+			t.Errorf("frame %d: views %s, want %s", i+1, got, first)
+		}
+	}
+	if vs.Len() != 4 {
+		t.Errorf("%d views in use, want 4", vs.Len())
+	}
+}
+
+func TestViewsPersistent(t *testing.T) {
+	var vs Views
+	v := vs.Get("capture")
+	v.Persistent = true
+	v.SetRect(0, 0, 64, 64)
+	vs.View("scene")
+	vs.Reset()
+	if vs.Len() != 1 {
+		t.Errorf("%d views after Reset, want 1", vs.Len())
+	}
+	if got := vs.Get("capture"); got != v || got.Width != 64 {
+		t.Errorf("persistent view not kept: %+v", got)
+	}
+	v.Persistent = false
+	vs.Reset()
+	if vs.Len() != 0 {
+		t.Errorf("%d views after Reset, want 0", vs.Len())
+	}
+}
+
+func TestViewsByID(t *testing.T) {
+	var vs Views
+	// A view set up by ID that was not handed out gets no name, so a
+	// view asked for by name can't collide with it.
+	vs.SetViewRect(0, 0, 0, 32, 32)
+	if id := vs.View("view 0"); id != 1 {
+		t.Errorf("view 0 by name: view %d, want 1", id)
+	}
+	if vs.Len() != 2 {
+		t.Errorf("%d views in use, want 2", vs.Len())
+	}
+	vs.Reset()
+	if vs.Len() != 0 {
+		t.Errorf("%d views after Reset, want 0", vs.Len())
+	}
+
+	vs.SetViewFrameBuffer(3, InvalidFrameBuffer)
+	if vs.byID[3].HasFrameBuffer {
+		t.Error("the backbuffer counts as a frame buffer")
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.HasPrefix(fmt.Sprint(r), "example: ") {
+			t.Errorf("panic %v, want one for a view out of range", r)
+		}
+	}()
+	vs.SetViewRect(MaxViews, 0, 0, 1, 1)
+}
+
+func TestViewsExhausted(t *testing.T) {
+	var vs Views
+	for i := 0; i < MaxViews; i++ {
+		vs.View(fmt.Sprint(i))
+	}
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "no view left for more") {
+			t.Errorf("panic %v, want no view left", r)
+		}
+	}()
+	vs.View("more")
+}
@@ -0,0 +1,477 @@
+/*
+Package graph renders a frame as a graph of passes. Each pass declares
+the targets it reads and the one it draws to, and the graph assigns each
+pass a view and runs the passes in dependency order.
+
+Frame buffers for targets come from a Pool. A target only holds one
+from the first pass that uses it to the last, so targets whose uses
+don't overlap share frame buffers, and the frame buffers of targets
+sized relative to the backbuffer are replaced when it changes size.
+Targets read as history hold two across frames, one written this frame
+and one left by the previous frame, and swap them every frame.
+*/
+package graph
+
+import (
+	"fmt"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/example"
+	"j4k.co/cgm/mat4"
+)
+
+// Size is the size of a target, either fixed or a fraction of the
+// backbuffer.
+type Size struct {
+	Width, Height int     // fixed size, if not zero
+	Scale         float32 // of the backbuffer, otherwise
+}
+
+// Fixed returns a fixed size.
+func Fixed(width, height int) Size {
+	return Size{Width: width, Height: height}
+}
+
+// Relative returns a size of scale times the backbuffer's.
+func Relative(scale float32) Size {
+	return Size{Scale: scale}
+}
+
+// Backbuffer is the size of the backbuffer.
+var Backbuffer = Relative(1)
+
+func (s Size) relative() bool {
+	return s.Width == 0 || s.Height == 0
+}
+
+// Resolve returns the size in pixels for a backbuffer of width by
+// height. Relative sizes round down, to no less than 1 by 1.
+func (s Size) Resolve(width, height int) (int, int) {
+	if !s.relative() {
+		return s.Width, s.Height
+	}
+	w := int(float32(width) * s.Scale)
+	h := int(float32(height) * s.Scale)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// Target is a render target that passes draw to and read from.
+type Target struct {
+	Name   string
+	Size   Size
+	Format bgfx.TextureFormat
+	Flags  bgfx.TextureFlags // in addition to clamping
+	Depth  bool              // add a depth buffer, which can't be read
+
+	fb            bgfx.FrameBuffer
+	prev          bgfx.FrameBuffer // of the previous frame, for history
+	prevValid     bool
+	width, height int
+	held          bool
+
+	// Set by compile: the passes, in running order, that first and
+	// last use the target, and whether it is read as history.
+	first, last int
+	history     bool
+}
+
+// FrameBuffer returns the target's frame buffer. It is only valid while
+// a pass that uses the target is executing, or for history targets,
+// after the graph has been rendered once.
+func (t *Target) FrameBuffer() bgfx.FrameBuffer {
+	return t.fb
+}
+
+// HasHistory reports whether a history target holds what was drawn to it
+// in the previous frame. It does not on the first frame, or on the first
+// after it was resized.
+func (t *Target) HasHistory() bool {
+	return t.prevValid
+}
+
+// Dimensions returns the target's size in pixels as of the last render.
+func (t *Target) Dimensions() (width, height int) {
+	return t.width, t.height
+}
+
+func (t *Target) acquire(pool *Pool, width, height int) {
+	t.width, t.height = t.Size.Resolve(width, height)
+	key := PoolKey{
+		Width:  t.width,
+		Height: t.height,
+		Format: t.Format,
+		Flags:  t.Flags,
+		Depth:  t.Depth,
+	}
+	t.fb = pool.Acquire(key)
+	if t.history {
+		t.prev = pool.Acquire(key)
+		t.prevValid = false
+	}
+	t.held = true
+}
+
+func (t *Target) release(pool *Pool) {
+	if t.held {
+		pool.Release(t.fb)
+		if t.history {
+			pool.Release(t.prev)
+		}
+		t.held = false
+	}
+}
+
+// swap makes this frame's frame buffer of a history target last frame's.
+func (t *Target) swap() {
+	t.fb, t.prev = t.prev, t.fb
+	t.prevValid = true
+}
+
+// Pass is one step of the graph, drawing to Output, or the backbuffer if
+// Output is nil.
+type Pass struct {
+	Name   string
+	Inputs []*Target // read this frame, after the passes that write them
+	Output *Target
+
+	// History are targets read, with Context.History, as they were left
+	// by the previous frame. They add no ordering, so a pass can read
+	// its own output.
+	History []*Target
+
+	Clear      bgfx.ClearFlags
+	ClearColor uint32
+
+	// Execute records the pass's draws. The view is set up to draw to
+	// the output with an orthographic projection of the unit square.
+	Execute func(c *Context)
+
+	view bgfx.ViewID
+}
+
+// View returns the view assigned to the pass.
+func (p *Pass) View() bgfx.ViewID {
+	return p.view
+}
+
+// ViewAllocator hands out views by name and sets them up, as
+// example.Views does.
+type ViewAllocator interface {
+	View(name string) bgfx.ViewID
+	SetViewRect(id bgfx.ViewID, x, y, w, h int)
+	SetViewFrameBuffer(id bgfx.ViewID, fb bgfx.FrameBuffer)
+	SetViewClear(id bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32)
+	SetViewTransform(id bgfx.ViewID, mtx, proj [16]float32)
+}
+
+// Graph is a set of targets and the passes between them.
+type Graph struct {
+	// Views allocates a view for each pass, named after it, every
+	// time the graph is rendered. If it is nil, passes get consecutive
+	// views from FirstView in the order they run.
+	Views     ViewAllocator
+	FirstView bgfx.ViewID
+
+	// Pool provides the targets' frame buffers. New gives each graph
+	// its own, which the graph ends frames on and destroys. A pool
+	// shared by several graphs is left to its owner to manage.
+	Pool *Pool
+	own  *Pool
+
+	targets       []*Target
+	passes        []*Pass
+	order         []*Pass
+	width, height int
+	dirty         bool
+	screen        example.Screen
+}
+
+// New returns an empty graph.
+func New() *Graph {
+	pool := new(Pool)
+	return &Graph{
+		Pool:   pool,
+		own:    pool,
+		screen: example.NewScreen(),
+	}
+}
+
+// AddTarget adds t to the graph and returns it.
+func (g *Graph) AddTarget(t *Target) *Target {
+	g.targets = append(g.targets, t)
+	return t
+}
+
+// AddPass adds p to the graph and returns it. Passes may be added in
+// any order; they run after the passes that write their inputs, and in
+// the order they were added otherwise.
+func (g *Graph) AddPass(p *Pass) *Pass {
+	g.passes = append(g.passes, p)
+	g.dirty = true
+	return p
+}
+
+// Passes returns the passes in the order they run.
+func (g *Graph) Passes() []*Pass {
+	g.compile()
+	return g.order
+}
+
+// compile sorts the passes so that each runs after every pass writing
+// one of its inputs. Pass names must be unique, since views are
+// allocated by name.
+func (g *Graph) compile() {
+	if !g.dirty {
+		return
+	}
+	g.dirty = false
+	names := make(map[string]bool)
+	writers := make(map[*Target][]int)
+	for i, p := range g.passes {
+		if names[p.Name] {
+			panic(fmt.Sprintf("graph: more than one pass named %s", p.Name))
+		}
+		names[p.Name] = true
+		if p.Output != nil {
+			writers[p.Output] = append(writers[p.Output], i)
+		}
+	}
+	deps := make([][]int, len(g.passes))
+	for i, p := range g.passes {
+		for _, t := range p.Inputs {
+			for _, w := range writers[t] {
+				if w != i {
+					deps[i] = append(deps[i], w)
+				}
+			}
+		}
+		// Passes drawing to the same target keep their order.
+		if p.Output != nil {
+			for _, w := range writers[p.Output] {
+				if w < i {
+					deps[i] = append(deps[i], w)
+				}
+			}
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	var (
+		state = make([]int, len(g.passes))
+		order = make([]*Pass, 0, len(g.passes))
+		visit func(i int)
+	)
+	visit = func(i int) {
+		switch state[i] {
+		case done:
+			return
+		case visiting:
+			panic(fmt.Sprintf("graph: pass %s depends on itself", g.passes[i].Name))
+		}
+		state[i] = visiting
+		for _, d := range deps[i] {
+			visit(d)
+		}
+		state[i] = done
+		order = append(order, g.passes[i])
+	}
+	for i := range g.passes {
+		visit(i)
+	}
+	g.order = order
+
+	for _, t := range g.targets {
+		t.first, t.last, t.history = -1, -1, false
+	}
+	use := func(t *Target, i int) {
+		if t.first < 0 {
+			t.first = i
+		}
+		t.last = i
+	}
+	for i, p := range order {
+		for _, t := range p.Inputs {
+			use(t, i)
+		}
+		if p.Output != nil {
+			use(p.Output, i)
+		}
+		for _, t := range p.History {
+			t.history = true
+		}
+	}
+}
+
+// Render runs the passes for a backbuffer of width by height, taking
+// frame buffers for the targets from the pool as they are needed.
+func (g *Graph) Render(width, height int) {
+	g.compile()
+	resized := width != g.width || height != g.height
+	g.width, g.height = width, height
+	for _, t := range g.targets {
+		if !t.history {
+			continue
+		}
+		if resized && t.Size.relative() {
+			t.release(g.Pool)
+		}
+		if t.held {
+			t.swap()
+		} else {
+			t.acquire(g.Pool, width, height)
+		}
+	}
+
+	var (
+		views ViewAllocator = sequentialViews{g.FirstView, g.order}
+		view                = mat4.Identity()
+		proj                = mat4.OrthoLH(0, 1, 1, 0, 0, 100)
+	)
+	if g.Views != nil {
+		views = g.Views
+	}
+	for i, p := range g.order {
+		for _, t := range g.targets {
+			if t.first == i && !t.history {
+				t.acquire(g.Pool, width, height)
+			}
+		}
+		p.view = views.View(p.Name)
+		c := Context{
+			Pass:   p,
+			View:   p.view,
+			Width:  width,
+			Height: height,
+			graph:  g,
+		}
+		if p.Output != nil {
+			c.Width, c.Height = p.Output.width, p.Output.height
+			views.SetViewFrameBuffer(p.view, p.Output.fb)
+		} else {
+			// Views are reused, so one may still have a frame
+			// buffer from whatever had it before.
+			views.SetViewFrameBuffer(p.view, example.InvalidFrameBuffer)
+		}
+		views.SetViewRect(p.view, 0, 0, c.Width, c.Height)
+		views.SetViewClear(p.view, p.Clear, p.ClearColor)
+		views.SetViewTransform(p.view, view, proj)
+		if p.Execute != nil {
+			p.Execute(&c)
+		}
+		for _, t := range g.targets {
+			if t.last == i && !t.history {
+				t.release(g.Pool)
+			}
+		}
+	}
+	if g.Pool != g.own {
+		return
+	}
+	if resized {
+		g.Pool.FreeUnused()
+	}
+	g.Pool.EndFrame()
+}
+
+// sequentialViews gives passes consecutive views in the order they run,
+// and sets them up directly.
+type sequentialViews struct {
+	first bgfx.ViewID
+	order []*Pass
+}
+
+func (s sequentialViews) View(name string) bgfx.ViewID {
+	for i, p := range s.order {
+		if p.Name == name {
+			return s.first + bgfx.ViewID(i)
+		}
+	}
+	panic("graph: no pass named " + name)
+}
+
+func (sequentialViews) SetViewRect(id bgfx.ViewID, x, y, w, h int) {
+	bgfx.SetViewRect(id, x, y, w, h)
+}
+
+func (sequentialViews) SetViewFrameBuffer(id bgfx.ViewID, fb bgfx.FrameBuffer) {
+	bgfx.SetViewFrameBuffer(id, fb)
+}
+
+func (sequentialViews) SetViewClear(id bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32) {
+	bgfx.SetViewClear(id, flags, rgba, 1, 0)
+}
+
+func (sequentialViews) SetViewTransform(id bgfx.ViewID, mtx, proj [16]float32) {
+	bgfx.SetViewTransform(id, mtx, proj)
+}
+
+// Destroy releases the history targets' frame buffers, and destroys the
+// pool if it is the graph's own.
+func (g *Graph) Destroy() {
+	g.Release()
+	if g.Pool == g.own {
+		g.Pool.Destroy()
+	}
+}
+
+// Release returns the frame buffers held by history targets to the pool.
+func (g *Graph) Release() {
+	for _, t := range g.targets {
+		t.release(g.Pool)
+	}
+	g.width, g.height = 0, 0
+}
+
+// Context is passed to a pass's Execute.
+type Context struct {
+	Pass          *Pass
+	View          bgfx.ViewID
+	Width, Height int // of the output
+
+	graph *Graph
+}
+
+// Texture binds target t's color texture to stage.
+func (c *Context) Texture(stage uint8, sampler bgfx.Uniform, t *Target) {
+	bgfx.SetTextureFromFrameBuffer(stage, sampler, t.fb)
+}
+
+// History binds the color texture that history target t was left with by
+// the previous frame to stage. See Target.HasHistory.
+func (c *Context) History(stage uint8, sampler bgfx.Uniform, t *Target) {
+	bgfx.SetTextureFromFrameBuffer(stage, sampler, t.prev)
+}
+
+// Screen returns the graph's screen geometry helper, for passes that
+// draw more than a full screen triangle.
+func (c *Context) Screen() example.Screen {
+	return c.graph.screen
+}
+
+// Quad draws a triangle covering the output with prog and state, and
+// submits it to the pass's view.
+func (c *Context) Quad(prog bgfx.Program, state bgfx.State) {
+	c.QuadOrigin(prog, state, c.graph.screen.OriginBottomLeft)
+}
+
+// QuadOrigin is like Quad, but with texture coordinates for the given
+// origin instead of the renderer's.
+func (c *Context) QuadOrigin(prog bgfx.Program, state bgfx.State, originBottomLeft bool) {
+	s := c.graph.screen
+	s.OriginBottomLeft = originBottomLeft
+	if !s.SetTriangle(float32(c.Width), float32(c.Height)) {
+		return
+	}
+	bgfx.SetProgram(prog)
+	bgfx.SetState(state)
+	bgfx.Submit(c.View)
+}
@@ -0,0 +1,194 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/example"
+)
+
+// views hands out views in the order they are asked for and records the
+// frame buffer each is set to.
+type views struct {
+	ids map[string]bgfx.ViewID
+	fbs map[bgfx.ViewID]bgfx.FrameBuffer
+}
+
+func (v *views) View(name string) bgfx.ViewID {
+	if v.ids == nil {
+		v.ids = make(map[string]bgfx.ViewID)
+		v.fbs = make(map[bgfx.ViewID]bgfx.FrameBuffer)
+	}
+	id, ok := v.ids[name]
+	if !ok {
+		id = bgfx.ViewID(len(v.ids))
+		v.ids[name] = id
+	}
+	return id
+}
+
+func (v *views) SetViewRect(id bgfx.ViewID, x, y, w, h int) {}
+
+func (v *views) SetViewFrameBuffer(id bgfx.ViewID, fb bgfx.FrameBuffer) {
+	v.fbs[id] = fb
+}
+
+func (v *views) SetViewClear(id bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32) {}
+
+func (v *views) SetViewTransform(id bgfx.ViewID, mtx, proj [16]float32) {}
+
+func names(passes []*Pass) string {
+	var s []string
+	for _, p := range passes {
+		s = append(s, p.Name)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestCompileOrder(t *testing.T) {
+	a, b, c := &Target{Name: "a"}, &Target{Name: "b"}, &Target{Name: "c"}
+	for _, tt := range []struct {
+		name   string
+		passes []*Pass
+		want   string
+	}{
+		{
+			"independent passes keep their order",
+			[]*Pass{{Name: "x"}, {Name: "y"}, {Name: "z"}},
+			"x y z",
+		},
+		{
+			"readers after writers",
+			[]*Pass{
+				{Name: "post", Inputs: []*Target{b}},
+				{Name: "blur", Inputs: []*Target{a}, Output: b},
+				{Name: "scene", Output: a},
+			},
+			"scene blur post",
+		},
+		{
+			"independent chains keep their order",
+			[]*Pass{
+				{Name: "a1", Output: a},
+				{Name: "c1", Output: c},
+				{Name: "a2", Inputs: []*Target{a}},
+				{Name: "c2", Inputs: []*Target{c}},
+			},
+			"a1 c1 a2 c2",
+		},
+		{
+			"writers to one target keep their order",
+			[]*Pass{
+				{Name: "read", Inputs: []*Target{a}},
+				{Name: "draw", Output: a},
+				{Name: "overlay", Output: a},
+			},
+			"draw overlay read",
+		},
+		{
+			"history adds no order",
+			[]*Pass{
+				{Name: "resolve", History: []*Target{a}},
+				{Name: "scene", Output: a},
+			},
+			"resolve scene",
+		},
+	} {
+		g := &Graph{}
+		for _, p := range tt.passes {
+			g.AddPass(p)
+		}
+		if got := names(g.Passes()); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func mustPanic(t *testing.T, want string, f func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("no panic, want %q", want)
+		} else if !strings.Contains(fmt.Sprint(r), want) {
+			t.Errorf("panic %q, want %q", r, want)
+		}
+	}()
+	f()
+}
+
+func TestCompileCycle(t *testing.T) {
+	a, b := &Target{Name: "a"}, &Target{Name: "b"}
+	g := &Graph{}
+	g.AddPass(&Pass{Name: "one", Inputs: []*Target{b}, Output: a})
+	g.AddPass(&Pass{Name: "two", Inputs: []*Target{a}, Output: b})
+	mustPanic(t, "depends on itself", func() { g.Passes() })
+}
+
+func TestCompileDuplicateName(t *testing.T) {
+	g := &Graph{}
+	g.AddPass(&Pass{Name: "blur"})
+	g.AddPass(&Pass{Name: "blur"})
+	mustPanic(t, "more than one pass named blur", func() { g.Passes() })
+}
+
+func TestTargetLifetimes(t *testing.T) {
+	g := &Graph{}
+	scene := g.AddTarget(&Target{Name: "scene"})
+	bloom := g.AddTarget(&Target{Name: "bloom"})
+	prev := g.AddTarget(&Target{Name: "prev"})
+	unused := g.AddTarget(&Target{Name: "unused"})
+	g.AddPass(&Pass{Name: "tonemap", Inputs: []*Target{scene, bloom}, History: []*Target{prev}})
+	g.AddPass(&Pass{Name: "bloom", Inputs: []*Target{scene}, Output: bloom})
+	g.AddPass(&Pass{Name: "scene", Output: scene})
+	g.AddPass(&Pass{Name: "copy", Inputs: []*Target{scene}, Output: prev})
+	if got, want := names(g.Passes()), "scene bloom tonemap copy"; got != want {
+		t.Fatalf("order %s, want %s", got, want)
+	}
+	for _, tt := range []struct {
+		t           *Target
+		first, last int
+		history     bool
+	}{
+		{scene, 0, 3, false},
+		{bloom, 1, 2, false},
+		{prev, 3, 3, true},
+		{unused, -1, -1, false},
+	} {
+		if tt.t.first != tt.first || tt.t.last != tt.last || tt.t.history != tt.history {
+			t.Errorf("%s: first %d, last %d, history %v; want %d, %d, %v",
+				tt.t.Name, tt.t.first, tt.t.last, tt.t.history, tt.first, tt.last, tt.history)
+		}
+	}
+}
+
+func TestRenderBackbuffer(t *testing.T) {
+	var (
+		v    views
+		g    = &Graph{Views: &v, Pool: new(Pool)}
+		runs []string
+	)
+	for _, name := range []string{"scene", "ui"} {
+		name := name
+		g.AddPass(&Pass{Name: name, Execute: func(c *Context) {
+			if c.View != v.ids[name] || c.Width != 640 || c.Height != 480 {
+				t.Errorf("%s: view %d, %dx%d", name, c.View, c.Width, c.Height)
+			}
+			runs = append(runs, name)
+		}})
+	}
+	// A view handed out before may still draw to a frame buffer.
+	v.View("scene")
+	v.fbs[v.ids["scene"]] = bgfx.FrameBuffer{}
+
+	g.Render(640, 480)
+	if got := strings.Join(runs, " "); got != "scene ui" {
+		t.Errorf("ran %s, want scene ui", got)
+	}
+	for name, id := range v.ids {
+		if fb, ok := v.fbs[id]; !ok || fb != example.InvalidFrameBuffer {
+			t.Errorf("%s does not draw to the backbuffer", name)
+		}
+	}
+}
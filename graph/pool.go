@@ -0,0 +1,209 @@
+package graph
+
+import "github.com/james4k/go-bgfx"
+
+// PoolKey describes a frame buffer; the pool only hands out frame
+// buffers that match exactly.
+type PoolKey struct {
+	Width, Height int
+	Format        bgfx.TextureFormat
+	Flags         bgfx.TextureFlags
+	Depth         bool // with a D16 depth buffer
+}
+
+// Bytes estimates the memory used by a frame buffer of key.
+func (k PoolKey) Bytes() int {
+	bits := formatBits[k.Format]
+	if k.Depth {
+		bits += formatBits[bgfx.TextureFormatD16]
+	}
+	return k.Width * k.Height * bits / 8
+}
+
+var formatBits = map[bgfx.TextureFormat]int{
+	bgfx.TextureFormatBC1:      4,
+	bgfx.TextureFormatR8:       8,
+	bgfx.TextureFormatR16F:     16,
+	bgfx.TextureFormatR32F:     32,
+	bgfx.TextureFormatBGRA8:    32,
+	bgfx.TextureFormatRGBA8:    32,
+	bgfx.TextureFormatRGBA16:   64,
+	bgfx.TextureFormatRGBA16F:  64,
+	bgfx.TextureFormatRGBA32F:  128,
+	bgfx.TextureFormatRG11B10F: 32,
+	bgfx.TextureFormatD16:      16,
+	bgfx.TextureFormatD24S8:    32,
+}
+
+// DefaultMaxIdle is how many frames a pool keeps an unused frame buffer
+// if its MaxIdle is 0.
+const DefaultMaxIdle = 3
+
+// Pool hands out frame buffers and keeps them for reuse once they are
+// released, until they go unused for MaxIdle frames.
+type Pool struct {
+	MaxIdle int
+	Stats   PoolStats // for the last frame, as of EndFrame
+
+	// CreateFrameBuffer and DestroyFrameBuffer make and free the
+	// pool's frame buffers. If nil, they are created with a color
+	// texture of the key's format, clamped, and destroyed with bgfx.
+	CreateFrameBuffer  func(key PoolKey) bgfx.FrameBuffer
+	DestroyFrameBuffer func(fb bgfx.FrameBuffer)
+
+	entries []*poolEntry
+	frame   int
+	cur     PoolStats // for this frame so far
+}
+
+// PoolStats describes a pool's frame buffers.
+type PoolStats struct {
+	FrameBuffers int // alive
+	InUse        int // at the end of the frame, such as history targets
+	Bytes        int // estimated, of all alive frame buffers
+	Created      int // this frame
+	Destroyed    int // this frame
+	PeakInUse    int // during this frame
+}
+
+type poolEntry struct {
+	key      PoolKey
+	fb       bgfx.FrameBuffer
+	inUse    bool
+	lastUsed int
+}
+
+// Acquire returns a frame buffer matching key, reusing a released one if
+// there is one.
+func (p *Pool) Acquire(key PoolKey) bgfx.FrameBuffer {
+	for _, e := range p.entries {
+		if !e.inUse && e.key == key {
+			e.inUse = true
+			e.lastUsed = p.frame
+			p.countInUse()
+			return e.fb
+		}
+	}
+	e := &poolEntry{
+		key:      key,
+		fb:       p.create(key),
+		inUse:    true,
+		lastUsed: p.frame,
+	}
+	p.entries = append(p.entries, e)
+	p.cur.Created++
+	p.countInUse()
+	return e.fb
+}
+
+func (p *Pool) countInUse() {
+	n := 0
+	for _, e := range p.entries {
+		if e.inUse {
+			n++
+		}
+	}
+	if n > p.cur.PeakInUse {
+		p.cur.PeakInUse = n
+	}
+}
+
+// Release returns fb to the pool for reuse.
+func (p *Pool) Release(fb bgfx.FrameBuffer) {
+	for _, e := range p.entries {
+		if e.inUse && e.fb == fb {
+			e.inUse = false
+			e.lastUsed = p.frame
+			return
+		}
+	}
+	panic("graph: released a frame buffer not from the pool")
+}
+
+// EndFrame destroys frame buffers that have not been used for MaxIdle
+// frames and updates Stats.
+func (p *Pool) EndFrame() {
+	maxIdle := p.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = DefaultMaxIdle
+	}
+	p.collect(func(e *poolEntry) bool {
+		return p.frame-e.lastUsed >= maxIdle
+	})
+	s := &p.cur
+	s.FrameBuffers = len(p.entries)
+	for _, e := range p.entries {
+		if e.inUse {
+			s.InUse++
+		}
+		s.Bytes += e.key.Bytes()
+	}
+	p.Stats = *s
+	p.cur = PoolStats{PeakInUse: s.InUse}
+	p.frame++
+}
+
+// FreeUnused destroys every frame buffer that has not been used this
+// frame, such as after the backbuffer is resized and the old sizes are
+// no longer wanted.
+func (p *Pool) FreeUnused() {
+	p.collect(func(e *poolEntry) bool { return e.lastUsed < p.frame })
+}
+
+func (p *Pool) collect(free func(e *poolEntry) bool) {
+	kept := p.entries[:0]
+	for _, e := range p.entries {
+		if !e.inUse && free(e) {
+			p.destroy(e.fb)
+			p.cur.Destroyed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	for i := len(kept); i < len(p.entries); i++ {
+		p.entries[i] = nil
+	}
+	p.entries = kept
+}
+
+// Destroy destroys all of the pool's frame buffers, whether in use or
+// not.
+func (p *Pool) Destroy() {
+	for _, e := range p.entries {
+		p.destroy(e.fb)
+	}
+	p.entries = nil
+}
+
+// DrawStats prints the pool's statistics to the debug text at row y.
+func (p *Pool) DrawStats(y int) {
+	s := p.Stats
+	bgfx.DebugTextPrintf(0, y, 0x0f, "Targets: %d (%d held, peak %d in use), %.1f MiB",
+		s.FrameBuffers, s.InUse, s.PeakInUse, float64(s.Bytes)/(1<<20))
+}
+
+func (p *Pool) create(key PoolKey) bgfx.FrameBuffer {
+	if p.CreateFrameBuffer != nil {
+		return p.CreateFrameBuffer(key)
+	}
+	return createFrameBuffer(key)
+}
+
+func (p *Pool) destroy(fb bgfx.FrameBuffer) {
+	if p.DestroyFrameBuffer != nil {
+		p.DestroyFrameBuffer(fb)
+		return
+	}
+	bgfx.DestroyFrameBuffer(fb)
+}
+
+func createFrameBuffer(key PoolKey) bgfx.FrameBuffer {
+	flags := bgfx.TextureUClamp | bgfx.TextureVClamp | key.Flags
+	if key.Depth {
+		return bgfx.CreateFrameBufferFromTextures([]bgfx.Texture{
+			bgfx.CreateTexture2D(key.Width, key.Height, 1, key.Format, bgfx.TextureRT|flags, nil),
+			bgfx.CreateTexture2D(key.Width, key.Height, 1, bgfx.TextureFormatD16, bgfx.TextureRTBufferOnly, nil),
+		}, true)
+	}
+	return bgfx.CreateFrameBuffer(key.Width, key.Height, key.Format, flags)
+}
@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// fakeFrameBuffers makes p create distinct frame buffers without a
+// renderer, and returns the set of those alive.
+func fakeFrameBuffers(t *testing.T, p *Pool) map[bgfx.FrameBuffer]PoolKey {
+	var fb bgfx.FrameBuffer
+	if unsafe.Sizeof(fb) != 2 {
+		t.Skip("frame buffer handles are not 16 bit indices")
+	}
+	alive := make(map[bgfx.FrameBuffer]PoolKey)
+	next := uint16(0)
+	p.CreateFrameBuffer = func(key PoolKey) bgfx.FrameBuffer {
+		var fb bgfx.FrameBuffer
+		*(*uint16)(unsafe.Pointer(&fb)) = next
+		next++
+		alive[fb] = key
+		return fb
+	}
+	p.DestroyFrameBuffer = func(fb bgfx.FrameBuffer) {
+		if _, ok := alive[fb]; !ok {
+			t.Errorf("destroyed frame buffer %v twice", fb)
+		}
+		delete(alive, fb)
+	}
+	return alive
+}
+
+func TestPoolReuse(t *testing.T) {
+	var p Pool
+	fakeFrameBuffers(t, &p)
+	key := PoolKey{Width: 256, Height: 128, Format: bgfx.TextureFormatRGBA16F}
+	fb := p.Acquire(key)
+	p.Release(fb)
+	if got := p.Acquire(key); got != fb {
+		t.Errorf("released frame buffer not reused")
+	}
+	p.Release(fb)
+
+	for _, other := range []PoolKey{
+		{Width: 128, Height: 128, Format: bgfx.TextureFormatRGBA16F},
+		{Width: 256, Height: 128, Format: bgfx.TextureFormatRGBA8},
+		{Width: 256, Height: 128, Format: bgfx.TextureFormatRGBA16F, Depth: true},
+		{Width: 256, Height: 128, Format: bgfx.TextureFormatRGBA16F, Flags: bgfx.TextureMinPoint},
+	} {
+		if got := p.Acquire(other); got == fb {
+			t.Errorf("%+v reused the frame buffer of %+v", other, key)
+		}
+	}
+	// One in use isn't handed out again.
+	a := p.Acquire(key)
+	if b := p.Acquire(key); b == a {
+		t.Errorf("frame buffer in use handed out twice")
+	}
+	p.EndFrame()
+	if p.Stats.Created != 6 {
+		t.Errorf("created %d frame buffers, want 6", p.Stats.Created)
+	}
+}
+
+func TestPoolIdle(t *testing.T) {
+	p := Pool{MaxIdle: 2}
+	alive := fakeFrameBuffers(t, &p)
+	key := PoolKey{Width: 64, Height: 64, Format: bgfx.TextureFormatRGBA8}
+	held := p.Acquire(key)
+	p.Release(p.Acquire(key))
+	for frame := 0; frame < 2; frame++ {
+		p.EndFrame()
+		if len(alive) != 2 {
+			t.Fatalf("frame %d: %d frame buffers alive, want 2", frame, len(alive))
+		}
+	}
+	// Unused for MaxIdle frames.
+	p.EndFrame()
+	if len(alive) != 1 || p.Stats.Destroyed != 1 {
+		t.Fatalf("%d alive and %d destroyed, want 1 and 1", len(alive), p.Stats.Destroyed)
+	}
+	// One held across frames is never idle.
+	for frame := 0; frame < 5; frame++ {
+		p.EndFrame()
+	}
+	if _, ok := alive[held]; !ok {
+		t.Error("frame buffer in use was destroyed")
+	}
+	p.Destroy()
+	if len(alive) != 0 {
+		t.Errorf("%d frame buffers alive after Destroy", len(alive))
+	}
+}
+
+func TestPoolFreeUnused(t *testing.T) {
+	var p Pool
+	alive := fakeFrameBuffers(t, &p)
+	small := PoolKey{Width: 64, Height: 64, Format: bgfx.TextureFormatRGBA8}
+	large := PoolKey{Width: 128, Height: 128, Format: bgfx.TextureFormatRGBA8}
+	held := p.Acquire(small)
+	p.Release(p.Acquire(small))
+	p.Release(p.Acquire(large))
+	p.EndFrame()
+
+	p.Release(p.Acquire(large))
+	p.FreeUnused()
+	// The second small one went unused this frame; the held one and
+	// the large one did not.
+	if len(alive) != 2 {
+		t.Errorf("%d frame buffers alive, want 2", len(alive))
+	}
+	if _, ok := alive[held]; !ok {
+		t.Error("frame buffer in use was freed")
+	}
+	for fb, key := range alive {
+		if fb != held && key != large {
+			t.Errorf("kept %+v, want the large one", key)
+		}
+	}
+	p.EndFrame()
+	if p.Stats.Destroyed != 1 {
+		t.Errorf("destroyed %d, want 1", p.Stats.Destroyed)
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	var p Pool
+	fakeFrameBuffers(t, &p)
+	color := PoolKey{Width: 100, Height: 100, Format: bgfx.TextureFormatRGBA8}
+	depth := PoolKey{Width: 100, Height: 100, Format: bgfx.TextureFormatRGBA16F, Depth: true}
+	a := p.Acquire(color)
+	b := p.Acquire(depth)
+	p.Release(a)
+	p.Acquire(color)
+	p.Release(b)
+	p.EndFrame()
+	want := PoolStats{
+		FrameBuffers: 2,
+		InUse:        1,
+		Bytes:        100 * 100 * (4 + 8 + 2),
+		Created:      2,
+		PeakInUse:    2,
+	}
+	if p.Stats != want {
+		t.Errorf("stats %+v, want %+v", p.Stats, want)
+	}
+	// Counts start over each frame, and the peak starts from what is
+	// held across the frame.
+	p.EndFrame()
+	want.Created, want.PeakInUse = 0, 1
+	if p.Stats != want {
+		t.Errorf("next frame: stats %+v, want %+v", p.Stats, want)
+	}
+}
+
+func TestPoolReleaseUnknown(t *testing.T) {
+	var p Pool
+	fakeFrameBuffers(t, &p)
+	fb := p.Acquire(PoolKey{Width: 1, Height: 1})
+	p.Release(fb)
+	mustPanic(t, "not from the pool", func() { p.Release(fb) })
+}
@@ -0,0 +1,40 @@
+package hdr
+
+import "math"
+
+// Adaptation is how quickly auto exposure follows changes in the scene's
+// average luminance, like an eye adjusting to light. The adapted
+// luminance approaches the scene's exponentially, covering 1-1/e of the
+// way in 1/rate seconds.
+type Adaptation struct {
+	Up   float32 // rate per second toward a brighter scene
+	Down float32 // and toward a darker one
+}
+
+// Adapt returns the adapted luminance dt seconds after it was adapted,
+// for a scene whose average luminance is lum. It is what fs_hdr_adapt
+// computes on the GPU.
+func (a Adaptation) Adapt(adapted, lum, dt float32) float32 {
+	rate := a.Down
+	if lum > adapted {
+		rate = a.Up
+	}
+	return adapted + (lum-adapted)*blend(rate, dt)
+}
+
+// Params returns the value of u_adapt for a frame of dt seconds: how far
+// to move toward a brighter and a darker scene, and 1 if the previous
+// adapted luminance is valid or 0 to take the scene's as is.
+func (a Adaptation) Params(dt float32, hasHistory bool) [4]float32 {
+	var valid float32
+	if hasHistory {
+		valid = 1
+	}
+	return [4]float32{blend(a.Up, dt), blend(a.Down, dt), valid, 0}
+}
+
+// blend returns the fraction of the way covered in dt seconds at rate.
+// It does not depend on how dt is split into frames.
+func blend(rate, dt float32) float32 {
+	return 1 - float32(math.Exp(-float64(rate*dt)))
+}
@@ -0,0 +1,80 @@
+package hdr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAdaptRates(t *testing.T) {
+	a := Adaptation{Up: 2, Down: 0.5}
+	const dt = 0.1
+
+	// Toward a brighter scene at the up rate.
+	got := a.Adapt(1, 2, dt)
+	want := 1 + (1 - float32(math.Exp(-2*dt)))
+	if !near(got, want) {
+		t.Errorf("brightening: %v, want %v", got, want)
+	}
+
+	// Toward a darker scene at the down rate.
+	got = a.Adapt(2, 1, dt)
+	want = 2 - (1 - float32(math.Exp(-0.5*dt)))
+	if !near(got, want) {
+		t.Errorf("darkening: %v, want %v", got, want)
+	}
+}
+
+func TestAdaptSplitFrames(t *testing.T) {
+	a := Adaptation{Up: 1.5, Down: 0.37}
+	for _, lum := range []float32{0.05, 4} {
+		once := a.Adapt(1, lum, 0.6)
+		split := float32(1)
+		for i := 0; i < 6; i++ {
+			split = a.Adapt(split, lum, 0.1)
+		}
+		if !near(once, split) {
+			t.Errorf("lum %v: one 0.6s step %v, six 0.1s steps %v", lum, once, split)
+		}
+	}
+}
+
+func TestAdaptConverges(t *testing.T) {
+	a := Adaptation{Up: 1, Down: 0.37}
+	for _, lum := range []float32{0.01, 10} {
+		adapted, dist := float32(1), abs(lum-1)
+		for i := 0; i < 600; i++ {
+			adapted = a.Adapt(adapted, lum, 1.0/60)
+			d := abs(lum - adapted)
+			if d > dist {
+				t.Fatalf("lum %v: moved away, %v after %v", lum, adapted, i)
+			}
+			dist = d
+		}
+		// Ten seconds is 3.7 time constants at the slower rate.
+		if dist > abs(lum-1)*0.03 {
+			t.Errorf("lum %v: %v after 10s", lum, adapted)
+		}
+	}
+}
+
+func TestAdaptParams(t *testing.T) {
+	a := Adaptation{Up: 2, Down: 0.5}
+	p := a.Params(0.1, false)
+	if !near(1+p[0], a.Adapt(1, 2, 0.1)) || !near(2-p[1], a.Adapt(2, 1, 0.1)) || p[2] != 0 {
+		t.Errorf("Params = %v", p)
+	}
+	if p := a.Params(0.1, true); p[2] != 1 {
+		t.Errorf("with history, Params = %v", p)
+	}
+}
+
+func near(a, b float32) bool {
+	return abs(a-b) < 1e-5
+}
+
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,281 @@
+package hdr
+
+import (
+	"fmt"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/graph"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+// Kernel is the filter a Bloom blurs with as it downsamples and
+// upsamples.
+type Kernel int
+
+const (
+	// DualFilter is Marius Bjørge's dual Kawase filter: 5 taps down and
+	// 8 up.
+	DualFilter Kernel = iota
+	// Gaussian is a 3x3 binomial kernel both ways, which is smoother
+	// but takes more taps.
+	Gaussian
+	NumKernels
+)
+
+func (k Kernel) String() string {
+	switch k {
+	case DualFilter:
+		return "dual filter"
+	case Gaussian:
+		return "Gaussian"
+	}
+	return "unknown"
+}
+
+// Bloom is a chain of passes that makes the bright parts of the scene
+// glow. The scene is downsampled through Levels targets, each half the
+// size of the one before, keeping only what is brighter than Threshold,
+// and blurred back up, adding each level to the one above. Tonemapping
+// adds the result to the scene, scaled by Intensity.
+//
+// On the RGBE path the levels are RGBE encoded too, and filtering them
+// is only an approximation.
+type Bloom struct {
+	Kernel    Kernel
+	Threshold float32 // brightness above which the scene blooms
+	Knee      float32 // width of the soft knee, as a fraction of Threshold
+	Intensity float32
+	Radius    float32 // of the upsampling filter, in texels
+
+	levels          int
+	down, up        []*graph.Target
+	prefilter, step bgfx.Program
+
+	uBloom   *render.Uniform[[4]float32]
+	uTaps    *render.Uniform[[4]float32]
+	uOffset  *render.Uniform[[4]float32]
+	uTexture bgfx.Uniform
+	uBase    bgfx.Uniform
+	uBloomed bgfx.Uniform
+}
+
+// NewBloom returns a bloom of the given number of levels, with default
+// settings, loading its shaders for formats.
+func NewBloom(formats Formats, levels int) *Bloom {
+	if levels < 1 {
+		panic("hdr: bloom needs at least one level")
+	}
+	return &Bloom{
+		Kernel:    DualFilter,
+		Threshold: 1,
+		Knee:      0.5,
+		Intensity: 0.1,
+		Radius:    1,
+
+		levels:    levels,
+		prefilter: assets.LoadProgram("vs_hdr_lumavg", formats.Shader("fs_hdr_bloom_prefilter")),
+		step:      assets.LoadProgram("vs_hdr_lumavg", formats.Shader("fs_hdr_bloom")),
+		uBloom:    render.NewUniform[[4]float32]("u_bloom", 1),
+		uTaps:     render.NewUniform[[4]float32]("u_bloomTaps", 1),
+		uOffset:   render.NewUniform[[4]float32]("u_bloomOffset", 16),
+		uTexture:  bgfx.CreateUniform("u_texSource", bgfx.Uniform1i, 1),
+		uBase:     bgfx.CreateUniform("u_texBase", bgfx.Uniform1i, 1),
+		uBloomed:  bgfx.CreateUniform("u_texBloom", bgfx.Uniform1i, 1),
+	}
+}
+
+// AddPasses adds the bloom's targets, in format, and passes to g, and
+// returns the target that holds the result.
+func (b *Bloom) AddPasses(g *graph.Graph, scene *graph.Target, format bgfx.TextureFormat) *graph.Target {
+	b.down = make([]*graph.Target, b.levels)
+	b.up = make([]*graph.Target, b.levels-1)
+	scale := float32(1)
+	for i := range b.down {
+		scale /= 2
+		b.down[i] = g.AddTarget(&graph.Target{
+			Name:   fmt.Sprintf("bloom down %d", i),
+			Size:   graph.Relative(scale),
+			Format: format,
+		})
+		if i < len(b.up) {
+			b.up[i] = g.AddTarget(&graph.Target{
+				Name:   fmt.Sprintf("bloom up %d", i),
+				Size:   graph.Relative(scale),
+				Format: format,
+			})
+		}
+	}
+
+	g.AddPass(&graph.Pass{
+		Name:   "bloom prefilter",
+		Inputs: []*graph.Target{scene},
+		Output: b.down[0],
+		Execute: func(c *graph.Context) {
+			w, h := scene.Dimensions()
+			b.setTaps(DownTaps(b.Kernel, w, h), 0)
+			c.Texture(0, b.uTexture, scene)
+			c.Quad(b.prefilter, bloomState)
+		},
+	})
+	for i := 1; i < b.levels; i++ {
+		in := b.down[i-1]
+		g.AddPass(&graph.Pass{
+			Name:   fmt.Sprintf("bloom downsample %d", i),
+			Inputs: []*graph.Target{in},
+			Output: b.down[i],
+			Execute: func(c *graph.Context) {
+				w, h := in.Dimensions()
+				b.setTaps(DownTaps(b.Kernel, w, h), 0)
+				c.Texture(0, b.uTexture, in)
+				c.Texture(1, b.uBase, in) // weighted 0, but sampled
+				c.Quad(b.step, bloomState)
+			},
+		})
+	}
+	for i := len(b.up) - 1; i >= 0; i-- {
+		in := b.down[i+1]
+		if i+1 < len(b.up) {
+			in = b.up[i+1]
+		}
+		base := b.down[i]
+		g.AddPass(&graph.Pass{
+			Name:   fmt.Sprintf("bloom upsample %d", i),
+			Inputs: []*graph.Target{in, base},
+			Output: b.up[i],
+			Execute: func(c *graph.Context) {
+				w, h := in.Dimensions()
+				b.setTaps(UpTaps(b.Kernel, w, h, b.Radius), 1)
+				c.Texture(0, b.uTexture, in)
+				c.Texture(1, b.uBase, base)
+				c.Quad(b.step, bloomState)
+			},
+		})
+	}
+	return b.Result()
+}
+
+const bloomState = bgfx.StateRGBWrite | bgfx.StateAlphaWrite
+
+// Result returns the target that holds the bloom, once AddPasses has
+// been called.
+func (b *Bloom) Result() *graph.Target {
+	if len(b.up) > 0 {
+		return b.up[0]
+	}
+	return b.down[0]
+}
+
+// Bind sets the bloom's settings for the next draw, and binds its result
+// to stage as u_texBloom, for the tonemapping pass. It must be called
+// from a pass that has Result as an input.
+func (b *Bloom) Bind(c *graph.Context, stage uint8) {
+	b.set()
+	c.Texture(stage, b.uBloomed, b.Result())
+}
+
+func (b *Bloom) set() {
+	b.uBloom.Set([4]float32{b.Threshold, b.Threshold * b.Knee, b.Intensity, 0})
+}
+
+func (b *Bloom) setTaps(taps [][4]float32, base float32) {
+	b.set()
+	b.uTaps.Set([4]float32{float32(len(taps)), base, 0, 0})
+	b.uOffset.Set(taps...)
+}
+
+// DownTaps returns the taps that downsample a level of w by h texels
+// with kernel k: texture coordinate offsets in x and y, and weights in z.
+func DownTaps(k Kernel, w, h int) [][4]float32 {
+	du, dv := 1/float32(w), 1/float32(h)
+	if k == Gaussian {
+		return binomialTaps(du, dv)
+	}
+	// Four bilinear taps between the corner texels, and the center.
+	return [][4]float32{
+		{0, 0, 4.0 / 8, 0},
+		{-du, -dv, 1.0 / 8, 0},
+		{du, -dv, 1.0 / 8, 0},
+		{-du, dv, 1.0 / 8, 0},
+		{du, dv, 1.0 / 8, 0},
+	}
+}
+
+// UpTaps returns the taps that upsample a level of w by h texels with
+// kernel k, spread out to radius texels, like DownTaps.
+func UpTaps(k Kernel, w, h int, radius float32) [][4]float32 {
+	du, dv := radius/float32(w), radius/float32(h)
+	if k == Gaussian {
+		return binomialTaps(du, dv)
+	}
+	hu, hv := du/2, dv/2
+	return [][4]float32{
+		{-du, 0, 1.0 / 12, 0},
+		{du, 0, 1.0 / 12, 0},
+		{0, -dv, 1.0 / 12, 0},
+		{0, dv, 1.0 / 12, 0},
+		{-hu, -hv, 2.0 / 12, 0},
+		{hu, -hv, 2.0 / 12, 0},
+		{-hu, hv, 2.0 / 12, 0},
+		{hu, hv, 2.0 / 12, 0},
+	}
+}
+
+// binomialTaps returns 3x3 taps du and dv apart, weighted 1 2 1 in each
+// direction.
+func binomialTaps(du, dv float32) [][4]float32 {
+	weights := [3]float32{1, 2, 1}
+	taps := make([][4]float32, 0, 9)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			taps = append(taps, [4]float32{
+				float32(x-1) * du,
+				float32(y-1) * dv,
+				weights[x] * weights[y] / 16,
+				0,
+			})
+		}
+	}
+	return taps
+}
+
+const (
+	intensityPerSecond = 0.25
+	radiusPerSecond    = 1
+	thresholdPerSecond = 1
+)
+
+// Update changes the settings from the keyboard: B switches kernels, I
+// and K raise and lower the intensity, O and L the radius, and U and J
+// the threshold.
+func (b *Bloom) Update(app *example.Application) {
+	if app.KeyPressed(example.KeyB) {
+		b.Kernel = (b.Kernel + 1) % NumKernels
+	}
+	dt := app.DeltaTime
+	b.Intensity = clamp(b.Intensity+app.Axis(example.KeyI, example.KeyK)*intensityPerSecond*dt, 0, 2)
+	b.Radius = clamp(b.Radius+app.Axis(example.KeyO, example.KeyL)*radiusPerSecond*dt, 0.25, 4)
+	b.Threshold = clamp(b.Threshold+app.Axis(example.KeyU, example.KeyJ)*thresholdPerSecond*dt, 0, 10)
+}
+
+// Draw prints the settings and their keys to the debug text, starting
+// at row y. It uses two rows.
+func (b *Bloom) Draw(y int) {
+	bgfx.DebugTextPrintf(0, y, 0x0f, "Bloom: %s, %d levels  [B]", b.Kernel, b.levels)
+	bgfx.DebugTextPrintf(0, y+1, 0x0f, "Intensity %.2f [I/K], radius %.2f [O/L], threshold %.2f [U/J]",
+		b.Intensity, b.Radius, b.Threshold)
+}
+
+// Destroy destroys the bloom's programs and uniforms. Its targets belong
+// to the graph.
+func (b *Bloom) Destroy() {
+	bgfx.DestroyProgram(b.prefilter)
+	bgfx.DestroyProgram(b.step)
+	b.uBloom.Destroy()
+	b.uTaps.Destroy()
+	b.uOffset.Destroy()
+	bgfx.DestroyUniform(b.uTexture)
+	bgfx.DestroyUniform(b.uBase)
+	bgfx.DestroyUniform(b.uBloomed)
+}
@@ -0,0 +1,103 @@
+package hdr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/james4k/go-bgfx-examples/graph"
+)
+
+func TestTaps(t *testing.T) {
+	const w, h = 64, 32
+	const du, dv = 1.0 / w, 1.0 / h
+	for _, tt := range []struct {
+		name    string
+		taps    [][4]float32
+		n       int
+		maxU    float32 // largest offsets
+		maxV    float32
+		centers int // taps with no offset
+	}{
+		{"dual filter down", DownTaps(DualFilter, w, h), 5, du, dv, 1},
+		{"dual filter up", UpTaps(DualFilter, w, h, 1), 8, du, dv, 0},
+		{"dual filter up, radius 2", UpTaps(DualFilter, w, h, 2), 8, 2 * du, 2 * dv, 0},
+		{"Gaussian down", DownTaps(Gaussian, w, h), 9, du, dv, 1},
+		{"Gaussian up, radius 2", UpTaps(Gaussian, w, h, 2), 9, 2 * du, 2 * dv, 1},
+	} {
+		if len(tt.taps) != tt.n {
+			t.Errorf("%s: %d taps, want %d", tt.name, len(tt.taps), tt.n)
+			continue
+		}
+		var sum, sumU, sumV, maxU, maxV float32
+		centers := 0
+		for _, tap := range tt.taps {
+			sum += tap[2]
+			sumU += tap[0] * tap[2]
+			sumV += tap[1] * tap[2]
+			if u := abs(tap[0]); u > maxU {
+				maxU = u
+			}
+			if v := abs(tap[1]); v > maxV {
+				maxV = v
+			}
+			if tap[0] == 0 && tap[1] == 0 {
+				centers++
+			}
+		}
+		if !near(sum, 1) {
+			t.Errorf("%s: weights sum to %v", tt.name, sum)
+		}
+		// Symmetric, so that nothing shifts as it blurs.
+		if !near(sumU, 0) || !near(sumV, 0) {
+			t.Errorf("%s: weighted offsets sum to %v, %v", tt.name, sumU, sumV)
+		}
+		if !near(maxU, tt.maxU) || !near(maxV, tt.maxV) {
+			t.Errorf("%s: offsets reach %v, %v; want %v, %v", tt.name, maxU, maxV, tt.maxU, tt.maxV)
+		}
+		if centers != tt.centers {
+			t.Errorf("%s: %d center taps, want %d", tt.name, centers, tt.centers)
+		}
+	}
+}
+
+func TestGaussianWeights(t *testing.T) {
+	taps := DownTaps(Gaussian, 4, 4)
+	want := []float32{1, 2, 1, 2, 4, 2, 1, 2, 1}
+	for i, tap := range taps {
+		if !near(tap[2], want[i]/16) {
+			t.Errorf("tap %d: weight %v, want %v/16", i, tap[2], want[i])
+		}
+	}
+}
+
+func TestBloomLevelSizes(t *testing.T) {
+	for _, tt := range []struct {
+		w, h   int
+		levels int
+		want   string
+	}{
+		{1280, 720, 4, "640x360 320x180 160x90 80x45"},
+		{101, 57, 7, "50x28 25x14 12x7 6x3 3x1 1x1 1x1"},
+		{3, 2, 3, "1x1 1x1 1x1"},
+		{1, 1, 2, "1x1 1x1"},
+	} {
+		g := &graph.Graph{}
+		b := &Bloom{levels: tt.levels}
+		b.AddPasses(g, g.AddTarget(&graph.Target{Name: "scene"}), 0)
+		got := ""
+		for i, d := range b.down {
+			w, h := d.Size.Resolve(tt.w, tt.h)
+			if i > 0 {
+				got += " "
+			}
+			got += fmt.Sprintf("%dx%d", w, h)
+			// Each upsampled level matches the one it is added to.
+			if i < len(b.up) && b.up[i].Size != d.Size {
+				t.Errorf("%dx%d: up %d is %+v, down is %+v", tt.w, tt.h, i, b.up[i].Size, d.Size)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%dx%d: levels %s, want %s", tt.w, tt.h, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package hdr
+
+import (
+	"fmt"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// Encoding is how HDR values are stored in render targets.
+type Encoding int
+
+const (
+	// EncodingRGBE stores colors in BGRA8, with an exponent shared by
+	// the color channels in alpha. Every renderer supports it, but the
+	// values can't be blended or filtered.
+	EncodingRGBE Encoding = iota
+	// EncodingFloat stores colors in floating point formats.
+	EncodingFloat
+)
+
+func (e Encoding) String() string {
+	if e == EncodingFloat {
+		return "float"
+	}
+	return "RGBE"
+}
+
+// Formats are the formats of an HDR pipeline's targets.
+type Formats struct {
+	Encoding  Encoding
+	Color     bgfx.TextureFormat // of the scene
+	Luminance bgfx.TextureFormat // of the luminance chain
+}
+
+// RGBE are the formats of the encoded path.
+var RGBE = Formats{
+	Encoding:  EncodingRGBE,
+	Color:     bgfx.TextureFormatBGRA8,
+	Luminance: bgfx.TextureFormatBGRA8,
+}
+
+// In order of preference.
+var (
+	floatColorFormats = []bgfx.TextureFormat{bgfx.TextureFormatRGBA16F, bgfx.TextureFormatRG11B10F}
+	floatLumFormats   = []bgfx.TextureFormat{bgfx.TextureFormatR16F, bgfx.TextureFormatR32F}
+)
+
+// FloatFormats returns the float formats that caps report support for,
+// and whether there were any for both color and luminance.
+func FloatFormats(caps bgfx.CapsInfo) (Formats, bool) {
+	color, colorOK := firstSupported(caps, floatColorFormats)
+	lum, lumOK := firstSupported(caps, floatLumFormats)
+	return Formats{EncodingFloat, color, lum}, colorOK && lumOK
+}
+
+func firstSupported(caps bgfx.CapsInfo, formats []bgfx.TextureFormat) (bgfx.TextureFormat, bool) {
+	for _, f := range formats {
+		// 1 is supported; 2 is emulated, which can't be rendered to.
+		if caps.Formats[f] == 1 {
+			return f, true
+		}
+	}
+	return bgfx.TextureFormatUnknown, false
+}
+
+// ChooseFormats returns the formats to use for an encoding of "auto",
+// "float" or "rgbe". Auto picks float formats if caps support them and
+// RGBE otherwise; the others force a path, and fail if float formats
+// are forced but not supported.
+func ChooseFormats(caps bgfx.CapsInfo, encoding string) (Formats, error) {
+	switch encoding {
+	case "", "auto":
+		if f, ok := FloatFormats(caps); ok {
+			return f, nil
+		}
+		return RGBE, nil
+	case "float":
+		f, ok := FloatFormats(caps)
+		if !ok {
+			return RGBE, fmt.Errorf("hdr: renderer does not support float render targets")
+		}
+		return f, nil
+	case "rgbe":
+		return RGBE, nil
+	}
+	return RGBE, fmt.Errorf("hdr: unknown encoding %q; want auto, float or rgbe", encoding)
+}
+
+// Shader returns the name of the variant of an HDR shader for the
+// encoding: name itself for RGBE, or name_float.
+func (f Formats) Shader(name string) string {
+	if f.Encoding == EncodingFloat {
+		return name + "_float"
+	}
+	return name
+}
+
+func (f Formats) String() string {
+	return fmt.Sprintf("%v (color %s, luminance %s)", f.Encoding,
+		formatNames[f.Color], formatNames[f.Luminance])
+}
+
+var formatNames = map[bgfx.TextureFormat]string{
+	bgfx.TextureFormatBGRA8:    "BGRA8",
+	bgfx.TextureFormatRGBA16F:  "RGBA16F",
+	bgfx.TextureFormatRG11B10F: "RG11B10F",
+	bgfx.TextureFormatR16F:     "R16F",
+	bgfx.TextureFormatR32F:     "R32F",
+}
@@ -0,0 +1,78 @@
+package hdr
+
+import (
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// caps reports formats as supported, and emulated formats as such.
+func caps(formats []bgfx.TextureFormat, emulated ...bgfx.TextureFormat) bgfx.CapsInfo {
+	var c bgfx.CapsInfo
+	for _, f := range formats {
+		c.Formats[f] = 1
+	}
+	for _, f := range emulated {
+		c.Formats[f] = 2
+	}
+	return c
+}
+
+const (
+	rgba16f  = bgfx.TextureFormatRGBA16F
+	rg11b10f = bgfx.TextureFormatRG11B10F
+	r16f     = bgfx.TextureFormatR16F
+	r32f     = bgfx.TextureFormatR32F
+)
+
+func TestChooseFormats(t *testing.T) {
+	float := func(color, lum bgfx.TextureFormat) Formats {
+		return Formats{EncodingFloat, color, lum}
+	}
+	all := []bgfx.TextureFormat{rgba16f, rg11b10f, r16f, r32f}
+	for _, tt := range []struct {
+		name     string
+		caps     bgfx.CapsInfo
+		encoding string
+		want     Formats
+		err      bool
+	}{
+		{"everything", caps(all), "auto", float(rgba16f, r16f), false},
+		{"default is auto", caps(all), "", float(rgba16f, r16f), false},
+		{"no RGBA16F", caps([]bgfx.TextureFormat{rg11b10f, r16f}), "auto", float(rg11b10f, r16f), false},
+		{"no R16F", caps([]bgfx.TextureFormat{rgba16f, r32f}), "auto", float(rgba16f, r32f), false},
+		{"emulated RGBA16F", caps([]bgfx.TextureFormat{rg11b10f, r16f}, rgba16f), "auto", float(rg11b10f, r16f), false},
+		{"no float color", caps([]bgfx.TextureFormat{r16f, r32f}), "auto", RGBE, false},
+		{"no float luminance", caps([]bgfx.TextureFormat{rgba16f, rg11b10f}), "auto", RGBE, false},
+		{"only emulated", caps(nil, all...), "auto", RGBE, false},
+		{"nothing", caps(nil), "auto", RGBE, false},
+		{"forced float", caps([]bgfx.TextureFormat{rg11b10f, r32f}), "float", float(rg11b10f, r32f), false},
+		{"forced float unsupported", caps([]bgfx.TextureFormat{rgba16f}), "float", RGBE, true},
+		{"forced RGBE", caps(all), "rgbe", RGBE, false},
+		{"unknown", caps(all), "half", RGBE, true},
+	} {
+		got, err := ChooseFormats(tt.caps, tt.encoding)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("%s: got %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFloatFormats(t *testing.T) {
+	f, ok := FloatFormats(caps([]bgfx.TextureFormat{rgba16f}))
+	if ok || f.Color != rgba16f || f.Luminance != bgfx.TextureFormatUnknown {
+		t.Errorf("color only: got %+v, %v", f, ok)
+	}
+	if f, ok := FloatFormats(caps([]bgfx.TextureFormat{rg11b10f, r32f})); !ok || f != (Formats{EncodingFloat, rg11b10f, r32f}) {
+		t.Errorf("fallbacks: got %+v, %v", f, ok)
+	}
+}
+
+func TestShaderVariant(t *testing.T) {
+	if got := RGBE.Shader("fs_hdr_lum"); got != "fs_hdr_lum" {
+		t.Errorf("RGBE: %s", got)
+	}
+	if got := (Formats{Encoding: EncodingFloat}).Shader("fs_hdr_lum"); got != "fs_hdr_lum_float" {
+		t.Errorf("float: %s", got)
+	}
+}
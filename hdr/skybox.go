@@ -0,0 +1,81 @@
+package hdr
+
+import (
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/graph"
+	"github.com/james4k/go-bgfx-examples/render"
+	"j4k.co/cgm/mat4"
+)
+
+// Skybox draws a cube map behind the scene, as seen from a camera.
+type Skybox struct {
+	Texture bgfx.Texture // cube map; the skybox does not own it
+
+	prog     bgfx.Program
+	mtx      [16]float32
+	uTexCube bgfx.Uniform
+	uMtx     *render.Uniform[[16]float32]
+}
+
+// NewSkybox returns a skybox of the cube map tex, loading its shaders
+// for formats.
+func NewSkybox(formats Formats, tex bgfx.Texture) *Skybox {
+	return NewSkyboxProgram(assets.LoadProgram("vs_hdr_skybox", formats.Shader("fs_hdr_skybox")), tex)
+}
+
+// NewSkyboxProgram returns a skybox of the cube map tex that draws with
+// prog instead, which takes u_texCube and u_mtx as fs_hdr_skybox does.
+// The skybox owns prog.
+func NewSkyboxProgram(prog bgfx.Program, tex bgfx.Texture) *Skybox {
+	return &Skybox{
+		Texture:  tex,
+		prog:     prog,
+		mtx:      mat4.Identity(),
+		uTexCube: bgfx.CreateUniform("u_texCube", bgfx.Uniform1i, 1),
+		uMtx:     render.NewUniform[[16]float32]("u_mtx", 1),
+	}
+}
+
+// SetCamera points the skybox the way a camera with the view and
+// projection matrices looks. Only the view's rotation matters.
+func (s *Skybox) SetCamera(view, proj [16]float32) {
+	s.mtx = SkyboxMatrix(view, proj)
+}
+
+// SkyboxMatrix returns the matrix that takes a point on the screen, x and
+// y in [-1, 1] and z 1, to the world space direction through it.
+func SkyboxMatrix(view, proj [16]float32) [16]float32 {
+	view[12], view[13], view[14] = 0, 0, 0
+	return mat4.Mul(mat4.Inv(view), mat4.Scale(1/proj[0], 1/proj[5], 1))
+}
+
+// AddPass adds a pass to g that clears out, with its depth, to
+// clearColor and draws the sky into it.
+func (s *Skybox) AddPass(g *graph.Graph, out *graph.Target, clearColor uint32) *graph.Pass {
+	return g.AddPass(&graph.Pass{
+		Name:       "skybox",
+		Output:     out,
+		Clear:      bgfx.ClearColor | bgfx.ClearDepth,
+		ClearColor: clearColor,
+		Execute: func(c *graph.Context) {
+			s.uMtx.Set(s.mtx)
+			s.BindTexture(0)
+			c.QuadOrigin(s.prog, bgfx.StateRGBWrite|bgfx.StateAlphaWrite, true)
+		},
+	})
+}
+
+// BindTexture binds the cube map to stage as u_texCube for the next draw,
+// such as a mesh that reflects the sky.
+func (s *Skybox) BindTexture(stage uint8) {
+	bgfx.SetTexture(stage, s.uTexCube, s.Texture)
+}
+
+// Destroy destroys the skybox's program and uniforms, but not its
+// texture.
+func (s *Skybox) Destroy() {
+	bgfx.DestroyProgram(s.prog)
+	bgfx.DestroyUniform(s.uTexCube)
+	s.uMtx.Destroy()
+}
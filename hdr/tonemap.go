@@ -0,0 +1,171 @@
+/*
+Package hdr holds the parts of a high dynamic range pipeline that are
+shared between examples: the choice of render target formats, a bloom
+chain, tonemapping and eye adaptation settings, and the controls to
+tune them at runtime.
+*/
+package hdr
+
+import (
+	"math"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/example"
+)
+
+// Operator is a tonemapping operator, which maps scene luminance to what
+// the display can show.
+type Operator int
+
+const (
+	Reinhard         Operator = iota // L/(1+L) on luminance
+	ReinhardExtended                 // Reinhard with a white point
+	ACES                             // Narkowicz's fit of the ACES filmic curve
+	Uncharted2                       // Hable's filmic curve
+	AgX                              // Sobotka's AgX, fitted
+	NumOperators
+)
+
+func (op Operator) String() string {
+	switch op {
+	case Reinhard:
+		return "Reinhard"
+	case ReinhardExtended:
+		return "extended Reinhard"
+	case ACES:
+		return "ACES filmic"
+	case Uncharted2:
+		return "Uncharted 2"
+	case AgX:
+		return "AgX"
+	}
+	return "unknown"
+}
+
+// ExposureMode is how the exposure is chosen.
+type ExposureMode int
+
+const (
+	// ExposureAuto scales the scene so that its average luminance, from
+	// the luminance chain, maps to middle gray.
+	ExposureAuto ExposureMode = iota
+	// ExposureManual scales the scene by a fixed exposure only.
+	ExposureManual
+)
+
+func (m ExposureMode) String() string {
+	if m == ExposureManual {
+		return "manual"
+	}
+	return "auto"
+}
+
+// Tonemap is the settings of the tonemapping stage, as used by the
+// fs_hdr_tonemap_ops shader.
+type Tonemap struct {
+	Operator Operator
+	Exposure ExposureMode
+
+	// EV is the exposure in stops, on top of auto exposure if it is on.
+	EV float32
+
+	MiddleGray float32 // that the average luminance maps to
+	White      float32 // luminance that maps to white with ReinhardExtended
+
+	// Adaptation is how quickly auto exposure follows the scene, for
+	// the adapt pass's u_adapt. It has no effect on manual exposure.
+	Adaptation Adaptation
+}
+
+// DefaultTonemap returns the settings bgfx's HDR example uses, with eye
+// adaptation that is quicker toward brighter scenes.
+func DefaultTonemap() Tonemap {
+	return Tonemap{
+		Operator:   ReinhardExtended,
+		Exposure:   ExposureAuto,
+		MiddleGray: 0.18,
+		White:      1.1,
+		Adaptation: Adaptation{Up: 1, Down: 0.37},
+	}
+}
+
+// Params returns the value of u_tonemap: middle gray and white squared.
+func (t *Tonemap) Params() [4]float32 {
+	return [4]float32{t.MiddleGray, t.White * t.White, 0, 0}
+}
+
+// OperatorParams returns the value of u_tonemapOp: the operator, the
+// exposure scale, and 1 for auto exposure or 0 for manual.
+func (t *Tonemap) OperatorParams() [4]float32 {
+	var auto float32
+	if t.Exposure == ExposureAuto {
+		auto = 1
+	}
+	return [4]float32{float32(t.Operator), ExposureScale(t.EV), auto, 0}
+}
+
+const (
+	evPerSecond  = 2 // while - or = is held
+	minEV, maxEV = -8, 8
+	speedStep    = 1.25 // per press of [ or ]
+	minSpeed     = 0.01
+	maxSpeed     = 20.0
+)
+
+var operatorKeys = [NumOperators]example.Key{
+	example.Key1, example.Key2, example.Key3, example.Key4, example.Key5,
+}
+
+// Update changes the settings from the keyboard: Tab or 1 to 5 choose the
+// operator, M switches between auto and manual exposure, - and = lower and
+// raise the exposure, and [ and ] slow down and speed up adaptation.
+func (t *Tonemap) Update(app *example.Application) {
+	if app.KeyPressed(example.KeyTab) {
+		t.Operator = (t.Operator + 1) % NumOperators
+	}
+	for op, k := range operatorKeys {
+		if app.KeyPressed(k) {
+			t.Operator = Operator(op)
+		}
+	}
+	if app.KeyPressed(example.KeyM) {
+		t.Exposure = 1 - t.Exposure
+	}
+	t.EV = clamp(t.EV+app.Axis(example.KeyEqual, example.KeyMinus)*evPerSecond*app.DeltaTime, minEV, maxEV)
+	a := &t.Adaptation
+	if app.KeyPressed(example.KeyLeftBracket) {
+		a.Up /= speedStep
+		a.Down /= speedStep
+	}
+	if app.KeyPressed(example.KeyRightBracket) {
+		a.Up *= speedStep
+		a.Down *= speedStep
+	}
+	a.Up = clamp(a.Up, minSpeed, maxSpeed)
+	a.Down = clamp(a.Down, minSpeed, maxSpeed)
+}
+
+// Draw prints the settings and their keys to the debug text, starting
+// at row y. It uses three rows.
+func (t *Tonemap) Draw(y int) {
+	bgfx.DebugTextPrintf(0, y, 0x0f, "Tonemap: %-17s [Tab, 1-5]", t.Operator)
+	bgfx.DebugTextPrintf(0, y+1, 0x0f, "Exposure: %-6s %+5.2f EV  [M, -/=]", t.Exposure, t.EV)
+	bgfx.DebugTextPrintf(0, y+2, 0x0f, "Adaptation: up %.2f/s, down %.2f/s  [[/]]",
+		t.Adaptation.Up, t.Adaptation.Down)
+}
+
+// ExposureScale returns what an exposure of ev stops scales the scene
+// by.
+func ExposureScale(ev float32) float32 {
+	return float32(math.Exp2(float64(ev)))
+}
+
+func clamp(x, min, max float32) float32 {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
@@ -0,0 +1,347 @@
+package hdrimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// EXR pixel types.
+const (
+	exrUint = iota
+	exrHalf
+	exrFloat
+)
+
+// EXR compression methods. Only those up to PIZ are supported.
+const (
+	exrNone = iota
+	exrRLE
+	exrZIPS
+	exrZIP
+	exrPIZ
+)
+
+var exrCompressionNames = []string{"none", "RLE", "ZIPS", "ZIP", "PIZ", "PXR24", "B44", "B44A", "DWAA", "DWAB"}
+
+type exrChannel struct {
+	name      string
+	pixelType int32
+}
+
+// size returns the size in bytes of one of the channel's samples.
+func (c exrChannel) size() int {
+	if c.pixelType == exrHalf {
+		return 2
+	}
+	return 4
+}
+
+// maxChunkSize is the largest uncompressed chunk DecodeEXR accepts. A
+// 65536 pixel wide block of 32 scanlines of RGBA floats fits.
+const maxChunkSize = 1 << 26
+
+type exrHeader struct {
+	channels         []exrChannel // in the file's order, by name
+	compression      int
+	xMin, yMin       int // of the data window
+	width, height    int
+	tiled            bool
+	tileW, tileH     int
+	lines            int // per scanline block
+	bytesPerPixel    int // of all channels
+	rgba             [4]int
+	offsetTableStart int
+}
+
+// DecodeEXR decodes a single part OpenEXR image, in scanlines or tiles,
+// uncompressed or compressed with RLE, ZIP or PIZ. The image covers the
+// data window. Its R, G, B and A channels are used, or a luminance
+// channel Y for gray images; alpha is 1 if there is none. Only the most
+// detailed level of a mipmapped or ripmapped image is read.
+func DecodeEXR(r io.Reader) (*Image, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	h, err := exrReadHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	var numChunks, chunkW, chunkH, chunkHeader int
+	if h.tiled {
+		nx := (h.width + h.tileW - 1) / h.tileW
+		ny := (h.height + h.tileH - 1) / h.tileH
+		numChunks, chunkW, chunkH = nx*ny, minInt(h.tileW, h.width), minInt(h.tileH, h.height)
+		chunkHeader = 20
+	} else {
+		numChunks = (h.height + h.lines - 1) / h.lines
+		chunkW, chunkH = h.width, minInt(h.lines, h.height)
+		chunkHeader = 8
+	}
+	// The offsets of the chunks of the first level come first, and
+	// each chunk has at least its header. Check the file is big enough
+	// for them before allocating anything the header asked for.
+	table := data[h.offsetTableStart:]
+	if len(table)/(8+chunkHeader) < numChunks {
+		return nil, errors.New("hdrimage: exr: file too short for its chunks")
+	}
+	if chunkW*chunkH*h.bytesPerPixel > maxChunkSize {
+		return nil, fmt.Errorf("hdrimage: exr: chunks of %dx%d pixels of %d bytes are too large",
+			chunkW, chunkH, h.bytesPerPixel)
+	}
+	buf := make([]byte, chunkW*chunkH*h.bytesPerPixel)
+	m := New(h.width, h.height)
+	for i := 0; i < numChunks; i++ {
+		off := binary.LittleEndian.Uint64(table[i*8:])
+		if off == 0 || off >= uint64(len(data)) {
+			return nil, fmt.Errorf("hdrimage: exr: bad offset for chunk %d", i)
+		}
+		if err := h.readChunk(m, data[off:], buf); err != nil {
+			return nil, fmt.Errorf("hdrimage: exr: chunk %d: %v", i, err)
+		}
+	}
+	return m, nil
+}
+
+func exrReadHeader(data []byte) (*exrHeader, error) {
+	bad := func(what string) error {
+		return errors.New("hdrimage: exr: " + what)
+	}
+	if len(data) < 8 || binary.LittleEndian.Uint32(data) != 20000630 {
+		return nil, bad("not an OpenEXR file")
+	}
+	version := binary.LittleEndian.Uint32(data[4:])
+	if version&0xff != 2 {
+		return nil, bad(fmt.Sprintf("unsupported version %d", version&0xff))
+	}
+	if version&0x1800 != 0 {
+		return nil, bad("multipart and deep images are not supported")
+	}
+	h := &exrHeader{tiled: version&0x200 != 0, rgba: [4]int{-1, -1, -1, -1}}
+	var (
+		p            = data[8:]
+		haveChannels bool
+		haveWindow   bool
+		y            = -1
+	)
+	for {
+		name, ok := cstring(&p)
+		if !ok {
+			return nil, bad("header truncated")
+		}
+		if name == "" {
+			break
+		}
+		typ, ok := cstring(&p)
+		if !ok || len(p) < 4 {
+			return nil, bad("header truncated")
+		}
+		size := int(binary.LittleEndian.Uint32(p))
+		p = p[4:]
+		if size < 0 || size > len(p) {
+			return nil, bad("attribute " + name + " truncated")
+		}
+		v := p[:size]
+		p = p[size:]
+		switch {
+		case name == "channels" && typ == "chlist":
+			for {
+				cname, ok := cstring(&v)
+				if !ok {
+					return nil, bad("channel list truncated")
+				}
+				if cname == "" {
+					break
+				}
+				if len(v) < 16 {
+					return nil, bad("channel list truncated")
+				}
+				c := exrChannel{cname, int32(binary.LittleEndian.Uint32(v))}
+				xs := binary.LittleEndian.Uint32(v[8:])
+				ys := binary.LittleEndian.Uint32(v[12:])
+				v = v[16:]
+				if c.pixelType < exrUint || c.pixelType > exrFloat {
+					return nil, bad("channel " + cname + " has an unknown pixel type")
+				}
+				if xs != 1 || ys != 1 {
+					return nil, bad("subsampled channels are not supported")
+				}
+				i := len(h.channels)
+				switch cname {
+				case "R":
+					h.rgba[0] = i
+				case "G":
+					h.rgba[1] = i
+				case "B":
+					h.rgba[2] = i
+				case "A":
+					h.rgba[3] = i
+				case "Y":
+					y = i
+				}
+				h.channels = append(h.channels, c)
+				h.bytesPerPixel += c.size()
+			}
+			haveChannels = true
+		case name == "compression" && typ == "compression" && size == 1:
+			h.compression = int(v[0])
+		case name == "dataWindow" && typ == "box2i" && size == 16:
+			xMin := int32(binary.LittleEndian.Uint32(v))
+			yMin := int32(binary.LittleEndian.Uint32(v[4:]))
+			xMax := int32(binary.LittleEndian.Uint32(v[8:]))
+			yMax := int32(binary.LittleEndian.Uint32(v[12:]))
+			h.xMin, h.yMin = int(xMin), int(yMin)
+			h.width, h.height = int(xMax)-int(xMin)+1, int(yMax)-int(yMin)+1
+			haveWindow = true
+		case name == "tiles" && typ == "tiledesc" && size == 9:
+			h.tileW = int(binary.LittleEndian.Uint32(v))
+			h.tileH = int(binary.LittleEndian.Uint32(v[4:]))
+		}
+	}
+	switch {
+	case !haveChannels:
+		return nil, bad("no channel list")
+	case !haveWindow:
+		return nil, bad("no data window")
+	case h.width <= 0 || h.height <= 0 || h.width > 1<<16 || h.height > 1<<16 || h.width*h.height > maxPixels:
+		return nil, bad(fmt.Sprintf("unsupported size %dx%d", h.width, h.height))
+	case h.tiled && (h.tileW <= 0 || h.tileH <= 0 || h.tileW > 1<<16 || h.tileH > 1<<16):
+		return nil, bad("bad tile size")
+	case h.compression > exrPIZ:
+		name := "unknown"
+		if h.compression < len(exrCompressionNames) {
+			name = exrCompressionNames[h.compression]
+		}
+		return nil, bad(name + " compression is not supported")
+	}
+	if h.rgba[0] < 0 && h.rgba[1] < 0 && h.rgba[2] < 0 {
+		if y < 0 {
+			return nil, bad("no R, G, B or Y channels")
+		}
+		h.rgba[0], h.rgba[1], h.rgba[2] = y, y, y
+	}
+	h.lines = map[int]int{exrNone: 1, exrRLE: 1, exrZIPS: 1, exrZIP: 16, exrPIZ: 32}[h.compression]
+	h.offsetTableStart = len(data) - len(p)
+	return h, nil
+}
+
+// cstring returns the null terminated string at the start of *p, and
+// advances *p past it.
+func cstring(p *[]byte) (string, bool) {
+	i := bytes.IndexByte(*p, 0)
+	if i < 0 {
+		return "", false
+	}
+	s := string((*p)[:i])
+	*p = (*p)[i+1:]
+	return s, true
+}
+
+// readChunk decodes a scanline block or tile into m, using buf for its
+// uncompressed data.
+func (h *exrHeader) readChunk(m *Image, chunk, buf []byte) error {
+	var x0, y0, w, lines int
+	if h.tiled {
+		if len(chunk) < 20 {
+			return errors.New("truncated")
+		}
+		tx := int(int32(binary.LittleEndian.Uint32(chunk)))
+		ty := int(int32(binary.LittleEndian.Uint32(chunk[4:])))
+		lx := binary.LittleEndian.Uint32(chunk[8:])
+		ly := binary.LittleEndian.Uint32(chunk[12:])
+		if lx != 0 || ly != 0 {
+			return errors.New("tile of a lower level where the first was expected")
+		}
+		x0, y0 = tx*h.tileW, ty*h.tileH
+		if tx < 0 || ty < 0 || x0 >= h.width || y0 >= h.height {
+			return errors.New("tile outside the data window")
+		}
+		w = minInt(h.tileW, h.width-x0)
+		lines = minInt(h.tileH, h.height-y0)
+		chunk = chunk[16:]
+	} else {
+		if len(chunk) < 8 {
+			return errors.New("truncated")
+		}
+		y0 = int(int32(binary.LittleEndian.Uint32(chunk))) - h.yMin
+		if y0 < 0 || y0 >= h.height || y0%h.lines != 0 {
+			return errors.New("scanline block outside the data window")
+		}
+		w = h.width
+		lines = minInt(h.lines, h.height-y0)
+		chunk = chunk[4:]
+	}
+	size := int(binary.LittleEndian.Uint32(chunk))
+	chunk = chunk[4:]
+	if size < 0 || size > len(chunk) {
+		return errors.New("truncated")
+	}
+	chunk = chunk[:size]
+	raw := buf[:w*lines*h.bytesPerPixel]
+	if err := h.decompress(raw, chunk, w, lines); err != nil {
+		return err
+	}
+
+	// Each line holds all of its samples of one channel, then the next.
+	for y := 0; y < lines; y++ {
+		line := raw[y*w*h.bytesPerPixel:]
+		row := m.Pix[((y0+y)*m.Width+x0)*4:]
+		for x := 0; x < w; x++ {
+			row[x*4+3] = 1
+		}
+		for i, c := range h.channels {
+			for k, ci := range h.rgba {
+				if ci != i {
+					continue
+				}
+				for x := 0; x < w; x++ {
+					row[x*4+k] = exrSample(line, c.pixelType, x)
+				}
+			}
+			line = line[w*c.size():]
+		}
+	}
+	return nil
+}
+
+// exrSample returns sample x of a line of one channel's samples.
+func exrSample(line []byte, pixelType int32, x int) float32 {
+	switch pixelType {
+	case exrHalf:
+		return Float32(binary.LittleEndian.Uint16(line[x*2:]))
+	case exrFloat:
+		return math.Float32frombits(binary.LittleEndian.Uint32(line[x*4:]))
+	}
+	return float32(binary.LittleEndian.Uint32(line[x*4:]))
+}
+
+// decompress decompresses the data of a chunk of w by lines pixels into
+// raw, which is exactly its uncompressed size.
+func (h *exrHeader) decompress(raw, data []byte, w, lines int) error {
+	// Data that wouldn't get smaller is stored as is.
+	if len(data) == len(raw) {
+		copy(raw, data)
+		return nil
+	}
+	switch h.compression {
+	case exrNone:
+		return errors.New("uncompressed data has the wrong size")
+	case exrRLE:
+		return exrUnRLE(raw, data)
+	case exrZIPS, exrZIP:
+		return exrUnzip(raw, data)
+	}
+	return exrUnPIZ(raw, data, h.channels, w, lines)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,197 @@
+package hdrimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// The fixtures in testdata are 19 by 35 pixel images of testPixel, with
+// R and G as halves, B as floats and A as unsigned ints, in scanlines
+// and in 16 by 8 tiles, with each compression. Their data window starts
+// at 3, -2. They were written by an encoder that follows the OpenEXR
+// library.
+var exrFixtures = []string{"none", "rle", "zips", "zip", "piz"}
+
+// testPixel returns channel c of the pixel at x, y of the fixtures:
+// flat regions, gradients and larger values.
+func testPixel(x, y, c int) float64 {
+	if (x/8+y/8)%3 == 0 {
+		return []float64{1, 0.5, 0.25, 1}[c]
+	}
+	fx, fy := float64(x), float64(y)
+	return []float64{fx * 0.37, fy*1.7 + 0.01*fx, float64(x*y%17) * 3.5, 0.5 + 0.01*fx}[c]
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name+".exr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeEXR(t *testing.T) {
+	for _, comp := range exrFixtures {
+		for _, name := range []string{comp, comp + "_tiled"} {
+			m, err := DecodeEXR(bytes.NewReader(readFixture(t, name)))
+			if err != nil {
+				t.Errorf("%s: %v", name, err)
+				continue
+			}
+			if m.Width != 19 || m.Height != 35 {
+				t.Errorf("%s: size %dx%d", name, m.Width, m.Height)
+				continue
+			}
+			errors := 0
+			for y := 0; y < m.Height; y++ {
+				for x := 0; x < m.Width; x++ {
+					want := [4]float32{
+						Float32(Float16(float32(testPixel(x, y, 0)))),
+						Float32(Float16(float32(testPixel(x, y, 1)))),
+						float32(testPixel(x, y, 2)),
+						float32(math.Trunc(testPixel(x, y, 3))),
+					}
+					if got := m.At(x, y); got != want && errors < 5 {
+						t.Errorf("%s: pixel %d,%d is %v, want %v", name, x, y, got, want)
+						errors++
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeEXRTruncated(t *testing.T) {
+	for _, comp := range exrFixtures {
+		for _, name := range []string{comp, comp + "_tiled"} {
+			data := readFixture(t, name)
+			for n := 0; n < len(data); n += 7 {
+				if _, err := DecodeEXR(bytes.NewReader(data[:n])); err == nil {
+					t.Errorf("%s: no error truncated to %d of %d bytes", name, n, len(data))
+				}
+			}
+		}
+	}
+}
+
+// Corrupt data may decode to wrong pixels, but must not panic. Every
+// byte of the headers and offset tables is changed, and some of the
+// chunks'.
+func TestDecodeEXRCorrupt(t *testing.T) {
+	for _, comp := range exrFixtures {
+		for _, name := range []string{comp, comp + "_tiled"} {
+			data := readFixture(t, name)
+			h, err := exrReadHeader(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The first chunk follows the offset table.
+			chunks := int(binary.LittleEndian.Uint64(data[h.offsetTableStart:]))
+			bad := make([]byte, len(data))
+			for i := 0; i < len(data); i++ {
+				if i > chunks && i%13 != 0 {
+					continue
+				}
+				for _, x := range []byte{0x01, 0x80, 0xff} {
+					copy(bad, data)
+					bad[i] ^= x
+					func() {
+						defer func() {
+							if r := recover(); r != nil {
+								t.Fatalf("%s: byte %d xor %#x: panic: %v", name, i, x, r)
+							}
+						}()
+						DecodeEXR(bytes.NewReader(bad))
+					}()
+				}
+			}
+		}
+	}
+}
+
+// exrTestHeader returns a header for an image of RGB halves with the
+// given data window, compression and tile size, if any.
+func exrTestHeader(w, h int, compression byte, tileW, tileH int) []byte {
+	var b bytes.Buffer
+	attr := func(name, typ string, v []byte) {
+		fmt.Fprintf(&b, "%s\x00%s\x00", name, typ)
+		binary.Write(&b, binary.LittleEndian, uint32(len(v)))
+		b.Write(v)
+	}
+	version := uint32(2)
+	if tileW > 0 {
+		version |= 0x200
+	}
+	binary.Write(&b, binary.LittleEndian, [2]uint32{20000630, version})
+	var chlist bytes.Buffer
+	for _, c := range []string{"B", "G", "R"} {
+		chlist.WriteString(c + "\x00")
+		binary.Write(&chlist, binary.LittleEndian, [4]int32{exrHalf, 0, 1, 1})
+	}
+	chlist.WriteByte(0)
+	attr("channels", "chlist", chlist.Bytes())
+	attr("compression", "compression", []byte{compression})
+	window := new(bytes.Buffer)
+	binary.Write(window, binary.LittleEndian, [4]int32{0, 0, int32(w - 1), int32(h - 1)})
+	attr("dataWindow", "box2i", window.Bytes())
+	if tileW > 0 {
+		tiles := new(bytes.Buffer)
+		binary.Write(tiles, binary.LittleEndian, [2]uint32{uint32(tileW), uint32(tileH)})
+		tiles.WriteByte(0)
+		attr("tiles", "tiledesc", tiles.Bytes())
+	}
+	b.WriteByte(0)
+	return b.Bytes()
+}
+
+func TestDecodeEXRSizes(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		// Headers that ask for more than maxPixels, or chunks that the
+		// file is too short to hold, are rejected before allocating.
+		{"too large", exrTestHeader(1<<16, 1<<16, exrZIP, 0, 0)},
+		{"no chunks", exrTestHeader(8192, 8192, exrZIP, 0, 0)},
+		{"no tiles", exrTestHeader(8192, 8192, exrNone, 64, 64)},
+		{"huge tiles", append(exrTestHeader(8192, 8192, exrNone, 1<<16, 1<<16), make([]byte, 28)...)},
+		{"bad offset", append(exrTestHeader(2, 1, exrNone, 0, 0), 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := DecodeEXR(bytes.NewReader(tt.data))
+		runtime.ReadMemStats(&after)
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+		if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+			t.Errorf("%s: allocated %d bytes", tt.name, n)
+		}
+	}
+
+	// A file that does hold its chunks decodes.
+	data := exrTestHeader(2, 1, exrNone, 0, 0)
+	off := uint64(len(data) + 8)
+	data = binary.LittleEndian.AppendUint64(data, off)
+	data = append(data, 0, 0, 0, 0, 12, 0, 0, 0)
+	for _, v := range []uint16{0x3c00, 0x4000, 0x3800, 0x3400, 0, 0x3c00} { // B, G, R of 2 pixels
+		data = binary.LittleEndian.AppendUint16(data, v)
+	}
+	m, err := DecodeEXR(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.At(0, 0), [4]float32{0, 0.5, 1, 1}; got != want {
+		t.Errorf("pixel 0 is %v, want %v", got, want)
+	}
+	if got, want := m.At(1, 0), [4]float32{1, 0.25, 2, 1}; got != want {
+		t.Errorf("pixel 1 is %v, want %v", got, want)
+	}
+}
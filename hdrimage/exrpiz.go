@@ -0,0 +1,402 @@
+package hdrimage
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// PIZ compression maps the 16 bit words of a chunk through a table of
+// the values that occur, transforms each channel with a Haar wavelet,
+// and Huffman codes the result. This follows ImfPizCompressor.cpp,
+// ImfWav.cpp and ImfHuf.cpp of the OpenEXR library.
+
+var errPIZ = errors.New("bad PIZ data")
+
+// exrUnPIZ decompresses PIZ data of w by lines pixels of channels into
+// raw.
+func exrUnPIZ(raw, data []byte, channels []exrChannel, w, lines int) error {
+	if len(data) < 4 {
+		return errPIZ
+	}
+	var bitmap [1 << 16 / 8]byte
+	minNonZero := int(binary.LittleEndian.Uint16(data))
+	maxNonZero := int(binary.LittleEndian.Uint16(data[2:]))
+	data = data[4:]
+	if minNonZero <= maxNonZero {
+		n := maxNonZero - minNonZero + 1
+		if maxNonZero >= len(bitmap) || n > len(data) {
+			return errPIZ
+		}
+		copy(bitmap[minNonZero:], data[:n])
+		data = data[n:]
+	}
+	lut, maxValue := pizReverseLUT(&bitmap)
+	if len(data) < 4 {
+		return errPIZ
+	}
+	length := int(int32(binary.LittleEndian.Uint32(data)))
+	data = data[4:]
+	if length < 0 || length > len(data) {
+		return errPIZ
+	}
+	words := make([]uint16, len(raw)/2)
+	if err := hufDecompress(data[:length], words); err != nil {
+		return err
+	}
+
+	// The words of each channel are together, and samples of 32 bits
+	// are two words that are transformed separately.
+	starts := make([]int, len(channels))
+	start := 0
+	for i, c := range channels {
+		size := c.size() / 2
+		n := w * lines * size
+		for j := 0; j < size; j++ {
+			wav2Decode(words[start+j:start+n], w, size, lines, w*size, maxValue)
+		}
+		starts[i] = start
+		start += n
+	}
+	for i, v := range words {
+		words[i] = lut[v]
+	}
+	out := raw
+	for y := 0; y < lines; y++ {
+		for i, c := range channels {
+			n := w * c.size() / 2
+			for _, v := range words[starts[i] : starts[i]+n] {
+				binary.LittleEndian.PutUint16(out, v)
+				out = out[2:]
+			}
+			starts[i] += n
+		}
+	}
+	return nil
+}
+
+// pizReverseLUT returns the table that maps the indices PIZ data is
+// stored as back to the values set in bitmap, and the largest index.
+func pizReverseLUT(bitmap *[1 << 16 / 8]byte) (lut []uint16, maxValue uint16) {
+	lut = make([]uint16, 1<<16)
+	k := 0
+	for i := 0; i < 1<<16; i++ {
+		if i == 0 || bitmap[i>>3]&(1<<uint(i&7)) != 0 {
+			lut[k] = uint16(i)
+			k++
+		}
+	}
+	return lut, uint16(k - 1)
+}
+
+// wav2Decode inverts the 2D wavelet transform of nx by ny values of in,
+// ox apart in x and oy apart in y. Values are up to mx; below 1<<14 a
+// transform without modular arithmetic is used.
+func wav2Decode(in []uint16, nx, ox, ny, oy int, mx uint16) {
+	dec := wdec16
+	if mx < 1<<14 {
+		dec = wdec14
+	}
+	n := nx
+	if ny < n {
+		n = ny
+	}
+	p := 1
+	for p <= n {
+		p <<= 1
+	}
+	p >>= 1
+	p2 := p
+	p >>= 1
+
+	// From the coarsest level to the finest.
+	for p >= 1 {
+		ey := oy * (ny - p2)
+		oy1, oy2 := oy*p, oy*p2
+		ox1, ox2 := ox*p, ox*p2
+		py := 0
+		for ; py <= ey; py += oy2 {
+			px := py
+			ex := py + ox*(nx-p2)
+			for ; px <= ex; px += ox2 {
+				p01 := px + ox1
+				p10 := px + oy1
+				p11 := p10 + ox1
+				i00, i10 := dec(in[px], in[p10])
+				i01, i11 := dec(in[p01], in[p11])
+				in[px], in[p01] = dec(i00, i01)
+				in[p10], in[p11] = dec(i10, i11)
+			}
+			// An odd column.
+			if nx&p != 0 {
+				p10 := px + oy1
+				in[px], in[p10] = dec(in[px], in[p10])
+			}
+		}
+		// An odd line.
+		if ny&p != 0 {
+			px := py
+			ex := py + ox*(nx-p2)
+			for ; px <= ex; px += ox2 {
+				p01 := px + ox1
+				in[px], in[p01] = dec(in[px], in[p01])
+			}
+		}
+		p2 = p
+		p >>= 1
+	}
+}
+
+// wdec14 inverts the wavelet transform of a pair of values of 14 bits.
+func wdec14(l, h uint16) (a, b uint16) {
+	hi := int(int16(h))
+	ai := int(int16(l)) + hi&1 + hi>>1
+	return uint16(int16(ai)), uint16(int16(ai - hi))
+}
+
+// wdec16 inverts the wavelet transform of a pair of values of 16 bits,
+// modulo 1<<16.
+func wdec16(l, h uint16) (a, b uint16) {
+	m, d := int(l), int(h)
+	bb := (m - d>>1) & 0xffff
+	aa := (d + bb - 0x8000) & 0xffff
+	return uint16(aa), uint16(bb)
+}
+
+const (
+	hufEncBits = 16
+	hufDecBits = 14 // of the codes that are looked up directly
+	hufEncSize = 1<<hufEncBits + 1
+	hufDecSize = 1 << hufDecBits
+	hufDecMask = hufDecSize - 1
+
+	// Code lengths from shortZeroRun on stand for runs of unused
+	// symbols; longZeroRun is followed by a byte of the run length.
+	shortZeroRun    = 59
+	longZeroRun     = 63
+	shortestLongRun = 2 + longZeroRun - shortZeroRun
+)
+
+// hufDec is an entry of the decoding table, indexed by the first
+// hufDecBits of a code.
+type hufDec struct {
+	len  int   // of the code, if it is short
+	lit  int   // symbol of the short code
+	long []int // symbols of longer codes that start with the index
+}
+
+// bitReader reads bits from the most significant end of bytes.
+type bitReader struct {
+	c  uint64
+	lc int // bits in c
+	in []byte
+}
+
+func (br *bitReader) fill() bool {
+	if len(br.in) == 0 {
+		return false
+	}
+	br.c = br.c<<8 | uint64(br.in[0])
+	br.in = br.in[1:]
+	br.lc += 8
+	return true
+}
+
+func (br *bitReader) bits(n int) (uint64, bool) {
+	for br.lc < n {
+		if !br.fill() {
+			return 0, false
+		}
+	}
+	br.lc -= n
+	return br.c >> uint(br.lc) & (1<<uint(n) - 1), true
+}
+
+// hufDecompress decodes Huffman coded data into out, which must be
+// filled exactly.
+func hufDecompress(data []byte, out []uint16) error {
+	if len(data) < 20 {
+		return errPIZ
+	}
+	im := int(binary.LittleEndian.Uint32(data))
+	iM := int(binary.LittleEndian.Uint32(data[4:]))
+	nBits := int(binary.LittleEndian.Uint32(data[12:]))
+	if im < 0 || iM < im || iM >= hufEncSize {
+		return errPIZ
+	}
+	hcode := make([]uint64, hufEncSize)
+	data, err := hufUnpackEncTable(data[20:], im, iM, hcode)
+	if err != nil {
+		return err
+	}
+	if nBits < 0 || nBits > 8*len(data) {
+		return errPIZ
+	}
+	dec, err := hufBuildDecTable(hcode, im, iM)
+	if err != nil {
+		return err
+	}
+	return hufDecode(hcode, dec, data[:(nBits+7)/8], nBits, iM, out)
+}
+
+// hufUnpackEncTable reads the code lengths of symbols im to iM into
+// hcode, makes them into canonical codes, and returns the data after
+// the table.
+func hufUnpackEncTable(data []byte, im, iM int, hcode []uint64) ([]byte, error) {
+	br := bitReader{in: data}
+	for ; im <= iM; im++ {
+		l, ok := br.bits(6)
+		if !ok {
+			return nil, errPIZ
+		}
+		hcode[im] = l
+		zerun := 0
+		if l == longZeroRun {
+			n, ok := br.bits(8)
+			if !ok {
+				return nil, errPIZ
+			}
+			zerun = int(n) + shortestLongRun
+		} else if l >= shortZeroRun {
+			zerun = int(l) - shortZeroRun + 2
+		}
+		if zerun > 0 {
+			if im+zerun > iM+1 {
+				return nil, errPIZ
+			}
+			for i := 0; i < zerun; i++ {
+				hcode[im+i] = 0
+			}
+			im += zerun - 1
+		}
+	}
+	hufCanonicalCodeTable(hcode)
+	return br.in, nil
+}
+
+// hufCanonicalCodeTable replaces the code lengths in hcode with the
+// canonical codes of those lengths, shifted left by 6, or'd with the
+// lengths.
+func hufCanonicalCodeTable(hcode []uint64) {
+	var n [59]uint64
+	for _, l := range hcode {
+		n[l]++
+	}
+	var c uint64
+	for i := 58; i > 0; i-- {
+		nc := (c + n[i]) >> 1
+		n[i] = c
+		c = nc
+	}
+	for i, l := range hcode {
+		if l > 0 {
+			hcode[i] = l | n[l]<<6
+			n[l]++
+		}
+	}
+}
+
+func hufBuildDecTable(hcode []uint64, im, iM int) ([]hufDec, error) {
+	dec := make([]hufDec, hufDecSize)
+	for ; im <= iM; im++ {
+		c := hcode[im] >> 6
+		l := int(hcode[im] & 63)
+		if c>>uint(l) != 0 {
+			return nil, errPIZ
+		}
+		if l > hufDecBits {
+			pl := &dec[c>>uint(l-hufDecBits)]
+			if pl.len != 0 {
+				return nil, errPIZ
+			}
+			pl.long = append(pl.long, im)
+		} else if l > 0 {
+			base := int(c << uint(hufDecBits-l))
+			for i := 0; i < 1<<uint(hufDecBits-l); i++ {
+				pl := &dec[base+i]
+				if pl.len != 0 || pl.long != nil {
+					return nil, errPIZ
+				}
+				pl.len = l
+				pl.lit = im
+			}
+		}
+	}
+	return dec, nil
+}
+
+// hufDecode decodes nBits of in into out. The symbol rlc is followed by
+// a byte counting repeats of the last word.
+func hufDecode(hcode []uint64, dec []hufDec, in []byte, nBits, rlc int, out []uint16) error {
+	br := bitReader{in: in}
+	o := 0
+	put := func(sym int) error {
+		if sym != rlc {
+			if o >= len(out) {
+				return errPIZ
+			}
+			out[o] = uint16(sym)
+			o++
+			return nil
+		}
+		n, ok := br.bits(8)
+		if !ok || o == 0 || o+int(n) > len(out) {
+			return errPIZ
+		}
+		for s := out[o-1]; n > 0; n-- {
+			out[o] = s
+			o++
+		}
+		return nil
+	}
+	for br.fill() {
+		for br.lc >= hufDecBits {
+			pl := &dec[br.c>>uint(br.lc-hufDecBits)&hufDecMask]
+			if pl.len != 0 {
+				br.lc -= pl.len
+				if err := put(pl.lit); err != nil {
+					return err
+				}
+				continue
+			}
+			found := false
+			for _, sym := range pl.long {
+				l := int(hcode[sym] & 63)
+				for br.lc < l {
+					if !br.fill() {
+						break
+					}
+				}
+				if br.lc >= l && hcode[sym]>>6 == br.c>>uint(br.lc-l)&(1<<uint(l)-1) {
+					br.lc -= l
+					if err := put(sym); err != nil {
+						return err
+					}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return errPIZ
+			}
+		}
+	}
+
+	// The last codes are short, and padded to a byte.
+	pad := (8 - nBits) & 7
+	br.c >>= uint(pad)
+	br.lc -= pad
+	for br.lc > 0 {
+		pl := &dec[br.c<<uint(hufDecBits-br.lc)&hufDecMask]
+		if pl.len == 0 {
+			return errPIZ
+		}
+		br.lc -= pl.len
+		if err := put(pl.lit); err != nil {
+			return err
+		}
+	}
+	if o != len(out) {
+		return errPIZ
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package hdrimage
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+// exrUnRLE decompresses RLE data into raw. Runs of a repeated byte have
+// a count n of 0 to 127 and are n+1 long; -n is followed by n literal
+// bytes.
+func exrUnRLE(raw, data []byte) error {
+	tmp := make([]byte, 0, len(raw))
+	for len(data) > 0 {
+		n := int(int8(data[0]))
+		data = data[1:]
+		if n < 0 {
+			if -n > len(data) || len(tmp)-n > len(raw) {
+				return errors.New("bad RLE data")
+			}
+			tmp = append(tmp, data[:-n]...)
+			data = data[-n:]
+			continue
+		}
+		if len(data) == 0 || len(tmp)+n+1 > len(raw) {
+			return errors.New("bad RLE data")
+		}
+		for i := 0; i <= n; i++ {
+			tmp = append(tmp, data[0])
+		}
+		data = data[1:]
+	}
+	if len(tmp) != len(raw) {
+		return errors.New("RLE data is short")
+	}
+	exrUnpredict(raw, tmp)
+	return nil
+}
+
+// exrUnzip decompresses ZIP data, which is zlib, into raw.
+func exrUnzip(raw, data []byte) error {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	tmp := make([]byte, len(raw))
+	if _, err := io.ReadFull(zr, tmp); err != nil {
+		return err
+	}
+	exrUnpredict(raw, tmp)
+	return nil
+}
+
+// exrUnpredict undoes the preprocessing that RLE and ZIP compression do
+// to tmp, into raw: each byte is stored as the difference from the one
+// before, plus 128, and the bytes at even and odd offsets are split into
+// two halves.
+func exrUnpredict(raw, tmp []byte) {
+	for i := 1; i < len(tmp); i++ {
+		tmp[i] = tmp[i-1] + tmp[i] - 128
+	}
+	half := (len(tmp) + 1) / 2
+	for i := range raw {
+		if i%2 == 0 {
+			raw[i] = tmp[i/2]
+		} else {
+			raw[i] = tmp[half+i/2]
+		}
+	}
+}
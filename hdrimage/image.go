@@ -0,0 +1,160 @@
+/*
+Package hdrimage decodes high dynamic range images into floating point
+pixels, for environment maps and other HDR textures. It reads Radiance
+RGBE images and OpenEXR images in scanlines or tiles, uncompressed or
+compressed with RLE, ZIP or PIZ.
+*/
+package hdrimage
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Image is an RGBA image of linear float32 values, with rows from top to
+// bottom.
+type Image struct {
+	Width, Height int
+	Pix           []float32 // 4 per pixel
+}
+
+// maxPixels is the most pixels the decoders accept, 8192 by 8192, so
+// that a corrupt header can't make them allocate more than a gigabyte.
+const maxPixels = 1 << 26
+
+// New returns a black image of w by h pixels.
+func New(w, h int) *Image {
+	return &Image{Width: w, Height: h, Pix: make([]float32, w*h*4)}
+}
+
+// At returns the pixel at x, y.
+func (m *Image) At(x, y int) [4]float32 {
+	i := (y*m.Width + x) * 4
+	return [4]float32{m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3]}
+}
+
+// Set sets the pixel at x, y.
+func (m *Image) Set(x, y int, c [4]float32) {
+	i := (y*m.Width + x) * 4
+	copy(m.Pix[i:i+4], c[:])
+}
+
+// Sample returns the image bilinearly filtered at u, v in [0, 1], from
+// the top left. Coordinates wrap around horizontally and are clamped
+// vertically, as suits latitude-longitude maps.
+func (m *Image) Sample(u, v float32) [4]float32 {
+	x := u*float32(m.Width) - 0.5
+	y := v*float32(m.Height) - 0.5
+	x0 := int(math.Floor(float64(x)))
+	y0 := int(math.Floor(float64(y)))
+	fx, fy := x-float32(x0), y-float32(y0)
+	wrap := func(x int) int {
+		x %= m.Width
+		if x < 0 {
+			x += m.Width
+		}
+		return x
+	}
+	clamp := func(y int) int {
+		if y < 0 {
+			return 0
+		}
+		if y >= m.Height {
+			return m.Height - 1
+		}
+		return y
+	}
+	var (
+		c00 = m.At(wrap(x0), clamp(y0))
+		c10 = m.At(wrap(x0+1), clamp(y0))
+		c01 = m.At(wrap(x0), clamp(y0+1))
+		c11 = m.At(wrap(x0+1), clamp(y0+1))
+		c   [4]float32
+	)
+	for i := range c {
+		top := c00[i] + (c10[i]-c00[i])*fx
+		bottom := c01[i] + (c11[i]-c01[i])*fx
+		c[i] = top + (bottom-top)*fy
+	}
+	return c
+}
+
+// RGBA16F returns the pixels as half floats, for a texture of format
+// RGBA16F.
+func (m *Image) RGBA16F() []byte {
+	b := make([]byte, len(m.Pix)*2)
+	for i, f := range m.Pix {
+		binary.LittleEndian.PutUint16(b[i*2:], Float16(f))
+	}
+	return b
+}
+
+// RGBA32F returns the pixels as bytes, for a texture of format RGBA32F.
+func (m *Image) RGBA32F() []byte {
+	b := make([]byte, len(m.Pix)*4)
+	for i, f := range m.Pix {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(f))
+	}
+	return b
+}
+
+// Float16 returns f as an IEEE 754 half float, rounded to nearest even.
+// Values too large for a half become infinity.
+func Float16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23&0xff) - 127 + 15
+	mant := bits & 0x7fffff
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign
+	case bits>>23&0xff == 0xff: // infinity or NaN
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f:
+		return sign | 0x7c00
+	case exp <= 0:
+		// Denormal, or too small for one.
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		half := uint16(mant >> shift)
+		rem := mant & (1<<shift - 1)
+		mid := uint32(1) << (shift - 1)
+		if rem > mid || rem == mid && half&1 != 0 {
+			half++
+		}
+		return sign | half
+	}
+	half := uint16(exp)<<10 | uint16(mant>>13)
+	rem := mant & 0x1fff
+	if rem > 0x1000 || rem == 0x1000 && half&1 != 0 {
+		half++ // may carry into the exponent, which is still right
+	}
+	return sign | half
+}
+
+// Float32 returns the value of the half float h.
+func Float32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h >> 10 & 0x1f)
+	mant := uint32(h & 0x3ff)
+	switch {
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		f := float32(mant) / (1 << 24) // denormal
+		if sign != 0 {
+			return -f
+		}
+		return f
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
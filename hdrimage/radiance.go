@@ -0,0 +1,184 @@
+package hdrimage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DecodeRadiance decodes a Radiance RGBE image (.hdr or .pic), flat or
+// run length encoded. Alpha is 1.
+func DecodeRadiance(r io.Reader) (*Image, error) {
+	br := bufio.NewReader(r)
+	w, h, flipY, err := radianceHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	// The pixels grow as scanlines are read, rather than trusting the
+	// header with the whole allocation up front.
+	m := &Image{Width: w, Height: h}
+	line := make([]byte, w*4)
+	for y := 0; y < h; y++ {
+		if err := radianceScanline(br, line); err != nil {
+			return nil, fmt.Errorf("hdrimage: radiance scanline %d: %v", y, err)
+		}
+		for x := 0; x < w; x++ {
+			r, g, b := rgbe(line[x*4 : x*4+4])
+			m.Pix = append(m.Pix, r, g, b, 1)
+		}
+	}
+	if flipY {
+		tmp := make([]float32, w*4)
+		for y := 0; y < h/2; y++ {
+			top := m.Pix[y*w*4 : (y+1)*w*4]
+			bottom := m.Pix[(h-1-y)*w*4 : (h-y)*w*4]
+			copy(tmp, top)
+			copy(top, bottom)
+			copy(bottom, tmp)
+		}
+	}
+	return m, nil
+}
+
+// radianceHeader reads the header and resolution line. Only the usual
+// orientations, with rows along X, are supported.
+func radianceHeader(br *bufio.Reader) (w, h int, flipY bool, err error) {
+	bad := func(what string) error {
+		return errors.New("hdrimage: radiance: " + what)
+	}
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if !strings.HasPrefix(line, "#?") {
+		return 0, 0, false, bad("not a Radiance file")
+	}
+	for {
+		line, err = br.ReadString('\n')
+		if err != nil {
+			return 0, 0, false, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return 0, 0, false, bad("unsupported " + line)
+		}
+	}
+	line, err = br.ReadString('\n')
+	if err != nil {
+		return 0, 0, false, err
+	}
+	f := strings.Fields(line)
+	if len(f) != 4 || f[2] != "+X" || f[0] != "-Y" && f[0] != "+Y" {
+		return 0, 0, false, bad("unsupported resolution " + strings.TrimSpace(line))
+	}
+	h, err1 := strconv.Atoi(f[1])
+	w, err2 := strconv.Atoi(f[3])
+	if err1 != nil || err2 != nil || w <= 0 || h <= 0 {
+		return 0, 0, false, bad("bad resolution " + strings.TrimSpace(line))
+	}
+	if w > maxPixels || h > maxPixels || w*h > maxPixels {
+		return 0, 0, false, bad(fmt.Sprintf("unsupported size %dx%d", w, h))
+	}
+	return w, h, f[0] == "+Y", nil
+}
+
+// radianceScanline reads one scanline of RGBE pixels into line.
+func radianceScanline(br *bufio.Reader, line []byte) error {
+	w := len(line) / 4
+	head, err := br.Peek(4)
+	if err != nil {
+		return err
+	}
+	// Scanlines of 8 to 32767 pixels may be run length encoded one
+	// component at a time, marked by 2, 2 and the width.
+	if w < 8 || w > 0x7fff || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		return radianceFlat(br, line)
+	}
+	if int(head[2])<<8|int(head[3]) != w {
+		return errors.New("scanline width mismatch")
+	}
+	br.Discard(4)
+	for c := 0; c < 4; c++ {
+		for x := 0; x < w; {
+			n, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+			if n > 128 {
+				// A run of one value.
+				n -= 128
+				if x+int(n) > w {
+					return errors.New("run past end of scanline")
+				}
+				v, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				for i := 0; i < int(n); i++ {
+					line[(x+i)*4+c] = v
+				}
+			} else {
+				if n == 0 || x+int(n) > w {
+					return errors.New("bad run length")
+				}
+				for i := 0; i < int(n); i++ {
+					v, err := br.ReadByte()
+					if err != nil {
+						return err
+					}
+					line[(x+i)*4+c] = v
+				}
+			}
+			x += int(n)
+		}
+	}
+	return nil
+}
+
+// radianceFlat reads a scanline of whole pixels, with the old run length
+// encoding, where a pixel of 1, 1, 1 repeats the previous one.
+func radianceFlat(br *bufio.Reader, line []byte) error {
+	w := len(line) / 4
+	shift := uint(0)
+	for x := 0; x < w; {
+		p := line[x*4 : x*4+4]
+		if _, err := io.ReadFull(br, p); err != nil {
+			return err
+		}
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 {
+			if x == 0 {
+				return errors.New("repeat at start of scanline")
+			}
+			n := int(p[3]) << shift
+			if x+n > w {
+				return errors.New("repeat past end of scanline")
+			}
+			prev := line[(x-1)*4 : x*4]
+			for i := 0; i < n; i++ {
+				copy(line[(x+i)*4:], prev)
+			}
+			x += n
+			shift += 8
+			continue
+		}
+		shift = 0
+		x++
+	}
+	return nil
+}
+
+// rgbe returns the color of a pixel with a shared exponent.
+func rgbe(p []byte) (r, g, b float32) {
+	if p[3] == 0 {
+		return 0, 0, 0
+	}
+	f := float32(math.Ldexp(1, int(p[3])-(128+8)))
+	return float32(p[0]) * f, float32(p[1]) * f, float32(p[2]) * f
+}
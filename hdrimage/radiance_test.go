@@ -0,0 +1,138 @@
+package hdrimage
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// radianceTestPixels returns RGBE pixels of w by h, with runs of equal
+// pixels for the run length encodings to use.
+func radianceTestPixels(w, h int) [][]byte {
+	rows := make([][]byte, h)
+	for y := range rows {
+		row := make([]byte, w*4)
+		for x := 0; x < w; x++ {
+			v := byte(x / 3 * 40)
+			copy(row[x*4:], []byte{v, byte(200 - y*30), byte(y * 50), byte(128 + y)})
+		}
+		rows[y] = row
+	}
+	return rows
+}
+
+// encodeRadiance encodes rows of RGBE pixels as a Radiance file with
+// the given scanline encoding: "flat", "old" run length encoding with
+// repeat pixels, or "new" run length encoding per component.
+func encodeRadiance(rows [][]byte, orient, enc string) []byte {
+	var buf bytes.Buffer
+	w := len(rows[0]) / 4
+	fmt.Fprintf(&buf, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n%s %d +X %d\n", orient, len(rows), w)
+	for _, row := range rows {
+		switch enc {
+		case "flat":
+			buf.Write(row)
+		case "old":
+			for x := 0; x < w; {
+				buf.Write(row[x*4 : x*4+4])
+				n := 1
+				for x+n < w && bytes.Equal(row[(x+n)*4:(x+n+1)*4], row[x*4:x*4+4]) {
+					n++
+				}
+				if n > 1 {
+					buf.Write([]byte{1, 1, 1, byte(n - 1)})
+				}
+				x += n
+			}
+		case "new":
+			buf.Write([]byte{2, 2, byte(w >> 8), byte(w)})
+			for c := 0; c < 4; c++ {
+				comp := make([]byte, w)
+				for x := range comp {
+					comp[x] = row[x*4+c]
+				}
+				for len(comp) > 0 {
+					n := 1
+					for n < len(comp) && n < 127 && comp[n] == comp[0] {
+						n++
+					}
+					if n >= 3 {
+						buf.Write([]byte{byte(128 + n), comp[0]})
+						comp = comp[n:]
+						continue
+					}
+					// Literals up to the next run of three.
+					n = 1
+					for n < len(comp) && n < 128 &&
+						!(n+2 < len(comp) && comp[n] == comp[n+1] && comp[n] == comp[n+2]) {
+						n++
+					}
+					buf.WriteByte(byte(n))
+					buf.Write(comp[:n])
+					comp = comp[n:]
+				}
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeRadiance(t *testing.T) {
+	const w, h = 10, 3
+	rows := radianceTestPixels(w, h)
+	for _, enc := range []string{"flat", "old", "new"} {
+		for _, orient := range []string{"-Y", "+Y"} {
+			m, err := DecodeRadiance(bytes.NewReader(encodeRadiance(rows, orient, enc)))
+			if err != nil {
+				t.Errorf("%s %s: %v", enc, orient, err)
+				continue
+			}
+			if m.Width != w || m.Height != h {
+				t.Fatalf("%s %s: size %dx%d", enc, orient, m.Width, m.Height)
+			}
+			for y, row := range rows {
+				// +Y stores the bottom row first.
+				my := y
+				if orient == "+Y" {
+					my = h - 1 - y
+				}
+				for x := 0; x < w; x++ {
+					r, g, b := rgbe(row[x*4 : x*4+4])
+					if got, want := m.At(x, my), [4]float32{r, g, b, 1}; got != want {
+						t.Errorf("%s %s: pixel %d,%d is %v, want %v", enc, orient, x, my, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRadianceRGBE(t *testing.T) {
+	r, g, b := rgbe([]byte{128, 64, 255, 129})
+	if r != 1 || g != 0.5 || b != 255.0/128 {
+		t.Errorf("rgbe = %v %v %v", r, g, b)
+	}
+	if r, g, b := rgbe([]byte{10, 20, 30, 0}); r != 0 || g != 0 || b != 0 {
+		t.Errorf("zero exponent: %v %v %v", r, g, b)
+	}
+}
+
+func TestDecodeRadianceErrors(t *testing.T) {
+	good := encodeRadiance(radianceTestPixels(10, 3), "-Y", "new")
+	tests := map[string][]byte{
+		"truncated": good[:len(good)-5],
+		"magic":     []byte("P6\n10 3\n255\n"),
+		"format":    []byte("#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n"),
+		"columns":   []byte("#?RADIANCE\n\n+X 1 -Y 1\n"),
+		"huge":      []byte("#?RADIANCE\n\n-Y 65536 +X 65536\n"),
+		"overflow":  []byte("#?RADIANCE\n\n-Y 4294967296 +X 4294967296\n"),
+		"repeat":    append([]byte("#?RADIANCE\n\n-Y 1 +X 2\n"), 1, 1, 1, 1),
+		"run":       append([]byte("#?RADIANCE\n\n-Y 1 +X 8\n"), 2, 2, 0, 8, 128+9, 0),
+		"width":     append([]byte("#?RADIANCE\n\n-Y 1 +X 8\n"), 2, 2, 0, 9),
+	}
+	for name, data := range tests {
+		if _, err := DecodeRadiance(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
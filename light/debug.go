@@ -0,0 +1,97 @@
+package light
+
+import (
+	"math"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+// DebugDrawer draws the volumes that point and spot lights reach, the
+// ones Manager culls against, as faint shells in the lights' colors.
+// Directional lights reach everything and are not drawn.
+type DebugDrawer struct {
+	prog   bgfx.Program
+	sphere assets.Mesh
+	cone   assets.Mesh
+	uColor *render.Uniform[[4]float32]
+}
+
+// NewDebugDrawer loads the program and meshes it draws with.
+func NewDebugDrawer() *DebugDrawer {
+	return &DebugDrawer{
+		prog:   assets.LoadProgram("vs_light_volume", "fs_light_volume"),
+		sphere: assets.SphereMesh(1, 12, 24),
+		cone:   assets.ConeMesh(24),
+		uColor: render.NewUniform[[4]float32]("u_color", 1),
+	}
+}
+
+// Draw draws the volumes of lights to view, blended over what is drawn
+// already.
+func (d *DebugDrawer) Draw(view bgfx.ViewID, lights []Light) {
+	const state = bgfx.StateRGBWrite | bgfx.StateDepthTestLess | bgfx.StateMSAA
+	for i := range lights {
+		l := &lights[i]
+		var (
+			mesh *assets.Mesh
+			mtx  [16]float32
+		)
+		switch l.Type {
+		case Point:
+			mesh = &d.sphere
+			mtx = [16]float32{
+				l.Range, 0, 0, 0,
+				0, l.Range, 0, 0,
+				0, 0, l.Range, 0,
+				l.Position[0], l.Position[1], l.Position[2], 1,
+			}
+		case Spot:
+			// The cone's rim is at the range, as far from the apex
+			// as the light reaches.
+			mesh = &d.cone
+			sin, cos := math.Sincos(float64(l.Outer))
+			radius, length := l.Range*float32(sin), l.Range*float32(cos)
+			z := normalize(l.Direction)
+			x, y := basis(z)
+			mtx = [16]float32{
+				x[0] * radius, x[1] * radius, x[2] * radius, 0,
+				y[0] * radius, y[1] * radius, y[2] * radius, 0,
+				z[0] * length, z[1] * length, z[2] * length, 0,
+				l.Position[0], l.Position[1], l.Position[2], 1,
+			}
+		default:
+			continue
+		}
+		d.uColor.Set([4]float32{l.Color[0], l.Color[1], l.Color[2], 0.5})
+		mesh.Submit(view, d.prog, mtx, state|bgfx.StateBlendAdd())
+	}
+}
+
+// Destroy destroys the program, meshes and uniform.
+func (d *DebugDrawer) Destroy() {
+	bgfx.DestroyProgram(d.prog)
+	d.sphere.Unload()
+	d.cone.Unload()
+	d.uColor.Destroy()
+}
+
+// basis returns two unit vectors perpendicular to the unit vector z and
+// to each other.
+func basis(z [3]float32) (x, y [3]float32) {
+	up := [3]float32{0, 1, 0}
+	if z[1] > 0.999 || z[1] < -0.999 {
+		up = [3]float32{1, 0, 0}
+	}
+	x = normalize(cross(up, z))
+	return x, cross(z, x)
+}
+
+func cross(a, b [3]float32) [3]float32 {
+	return [3]float32{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
@@ -0,0 +1,128 @@
+// Package light describes the lights shaders are lit by. A Manager
+// holds any number of them and picks the few that matter most to each
+// object, which Pack puts into the fixed size uniform arrays shaders
+// take them in.
+package light
+
+import (
+	"math"
+
+	"j4k.co/cgm"
+)
+
+// Max is the number of lights shaders take. The uniform arrays in
+// fs_pbr.glsl are declared with this many elements.
+const Max = 8
+
+// Type is the kind of a light.
+type Type int
+
+const (
+	// Directional lights are infinitely far away, like the sun, and
+	// only have a direction.
+	Directional Type = iota
+	// Point lights shine in every direction from a position.
+	Point
+	// Spot lights shine from a position in a cone around a direction.
+	Spot
+)
+
+func (t Type) String() string {
+	switch t {
+	case Directional:
+		return "directional"
+	case Point:
+		return "point"
+	case Spot:
+		return "spot"
+	}
+	return "unknown"
+}
+
+// Light is a light in world space.
+type Light struct {
+	Type Type
+
+	// Position is where point and spot lights are.
+	Position [3]float32
+
+	// Direction is the direction directional and spot lights shine
+	// in. It need not be normalized.
+	Direction [3]float32
+
+	// Color is linear, and multiplied by Intensity.
+	Color     [3]float32
+	Intensity float32
+
+	// Range is the distance at which point and spot lights fade out
+	// completely.
+	Range float32
+
+	// Inner and Outer are the angles from Direction at which a spot
+	// light starts to fade and has faded out completely.
+	Inner, Outer cgm.Radians
+}
+
+// Uniforms are the uniform arrays shaders take up to Max lights in, for
+// render.NewBlock.
+type Uniforms struct {
+	// PosType is the position of point and spot lights, and the
+	// Type in w.
+	PosType [Max][4]float32 `uniform:"u_lightPosType"`
+
+	// DirRange is the normalized direction lights shine in, and the
+	// Range in w.
+	DirRange [Max][4]float32 `uniform:"u_lightDirRange"`
+
+	// Color is the color times the intensity.
+	Color [Max][4]float32 `uniform:"u_lightColor"`
+
+	// Cone is the cosine of a spot light's outer angle, and one over
+	// the difference to the cosine of its inner angle, which shaders
+	// scale and clamp the cosine of the angle to the light by.
+	Cone [Max][4]float32 `uniform:"u_lightCone"`
+
+	// Count is the number of lights in x.
+	Count [4]float32 `uniform:"u_lightCount"`
+}
+
+// Pack returns the uniforms of the first Max lights.
+func Pack(lights []Light) *Uniforms {
+	u := new(Uniforms)
+	if len(lights) > Max {
+		lights = lights[:Max]
+	}
+	for i, l := range lights {
+		dir := normalize(l.Direction)
+		u.PosType[i] = [4]float32{l.Position[0], l.Position[1], l.Position[2], float32(l.Type)}
+		u.DirRange[i] = [4]float32{dir[0], dir[1], dir[2], l.Range}
+		u.Color[i] = [4]float32{
+			l.Color[0] * l.Intensity,
+			l.Color[1] * l.Intensity,
+			l.Color[2] * l.Intensity,
+			0,
+		}
+		if l.Type == Spot {
+			cosInner := float32(math.Cos(float64(l.Inner)))
+			cosOuter := float32(math.Cos(float64(l.Outer)))
+			u.Cone[i] = [4]float32{cosOuter, 1 / maxf(cosInner-cosOuter, 1e-4), 0, 0}
+		}
+	}
+	u.Count[0] = float32(len(lights))
+	return u
+}
+
+func normalize(v [3]float32) [3]float32 {
+	l := float32(math.Sqrt(float64(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])))
+	if l == 0 {
+		return v
+	}
+	return [3]float32{v[0] / l, v[1] / l, v[2] / l}
+}
+
+func maxf(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,115 @@
+package light
+
+import (
+	"math"
+	"sort"
+
+	"github.com/james4k/go-bgfx-examples/assets"
+)
+
+// Manager holds any number of lights, and picks the few that matter
+// most to each object for shaders that take a fixed number of them.
+type Manager struct {
+	Lights []Light
+	Stats  Stats
+
+	scored   []scoredLight
+	selected []Light
+}
+
+// Stats counts what Select did since the last ResetStats.
+type Stats struct {
+	Objects  int // calls to Select
+	Tested   int // lights tested against the objects' bounds
+	Culled   int // lights that could not reach an object
+	Dropped  int // lights that reached an object but did not fit
+	Selected int // lights selected
+}
+
+type scoredLight struct {
+	index int
+	score float32
+}
+
+// ResetStats zeroes the stats, for example at the start of a frame.
+func (m *Manager) ResetStats() {
+	m.Stats = Stats{}
+}
+
+// Select returns the lights that reach the world space sphere s, at
+// most max of them, the most relevant first. The slice is only valid
+// until the next call.
+func (m *Manager) Select(s assets.Sphere, max int) []Light {
+	m.scored = m.scored[:0]
+	for i := range m.Lights {
+		l := &m.Lights[i]
+		if !l.Reaches(s) {
+			m.Stats.Culled++
+			continue
+		}
+		m.scored = append(m.scored, scoredLight{i, l.Relevance(s)})
+	}
+	m.Stats.Objects++
+	m.Stats.Tested += len(m.Lights)
+	sort.SliceStable(m.scored, func(i, j int) bool {
+		return m.scored[i].score > m.scored[j].score
+	})
+	if len(m.scored) > max {
+		m.Stats.Dropped += len(m.scored) - max
+		m.scored = m.scored[:max]
+	}
+	m.selected = m.selected[:0]
+	for _, sl := range m.scored {
+		m.selected = append(m.selected, m.Lights[sl.index])
+	}
+	m.Stats.Selected += len(m.selected)
+	return m.selected
+}
+
+// Reaches reports whether l lights any part of the sphere s. Directional
+// lights reach everything; point lights reach as far as their range,
+// and spot lights as far as their range inside their outer cone.
+func (l *Light) Reaches(s assets.Sphere) bool {
+	if l.Type == Directional {
+		return true
+	}
+	d := sub(s.Center, l.Position)
+	dist2 := dot(d, d)
+	if r := l.Range + s.Radius; dist2 > r*r {
+		return false
+	}
+	if l.Type != Spot {
+		return true
+	}
+	// The distance from the center to the cone's side is negative
+	// inside it.
+	along := dot(d, normalize(l.Direction))
+	across := float32(math.Sqrt(float64(maxf(dist2-along*along, 0))))
+	sin, cos := math.Sincos(float64(l.Outer))
+	return across*float32(cos)-along*float32(sin) <= s.Radius && along >= -s.Radius
+}
+
+// Relevance estimates how brightly l lights the sphere s, by the
+// luminance of its color and its falloff at the nearest point of s, to
+// rank lights by.
+func (l *Light) Relevance(s assets.Sphere) float32 {
+	lum := (0.2126*l.Color[0] + 0.7152*l.Color[1] + 0.0722*l.Color[2]) * l.Intensity
+	if l.Type == Directional {
+		return lum
+	}
+	d := sub(s.Center, l.Position)
+	dist := maxf(float32(math.Sqrt(float64(dot(d, d))))-s.Radius, 0)
+	// The windowed inverse square falloff of fs_pbr, kept finite for
+	// objects the light is inside of.
+	r := dist * dist / (l.Range * l.Range)
+	window := maxf(1-r*r, 0)
+	return lum * window * window / (dist*dist + 1)
+}
+
+func sub(a, b [3]float32) [3]float32 {
+	return [3]float32{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot(a, b [3]float32) float32 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
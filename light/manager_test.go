@@ -0,0 +1,126 @@
+package light
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james4k/go-bgfx-examples/assets"
+	"j4k.co/cgm"
+)
+
+func TestSpotReaches(t *testing.T) {
+	l := Light{
+		Type:      Spot,
+		Direction: [3]float32{0, 0, 2},
+		Range:     10,
+		Outer:     cgm.Radians(math.Pi / 6),
+	}
+	// A sphere of radius 0.5 five units along the spot light's
+	// direction, d from the side of its cone.
+	beside := func(d float64) assets.Sphere {
+		across := (d + 5*math.Sin(math.Pi/6)) / math.Cos(math.Pi/6)
+		return assets.Sphere{Center: [3]float32{float32(across), 0, 5}, Radius: 0.5}
+	}
+	tests := []struct {
+		name string
+		s    assets.Sphere
+		want bool
+	}{
+		{"on the axis", assets.Sphere{Center: [3]float32{0, 0, 5}, Radius: 0.5}, true},
+		{"just inside, center outside", beside(0.45), true},
+		{"just outside", beside(0.55), false},
+		{"behind the apex", assets.Sphere{Center: [3]float32{0, 0, -1}, Radius: 0.5}, false},
+		{"around the apex", assets.Sphere{Center: [3]float32{0, 0, -0.4}, Radius: 0.5}, true},
+		{"beyond the range", assets.Sphere{Center: [3]float32{0, 0, 10.6}, Radius: 0.5}, false},
+	}
+	for _, tt := range tests {
+		if got := l.Reaches(tt.s); got != tt.want {
+			t.Errorf("%s: Reaches(%v) = %v, want %v", tt.name, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPointReaches(t *testing.T) {
+	l := Light{Type: Point, Position: [3]float32{1, 0, 0}, Range: 2}
+	if !l.Reaches(assets.Sphere{Center: [3]float32{3.4, 0, 0}, Radius: 0.5}) {
+		t.Error("sphere overlapping the range not reached")
+	}
+	if l.Reaches(assets.Sphere{Center: [3]float32{3.6, 0, 0}, Radius: 0.5}) {
+		t.Error("sphere outside the range reached")
+	}
+	sun := Light{Type: Directional}
+	if !sun.Reaches(assets.Sphere{Center: [3]float32{1e6, 0, 0}}) {
+		t.Error("directional light does not reach everything")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	s := assets.Sphere{Radius: 1}
+	m := new(Manager)
+	// Lights of increasing intensity at the same distance, and one
+	// too far away.
+	for _, intensity := range []float32{1, 5, 2, 4, 3} {
+		m.Lights = append(m.Lights, Light{
+			Type:      Point,
+			Position:  [3]float32{2, 0, 0},
+			Color:     [3]float32{1, 1, 1},
+			Intensity: intensity,
+			Range:     4,
+		})
+	}
+	m.Lights = append(m.Lights, Light{
+		Type:      Point,
+		Position:  [3]float32{20, 0, 0},
+		Color:     [3]float32{1, 1, 1},
+		Intensity: 100,
+		Range:     4,
+	})
+
+	got := m.Select(s, 3)
+	if len(got) != 3 {
+		t.Fatalf("selected %d lights, want 3", len(got))
+	}
+	for i, want := range []float32{5, 4, 3} {
+		if got[i].Intensity != want {
+			t.Errorf("light %d has intensity %v, want %v", i, got[i].Intensity, want)
+		}
+	}
+	want := Stats{Objects: 1, Tested: 6, Culled: 1, Dropped: 2, Selected: 3}
+	if m.Stats != want {
+		t.Errorf("stats %+v, want %+v", m.Stats, want)
+	}
+
+	// Stats add up over calls until reset.
+	m.Select(assets.Sphere{Center: [3]float32{50, 0, 0}, Radius: 1}, 3)
+	want = Stats{Objects: 2, Tested: 12, Culled: 7, Dropped: 2, Selected: 3}
+	if m.Stats != want {
+		t.Errorf("after a second call, stats %+v, want %+v", m.Stats, want)
+	}
+	m.ResetStats()
+	if m.Stats != (Stats{}) {
+		t.Errorf("after ResetStats, stats %+v", m.Stats)
+	}
+}
+
+func TestRelevance(t *testing.T) {
+	s := assets.Sphere{Radius: 0.5}
+	near := Light{Type: Point, Position: [3]float32{1, 0, 0}, Color: [3]float32{1, 1, 1}, Intensity: 1, Range: 5}
+	far := near
+	far.Position = [3]float32{3, 0, 0}
+	if near.Relevance(s) <= far.Relevance(s) {
+		t.Errorf("nearer light is not more relevant: %v, %v", near.Relevance(s), far.Relevance(s))
+	}
+	// Green counts for more than blue of the same intensity.
+	green, blue := near, near
+	green.Color = [3]float32{0, 1, 0}
+	blue.Color = [3]float32{0, 0, 1}
+	if green.Relevance(s) <= blue.Relevance(s) {
+		t.Errorf("green %v, blue %v", green.Relevance(s), blue.Relevance(s))
+	}
+	// A light is finitely relevant to a sphere it is inside of.
+	inside := near
+	inside.Position = [3]float32{}
+	if r := inside.Relevance(s); math.IsInf(float64(r), 0) || math.IsNaN(float64(r)) {
+		t.Errorf("relevance from inside is %v", r)
+	}
+}
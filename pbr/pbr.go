@@ -0,0 +1,196 @@
+// Package pbr draws meshes with physically based metallic-roughness
+// materials, as glTF describes them, lit by the lights of package light.
+package pbr
+
+import (
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/light"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+// Slot is a texture slot of a material, which is also the sampler stage
+// the texture is bound to.
+type Slot uint8
+
+const (
+	// AlbedoMap is an sRGB color texture, with alpha.
+	AlbedoMap Slot = iota
+	// NormalMap is a tangent space normal map, with x and y in red
+	// and green.
+	NormalMap
+	// MetalRoughMap has roughness in green and metalness in blue.
+	MetalRoughMap
+	// OcclusionMap has ambient occlusion in red.
+	OcclusionMap
+	NumSlots
+)
+
+var samplerNames = [NumSlots]string{
+	AlbedoMap:     "u_texAlbedo",
+	NormalMap:     "u_texNormal",
+	MetalRoughMap: "u_texMetalRough",
+	OcclusionMap:  "u_texOcclusion",
+}
+
+// defaultTexels are the texels of the 1x1 textures bound to slots a
+// material has no texture for, which leave its factors as they are.
+var defaultTexels = [NumSlots][4]uint8{
+	AlbedoMap:     {255, 255, 255, 255},
+	NormalMap:     {128, 128, 255, 255},
+	MetalRoughMap: {255, 255, 255, 255},
+	OcclusionMap:  {255, 255, 255, 255},
+}
+
+// Material is a metallic-roughness material. The textures in Maps
+// multiply the factors; slots without one are left out of the product.
+type Material struct {
+	// Albedo is the linear base color and alpha.
+	Albedo    [4]float32
+	Metallic  float32
+	Roughness float32
+
+	// Occlusion is how much of the OcclusionMap is applied, from 0
+	// to 1. Occlusion only darkens ambient light.
+	Occlusion float32
+
+	// Emissive is linear light the material gives off.
+	Emissive [3]float32
+
+	Maps map[Slot]bgfx.Texture
+}
+
+// Lighting is what lights every material besides the lights.
+type Lighting struct {
+	// Eye is the camera position in world space.
+	Eye [3]float32
+
+	// Ambient is the linear color of light from every direction.
+	Ambient [3]float32
+
+	// Exposure multiplies colors before they are tonemapped.
+	Exposure float32
+}
+
+type materialUniforms struct {
+	Albedo   [4]float32 `uniform:"u_albedo"`
+	Material [4]float32 `uniform:"u_material"`
+	Emissive [4]float32 `uniform:"u_emissive"`
+}
+
+type lightingUniforms struct {
+	CamPos  [4]float32 `uniform:"u_camPos"`
+	Ambient [4]float32 `uniform:"u_ambient"`
+}
+
+// Shader is the vs_pbr and fs_pbr program, with the uniforms and
+// default textures it needs.
+type Shader struct {
+	Program bgfx.Program
+
+	samplers [NumSlots]bgfx.Uniform
+	defaults [NumSlots]bgfx.Texture
+	material *render.Block[materialUniforms]
+	lighting *render.Block[lightingUniforms]
+	lights   *render.Block[light.Uniforms]
+}
+
+// NewShader loads the program and creates its uniforms and default
+// textures.
+func NewShader() *Shader {
+	s := &Shader{
+		Program:  assets.LoadProgram("vs_pbr", "fs_pbr"),
+		material: render.NewBlock[materialUniforms](),
+		lighting: render.NewBlock[lightingUniforms](),
+		lights:   render.NewBlock[light.Uniforms](),
+	}
+	for slot := range s.samplers {
+		s.samplers[slot] = bgfx.CreateUniform(samplerNames[slot], bgfx.Uniform1iv, 1)
+		texel := defaultTexels[slot]
+		s.defaults[slot] = bgfx.CreateTexture2D(1, 1, 1, bgfx.TextureFormatRGBA8, 0, texel[:])
+	}
+	return s
+}
+
+// SetLighting sets the lighting for the next draws.
+func (s *Shader) SetLighting(l Lighting) {
+	s.lighting.Set(packLighting(l))
+}
+
+func packLighting(l Lighting) *lightingUniforms {
+	return &lightingUniforms{
+		CamPos:  [4]float32{l.Eye[0], l.Eye[1], l.Eye[2], 0},
+		Ambient: [4]float32{l.Ambient[0], l.Ambient[1], l.Ambient[2], l.Exposure},
+	}
+}
+
+// SetLights sets the first light.Max lights for the next draws.
+func (s *Shader) SetLights(lights []light.Light) {
+	s.lights.Set(light.Pack(lights))
+}
+
+// Apply sets m's textures and factors for the next draw.
+func (s *Shader) Apply(m *Material) {
+	s.ApplyTo(render.Default, m)
+}
+
+// ApplyTo sets m's textures and factors on r.
+func (s *Shader) ApplyTo(r render.Renderer, m *Material) {
+	for slot, sampler := range s.samplers {
+		r.SetTexture(uint8(slot), sampler, s.texture(m, Slot(slot)))
+	}
+	s.material.SetTo(r, packMaterial(m))
+}
+
+// texture returns m's texture for slot, or the default one if it has
+// none.
+func (s *Shader) texture(m *Material, slot Slot) bgfx.Texture {
+	if tex, ok := m.Maps[slot]; ok {
+		return tex
+	}
+	return s.defaults[slot]
+}
+
+func packMaterial(m *Material) *materialUniforms {
+	return &materialUniforms{
+		Albedo:   m.Albedo,
+		Material: [4]float32{m.Metallic, m.Roughness, m.Occlusion, 0},
+		Emissive: [4]float32{m.Emissive[0], m.Emissive[1], m.Emissive[2], 0},
+	}
+}
+
+// Submit draws mesh with m, applying the material for each of the
+// mesh's draws.
+func (s *Shader) Submit(view bgfx.ViewID, mesh *assets.Mesh, m *Material, mtx [16]float32) {
+	s.SubmitTo(render.Default, view, mesh, m, mtx)
+}
+
+// SubmitTo is like Submit, but draws through r.
+func (s *Shader) SubmitTo(r render.Renderer, view bgfx.ViewID, mesh *assets.Mesh, m *Material, mtx [16]float32) {
+	mesh.SubmitTo(materialRenderer{r, s, m}, view, s.Program, mtx, 0)
+}
+
+// Destroy destroys the program, uniforms and default textures.
+func (s *Shader) Destroy() {
+	bgfx.DestroyProgram(s.Program)
+	for slot := range s.samplers {
+		bgfx.DestroyUniform(s.samplers[slot])
+		bgfx.DestroyTexture(s.defaults[slot])
+	}
+	s.material.Destroy()
+	s.lighting.Destroy()
+	s.lights.Destroy()
+}
+
+// materialRenderer applies a material whenever a program is set, which
+// Mesh does once per draw.
+type materialRenderer struct {
+	render.Renderer
+	shader *Shader
+	mat    *Material
+}
+
+func (r materialRenderer) SetProgram(prog bgfx.Program) {
+	r.Renderer.SetProgram(prog)
+	r.shader.ApplyTo(r.Renderer, r.mat)
+}
@@ -0,0 +1,104 @@
+package pbr
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+func TestPackMaterial(t *testing.T) {
+	m := &Material{
+		Albedo:    [4]float32{0.8, 0.6, 0.4, 0.5},
+		Metallic:  1,
+		Roughness: 0.25,
+		Occlusion: 0.75,
+		Emissive:  [3]float32{2, 3, 4},
+	}
+	want := materialUniforms{
+		Albedo:   [4]float32{0.8, 0.6, 0.4, 0.5},
+		Material: [4]float32{1, 0.25, 0.75, 0},
+		Emissive: [4]float32{2, 3, 4, 0},
+	}
+	if got := packMaterial(m); *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
+
+func TestPackLighting(t *testing.T) {
+	l := Lighting{Eye: [3]float32{1, 2, 3}, Ambient: [3]float32{0.1, 0.2, 0.3}, Exposure: 1.5}
+	want := lightingUniforms{
+		CamPos:  [4]float32{1, 2, 3, 0},
+		Ambient: [4]float32{0.1, 0.2, 0.3, 1.5},
+	}
+	if got := packLighting(l); *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
+
+// texture returns a distinct texture handle without a renderer to
+// create one. Handles are a 16 bit index.
+func texture(t *testing.T, i uint16) bgfx.Texture {
+	var tex bgfx.Texture
+	if unsafe.Sizeof(tex) != unsafe.Sizeof(i) {
+		t.Skip("texture handles are not 16 bit indices")
+	}
+	*(*uint16)(unsafe.Pointer(&tex)) = i
+	return tex
+}
+
+func TestMapSlots(t *testing.T) {
+	// A shader with default textures 100 to 103 and no uniforms.
+	s := &Shader{material: new(render.Block[materialUniforms])}
+	for slot := range s.defaults {
+		s.defaults[slot] = texture(t, 100+uint16(slot))
+	}
+	for _, tt := range []struct {
+		name string
+		maps map[Slot]bgfx.Texture
+		want [NumSlots]uint16
+	}{
+		{"no maps", nil, [NumSlots]uint16{100, 101, 102, 103}},
+		{"normal map", map[Slot]bgfx.Texture{NormalMap: texture(t, 1)}, [NumSlots]uint16{100, 1, 102, 103}},
+		{
+			"every map",
+			map[Slot]bgfx.Texture{
+				AlbedoMap:     texture(t, 1),
+				NormalMap:     texture(t, 2),
+				MetalRoughMap: texture(t, 3),
+				OcclusionMap:  texture(t, 4),
+			},
+			[NumSlots]uint16{1, 2, 3, 4},
+		},
+	} {
+		var r render.Recorder
+		s.ApplyTo(&r, &Material{Maps: tt.maps})
+		r.Submit(0)
+		textures := r.Draws[0].Textures
+		if len(textures) != int(NumSlots) {
+			t.Errorf("%s: %d textures bound, want %d", tt.name, len(textures), NumSlots)
+		}
+		for slot, want := range tt.want {
+			if got := textures[uint8(slot)]; got != texture(t, want) {
+				t.Errorf("%s: slot %d has %v, want texture %d", tt.name, slot, got, want)
+			}
+		}
+	}
+}
+
+func TestDefaultTexelsLeaveFactors(t *testing.T) {
+	// White multiplies factors by 1, and the flat normal points along
+	// z once unpacked from [0, 1].
+	for _, slot := range []Slot{AlbedoMap, MetalRoughMap, OcclusionMap} {
+		if texel := defaultTexels[slot]; texel != [4]uint8{255, 255, 255, 255} {
+			t.Errorf("slot %d: default texel %v is not white", slot, texel)
+		}
+	}
+	n := defaultTexels[NormalMap]
+	for i, want := range []float32{0, 0, 1} {
+		if got := float32(n[i])/255*2 - 1; got-want > 0.01 || want-got > 0.01 {
+			t.Errorf("default normal component %d is %v, want %v", i, got, want)
+		}
+	}
+}
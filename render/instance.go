@@ -0,0 +1,143 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// InstanceBuffer collects per-instance data of type T and draws it with
+// as few instanced draws as the available instance data space allows.
+// T's size is the stride, and must be a multiple of 16 bytes, since
+// instance data is read in vec4s. For example:
+//
+//	type instance struct {
+//		Mtx   [16]float32
+//		Color [4]float32
+//	}
+//
+// Without instancing, each instance is drawn on its own. PerDraw sets
+// up those draws; if it is nil, T must be or begin with a [16]float32,
+// which is used as the draw's transform.
+type InstanceBuffer[T any] struct {
+	Instanced bool                          // draw with instance data
+	PerDraw   func(r Renderer, instance *T) // set up one draw without instancing
+	Stats     InstanceStats                 // for the last Submit
+
+	items     []T
+	stride    int
+	transform bool // T begins with a matrix
+}
+
+// InstanceStats counts what an InstanceBuffer submit drew.
+type InstanceStats struct {
+	Instances int
+	Draws     int
+	Dropped   int // instances with no instance data space left
+}
+
+// NewInstanceBuffer returns an empty buffer that uses instancing if the
+// renderer supports it. It panics if T's size is not a multiple of 16.
+func NewInstanceBuffer[T any]() *InstanceBuffer[T] {
+	var zero T
+	stride := int(unsafe.Sizeof(zero))
+	if stride == 0 || stride%16 != 0 {
+		panic(fmt.Sprintf("render: instance type %T is %d bytes, not a multiple of 16", zero, stride))
+	}
+	return &InstanceBuffer[T]{
+		Instanced: bgfx.Caps().Supported&bgfx.CapsInstancing != 0,
+		stride:    stride,
+		transform: leadingMatrix(reflect.TypeOf(zero)),
+	}
+}
+
+// leadingMatrix reports whether t is or begins with a [16]float32.
+func leadingMatrix(t reflect.Type) bool {
+	mtx := reflect.TypeOf([16]float32{})
+	if t == mtx {
+		return true
+	}
+	return t.Kind() == reflect.Struct && t.NumField() > 0 &&
+		t.Field(0).Type == mtx
+}
+
+// Stride returns the size of one instance in bytes.
+func (b *InstanceBuffer[T]) Stride() int {
+	return b.stride
+}
+
+// Len returns the number of instances added since the last Submit.
+func (b *InstanceBuffer[T]) Len() int {
+	return len(b.items)
+}
+
+// Add adds an instance.
+func (b *InstanceBuffer[T]) Add(instance T) {
+	b.items = append(b.items, instance)
+}
+
+// Reset discards the instances added since the last Submit.
+func (b *InstanceBuffer[T]) Reset() {
+	b.items = b.items[:0]
+}
+
+// Submit draws the instances to view and empties the buffer. setup is
+// called before each draw to set the program, buffers, textures and
+// state shared by all instances.
+func (b *InstanceBuffer[T]) Submit(view bgfx.ViewID, setup func(r Renderer)) {
+	b.SubmitTo(Default, view, setup)
+}
+
+// SubmitTo is like Submit, but draws through r.
+func (b *InstanceBuffer[T]) SubmitTo(r Renderer, view bgfx.ViewID, setup func(r Renderer)) {
+	b.Stats = InstanceStats{Instances: len(b.items)}
+	if b.Instanced {
+		b.submitInstanced(r, view, setup)
+	} else {
+		b.submitEach(r, view, setup)
+	}
+	b.Reset()
+}
+
+func (b *InstanceBuffer[T]) submitInstanced(r Renderer, view bgfx.ViewID, setup func(r Renderer)) {
+	items := b.items
+	for len(items) > 0 {
+		// Take as many as there is space for, halving until it fits.
+		n := len(items)
+		for n > 0 && !r.CheckAvailInstanceDataBuffer(n, b.stride) {
+			n /= 2
+		}
+		if n == 0 {
+			b.Stats.Dropped = len(items)
+			return
+		}
+		idb := r.AllocInstanceDataBuffer(n, b.stride)
+		idb.Write(unsafe.Slice((*byte)(unsafe.Pointer(&items[0])), n*b.stride))
+		setup(r)
+		r.SetInstanceDataBuffer(idb)
+		r.Submit(view)
+		b.Stats.Draws++
+		items = items[n:]
+	}
+}
+
+func (b *InstanceBuffer[T]) submitEach(r Renderer, view bgfx.ViewID, setup func(r Renderer)) {
+	perDraw := b.PerDraw
+	if perDraw == nil {
+		if !b.transform {
+			var zero T
+			panic(fmt.Sprintf("render: instance type %T has no transform and PerDraw is nil", zero))
+		}
+		perDraw = func(r Renderer, instance *T) {
+			r.SetTransform(*(*[16]float32)(unsafe.Pointer(instance)))
+		}
+	}
+	for i := range b.items {
+		setup(r)
+		perDraw(r, &b.items[i])
+		r.Submit(view)
+		b.Stats.Draws++
+	}
+}
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+)
+
+type testInstance struct {
+	Mtx   [16]float32
+	Color [4]float32
+}
+
+func testInstances(n int, instanced bool) *InstanceBuffer[testInstance] {
+	b := NewInstanceBuffer[testInstance]()
+	b.Instanced = instanced
+	for i := 0; i < n; i++ {
+		var inst testInstance
+		inst.Mtx[12] = float32(i)
+		b.Add(inst)
+	}
+	return b
+}
+
+func TestInstanceSubmit(t *testing.T) {
+	const stride = 80
+	for _, tt := range []struct {
+		name    string
+		space   int
+		allocs  []int // instances per draw
+		dropped int
+	}{
+		{"unlimited", 0, []int{10}, 0},
+		{"room for all", 10 * stride, []int{10}, 0},
+		// Halving from 10 fits 5, then from the 5 left fits 2, and
+		// then there is no room for even 1.
+		{"room for 7", 7 * stride, []int{5, 2}, 3},
+		{"no room", stride / 2, nil, 10},
+	} {
+		r := Recorder{InstanceSpace: tt.space}
+		b := testInstances(10, true)
+		if b.Stride() != stride {
+			t.Fatalf("stride %d, want %d", b.Stride(), stride)
+		}
+		b.SubmitTo(&r, 1, func(r Renderer) { r.SetProgram(bgfx.Program{}) })
+
+		allocs := r.CallsNamed("AllocInstanceDataBuffer")
+		if len(allocs) != len(tt.allocs) {
+			t.Errorf("%s: %d allocations, want %d", tt.name, len(allocs), len(tt.allocs))
+			continue
+		}
+		for i, c := range allocs {
+			if c.Args[0] != tt.allocs[i] || c.Args[1] != stride {
+				t.Errorf("%s: allocation %d of %v, want %d of %d", tt.name, i, c.Args, tt.allocs[i], stride)
+			}
+		}
+		if len(r.Draws) != len(tt.allocs) {
+			t.Errorf("%s: %d draws, want %d", tt.name, len(r.Draws), len(tt.allocs))
+		}
+		for i, d := range r.Draws {
+			if !d.Instanced || d.View != 1 {
+				t.Errorf("%s: draw %d to view %d, instanced %v", tt.name, i, d.View, d.Instanced)
+			}
+		}
+		if n := len(r.CallsNamed("SetProgram")); n != len(tt.allocs) {
+			t.Errorf("%s: setup called %d times, want once per draw", tt.name, n)
+		}
+		want := InstanceStats{Instances: 10, Draws: len(tt.allocs), Dropped: tt.dropped}
+		if b.Stats != want {
+			t.Errorf("%s: stats %+v, want %+v", tt.name, b.Stats, want)
+		}
+		if b.Len() != 0 {
+			t.Errorf("%s: %d instances left after Submit", tt.name, b.Len())
+		}
+	}
+}
+
+func TestInstanceSubmitEach(t *testing.T) {
+	var r Recorder
+	b := testInstances(3, false)
+	b.SubmitTo(&r, 0, func(Renderer) {})
+	if len(r.Draws) != 3 {
+		t.Fatalf("%d draws, want 3", len(r.Draws))
+	}
+	for i, d := range r.Draws {
+		if d.Instanced || d.Transform[12] != float32(i) {
+			t.Errorf("draw %d: instanced %v, transform x %v", i, d.Instanced, d.Transform[12])
+		}
+	}
+	if n := len(r.CallsNamed("CheckAvailInstanceDataBuffer")); n != 0 {
+		t.Errorf("%d instance data checks without instancing", n)
+	}
+	if want := (InstanceStats{Instances: 3, Draws: 3}); b.Stats != want {
+		t.Errorf("stats %+v, want %+v", b.Stats, want)
+	}
+}
@@ -0,0 +1,196 @@
+package render
+
+import (
+	"reflect"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// Call is a single recorded Renderer method call.
+type Call struct {
+	Name string
+	Args []interface{}
+}
+
+// Draw is the state bound when Submit was called. Like bgfx, the
+// Recorder discards the bound state after each Submit.
+type Draw struct {
+	View      bgfx.ViewID
+	Transform [16]float32
+	Program   bgfx.Program
+	VB        bgfx.VertexBuffer
+	IB        bgfx.IndexBuffer
+	State     bgfx.State
+	Instanced bool
+	Transient bool
+	Textures  map[uint8]interface{} // bgfx.Texture or bgfx.FrameBuffer
+
+	// Uniforms are copies of the values set, as recorded by
+	// SetUniform.
+	Uniforms map[bgfx.Uniform]interface{}
+}
+
+// Recorder is a Renderer that records every call made to it instead of
+// rendering anything.
+type Recorder struct {
+	Calls []Call
+	Draws []Draw
+
+	// InstanceSpace, if not zero, limits how many bytes of instance
+	// data can be allocated, like the space bgfx has for a frame.
+	InstanceSpace int
+
+	pending       Draw
+	instanceBytes int // allocated so far
+}
+
+// Reset clears everything recorded so far, including the instance data
+// allocated.
+func (r *Recorder) Reset() {
+	*r = Recorder{InstanceSpace: r.InstanceSpace}
+}
+
+// CallsNamed returns the recorded calls to the named method.
+func (r *Recorder) CallsNamed(name string) []Call {
+	var calls []Call
+	for _, c := range r.Calls {
+		if c.Name == name {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (r *Recorder) record(name string, args ...interface{}) {
+	r.Calls = append(r.Calls, Call{Name: name, Args: args})
+}
+
+func (r *Recorder) SetViewClear(view bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32, depth float32, stencil uint8) {
+	r.record("SetViewClear", view, flags, rgba, depth, stencil)
+}
+
+func (r *Recorder) SetViewRect(view bgfx.ViewID, x, y, w, h int) {
+	r.record("SetViewRect", view, x, y, w, h)
+}
+
+func (r *Recorder) SetViewTransform(view bgfx.ViewID, mtxView, mtxProj [16]float32) {
+	r.record("SetViewTransform", view, mtxView, mtxProj)
+}
+
+func (r *Recorder) SetViewFrameBuffer(view bgfx.ViewID, fb bgfx.FrameBuffer) {
+	r.record("SetViewFrameBuffer", view, fb)
+}
+
+func (r *Recorder) SetTransform(mtx [16]float32) {
+	r.record("SetTransform", mtx)
+	r.pending.Transform = mtx
+}
+
+func (r *Recorder) SetProgram(prog bgfx.Program) {
+	r.record("SetProgram", prog)
+	r.pending.Program = prog
+}
+
+func (r *Recorder) SetVertexBuffer(vb bgfx.VertexBuffer) {
+	r.record("SetVertexBuffer", vb)
+	r.pending.VB = vb
+}
+
+func (r *Recorder) SetIndexBuffer(ib bgfx.IndexBuffer) {
+	r.record("SetIndexBuffer", ib)
+	r.pending.IB = ib
+}
+
+func (r *Recorder) SetTransientVertexBuffer(tvb bgfx.TransientVertexBuffer, start, num int) {
+	r.record("SetTransientVertexBuffer", tvb, start, num)
+	r.pending.Transient = true
+}
+
+func (r *Recorder) SetTransientIndexBuffer(tib bgfx.TransientIndexBuffer, start, num int) {
+	r.record("SetTransientIndexBuffer", tib, start, num)
+	r.pending.Transient = true
+}
+
+func (r *Recorder) SetInstanceDataBuffer(idb bgfx.InstanceDataBuffer) {
+	r.record("SetInstanceDataBuffer", idb)
+	r.pending.Instanced = true
+}
+
+func (r *Recorder) CheckAvailInstanceDataBuffer(num, stride int) bool {
+	r.record("CheckAvailInstanceDataBuffer", num, stride)
+	return r.InstanceSpace == 0 || r.instanceBytes+num*stride <= r.InstanceSpace
+}
+
+// AllocInstanceDataBuffer returns an empty buffer, which the caller may
+// write to but nothing reads.
+func (r *Recorder) AllocInstanceDataBuffer(num, stride int) bgfx.InstanceDataBuffer {
+	r.record("AllocInstanceDataBuffer", num, stride)
+	r.instanceBytes += num * stride
+	return bgfx.InstanceDataBuffer{}
+}
+
+func (r *Recorder) SetState(state bgfx.State) {
+	r.record("SetState", state)
+	r.pending.State = state
+}
+
+// SetUniform records a copy of the value ptr points to, so that later
+// changes to it do not show in recorded calls and draws.
+func (r *Recorder) SetUniform(u bgfx.Uniform, ptr interface{}, num int) {
+	value := copyUniform(ptr, num)
+	r.record("SetUniform", u, value, num)
+	if r.pending.Uniforms == nil {
+		r.pending.Uniforms = make(map[bgfx.Uniform]interface{})
+	}
+	r.pending.Uniforms[u] = value
+}
+
+// copyUniform returns a pointer to a copy of the value ptr points to. A
+// pointer to the first of num elements of a uniform type, as
+// Uniform.SetTo passes, is copied as a pointer to an array of all of
+// them. Slices are copied whole, and anything else is returned as is.
+func copyUniform(ptr interface{}, num int) interface{} {
+	v := reflect.ValueOf(ptr)
+	switch v.Kind() {
+	case reflect.Slice:
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c.Interface()
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ptr
+		}
+		t := v.Type().Elem()
+		if _, n, ok := uniformType(t); ok && n == 1 && num > 1 {
+			t = reflect.ArrayOf(num, t)
+		}
+		c := reflect.New(t)
+		c.Elem().Set(reflect.NewAt(t, v.UnsafePointer()).Elem())
+		return c.Interface()
+	}
+	return ptr
+}
+
+func (r *Recorder) SetTexture(stage uint8, u bgfx.Uniform, tex bgfx.Texture) {
+	r.record("SetTexture", stage, u, tex)
+	r.setTexture(stage, tex)
+}
+
+func (r *Recorder) SetTextureFromFrameBuffer(stage uint8, u bgfx.Uniform, fb bgfx.FrameBuffer) {
+	r.record("SetTextureFromFrameBuffer", stage, u, fb)
+	r.setTexture(stage, fb)
+}
+
+func (r *Recorder) setTexture(stage uint8, tex interface{}) {
+	if r.pending.Textures == nil {
+		r.pending.Textures = make(map[uint8]interface{})
+	}
+	r.pending.Textures[stage] = tex
+}
+
+func (r *Recorder) Submit(view bgfx.ViewID) {
+	r.record("Submit", view)
+	r.pending.View = view
+	r.Draws = append(r.Draws, r.pending)
+	r.pending = Draw{}
+}
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+)
+
+func TestRecorderCopiesUniforms(t *testing.T) {
+	var (
+		r Recorder
+		u bgfx.Uniform
+		v = [4]float32{1, 2, 3, 4}
+	)
+	r.SetUniform(u, &v, 1)
+	r.Submit(0)
+	v[0] = 9
+	r.SetUniform(u, &v, 1)
+	r.Submit(0)
+
+	for i, want := range []float32{1, 9} {
+		got, ok := r.Draws[i].Uniforms[u].(*[4]float32)
+		if !ok {
+			t.Fatalf("draw %d: uniform is %T", i, r.Draws[i].Uniforms[u])
+		}
+		if got[0] != want {
+			t.Errorf("draw %d: uniform is %v, want %g first", i, *got, want)
+		}
+	}
+}
+
+func TestRecorderCopiesArrays(t *testing.T) {
+	var (
+		r Recorder
+		u bgfx.Uniform
+	)
+	values := [][4]float32{{1}, {2}, {3}}
+	r.SetUniform(u, &values[0], len(values))
+	floats := []float32{5, 6}
+	r.SetUniform(u, floats, 2)
+	values[2][0], floats[1] = 0, 0
+
+	args := r.CallsNamed("SetUniform")
+	if got := args[0].Args[1].(*[3][4]float32); got[2][0] != 3 {
+		t.Errorf("elements recorded as %v", *got)
+	}
+	if got := args[1].Args[1].([]float32); got[1] != 6 {
+		t.Errorf("slice recorded as %v", got)
+	}
+}
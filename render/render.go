@@ -0,0 +1,97 @@
+/*
+Package render abstracts the subset of bgfx's immediate mode API used by
+the examples, so that render code can be driven against a Recorder
+instead of a GPU.
+*/
+package render
+
+import "github.com/james4k/go-bgfx"
+
+// Renderer is the set of bgfx calls used to set up views and submit
+// draws.
+type Renderer interface {
+	SetViewClear(view bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32, depth float32, stencil uint8)
+	SetViewRect(view bgfx.ViewID, x, y, w, h int)
+	SetViewTransform(view bgfx.ViewID, mtxView, mtxProj [16]float32)
+	SetViewFrameBuffer(view bgfx.ViewID, fb bgfx.FrameBuffer)
+
+	SetTransform(mtx [16]float32)
+	SetProgram(prog bgfx.Program)
+	SetVertexBuffer(vb bgfx.VertexBuffer)
+	SetIndexBuffer(ib bgfx.IndexBuffer)
+	SetTransientVertexBuffer(tvb bgfx.TransientVertexBuffer, start, num int)
+	SetTransientIndexBuffer(tib bgfx.TransientIndexBuffer, start, num int)
+	SetInstanceDataBuffer(idb bgfx.InstanceDataBuffer)
+	CheckAvailInstanceDataBuffer(num, stride int) bool
+	AllocInstanceDataBuffer(num, stride int) bgfx.InstanceDataBuffer
+	SetState(state bgfx.State)
+	SetUniform(u bgfx.Uniform, ptr interface{}, num int)
+	SetTexture(stage uint8, u bgfx.Uniform, tex bgfx.Texture)
+	SetTextureFromFrameBuffer(stage uint8, u bgfx.Uniform, fb bgfx.FrameBuffer)
+	Submit(view bgfx.ViewID)
+}
+
+// Default forwards every call to bgfx.
+var Default Renderer = direct{}
+
+type direct struct{}
+
+func (direct) SetViewClear(view bgfx.ViewID, flags bgfx.ClearFlags, rgba uint32, depth float32, stencil uint8) {
+	bgfx.SetViewClear(view, flags, rgba, depth, stencil)
+}
+
+func (direct) SetViewRect(view bgfx.ViewID, x, y, w, h int) {
+	bgfx.SetViewRect(view, x, y, w, h)
+}
+
+func (direct) SetViewTransform(view bgfx.ViewID, mtxView, mtxProj [16]float32) {
+	bgfx.SetViewTransform(view, mtxView, mtxProj)
+}
+
+func (direct) SetViewFrameBuffer(view bgfx.ViewID, fb bgfx.FrameBuffer) {
+	bgfx.SetViewFrameBuffer(view, fb)
+}
+
+func (direct) SetTransform(mtx [16]float32) { bgfx.SetTransform(mtx) }
+
+func (direct) SetProgram(prog bgfx.Program) { bgfx.SetProgram(prog) }
+
+func (direct) SetVertexBuffer(vb bgfx.VertexBuffer) { bgfx.SetVertexBuffer(vb) }
+
+func (direct) SetIndexBuffer(ib bgfx.IndexBuffer) { bgfx.SetIndexBuffer(ib) }
+
+func (direct) SetTransientVertexBuffer(tvb bgfx.TransientVertexBuffer, start, num int) {
+	bgfx.SetTransientVertexBuffer(tvb, start, num)
+}
+
+func (direct) SetTransientIndexBuffer(tib bgfx.TransientIndexBuffer, start, num int) {
+	bgfx.SetTransientIndexBuffer(tib, start, num)
+}
+
+func (direct) SetInstanceDataBuffer(idb bgfx.InstanceDataBuffer) {
+	bgfx.SetInstanceDataBuffer(idb)
+}
+
+func (direct) CheckAvailInstanceDataBuffer(num, stride int) bool {
+	return bgfx.CheckAvailInstanceDataBuffer(num, stride)
+}
+
+func (direct) AllocInstanceDataBuffer(num, stride int) bgfx.InstanceDataBuffer {
+	return bgfx.AllocInstanceDataBuffer(num, stride)
+}
+
+func (direct) SetState(state bgfx.State) { bgfx.SetState(state) }
+
+func (direct) SetUniform(u bgfx.Uniform, ptr interface{}, num int) {
+	bgfx.SetUniform(u, ptr, num)
+}
+
+func (direct) SetTexture(stage uint8, u bgfx.Uniform, tex bgfx.Texture) {
+	bgfx.SetTexture(stage, u, tex)
+}
+
+func (direct) SetTextureFromFrameBuffer(stage uint8, u bgfx.Uniform, fb bgfx.FrameBuffer) {
+	bgfx.SetTextureFromFrameBuffer(stage, u, fb)
+}
+
+func (direct) Submit(view bgfx.ViewID) { bgfx.Submit(view) }
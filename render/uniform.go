@@ -0,0 +1,169 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/james4k/go-bgfx"
+)
+
+// Value is a Go type that can be uploaded as one element of a uniform:
+// float32, [3]float32 (vec3), [4]float32 (vec4), [9]float32 (mat3) or
+// [16]float32 (mat4).
+type Value interface {
+	~float32 | ~[3]float32 | ~[4]float32 | ~[9]float32 | ~[16]float32
+}
+
+// Uniform is a uniform array of Num elements of type T.
+type Uniform[T Value] struct {
+	Name   string
+	Num    int
+	handle bgfx.Uniform
+}
+
+// NewUniform creates a uniform of num elements of type T.
+func NewUniform[T Value](name string, num int) *Uniform[T] {
+	var zero T
+	typ, n, ok := uniformType(reflect.TypeOf(zero))
+	if !ok || n != 1 {
+		panic(fmt.Sprintf("render: uniform %s: no uniform type for %T", name, zero))
+	}
+	if num < 1 {
+		panic(fmt.Sprintf("render: uniform %s: %d elements", name, num))
+	}
+	return &Uniform[T]{
+		Name:   name,
+		Num:    num,
+		handle: bgfx.CreateUniform(name, typ, num),
+	}
+}
+
+// Handle returns the bgfx uniform.
+func (u *Uniform[T]) Handle() bgfx.Uniform {
+	return u.handle
+}
+
+// Set sets the first len(values) elements for the next draw. It panics
+// if there are more values than the uniform has elements.
+func (u *Uniform[T]) Set(values ...T) {
+	u.SetTo(Default, values...)
+}
+
+// SetTo is like Set, but sets the uniform on r. The values are copied,
+// so renderers that keep the pointer until later, such as a render
+// queue, see them as they were when SetTo was called, even if values is
+// a slice the caller goes on to change.
+func (u *Uniform[T]) SetTo(r Renderer, values ...T) {
+	if len(values) == 0 || len(values) > u.Num {
+		panic(fmt.Sprintf("render: uniform %s: %d values for %d elements", u.Name, len(values), u.Num))
+	}
+	values = append([]T(nil), values...)
+	r.SetUniform(u.handle, &values[0], len(values))
+}
+
+// Destroy destroys the uniform.
+func (u *Uniform[T]) Destroy() {
+	bgfx.DestroyUniform(u.handle)
+}
+
+// uniformType returns the uniform type and number of elements for a Go
+// type. A float32 is a float, as shaders' uniform tables have it, and
+// arrays of vectors and matrices are arrays of elements; [9] and [16]
+// float32 arrays are taken to be matrices, and other float32 arrays
+// float arrays.
+func uniformType(t reflect.Type) (bgfx.UniformType, int, bool) {
+	if t.Kind() == reflect.Float32 {
+		return bgfx.Uniform1f, 1, true
+	}
+	if t.Kind() != reflect.Array {
+		return 0, 0, false
+	}
+	if t.Elem().Kind() == reflect.Float32 {
+		switch t.Len() {
+		case 3:
+			return bgfx.Uniform3fv, 1, true
+		case 4:
+			return bgfx.Uniform4fv, 1, true
+		case 9:
+			return bgfx.Uniform3x3fv, 1, true
+		case 16:
+			return bgfx.Uniform4x4fv, 1, true
+		}
+		return bgfx.Uniform1fv, t.Len(), true
+	}
+	typ, n, ok := uniformType(t.Elem())
+	if !ok || n != 1 {
+		return 0, 0, false
+	}
+	return typ, t.Len(), true
+}
+
+// Block is a set of uniforms described by the struct type T. Each field
+// with a `uniform:"name"` tag is a uniform with that name; its type and
+// number of elements follow from the field's type, as for Uniform, so
+// that
+//
+//	struct {
+//		PosRadius [4][4]float32 `uniform:"u_lightPosRadius"`
+//	}
+//
+// is a vec4 array of four elements.
+type Block[T any] struct {
+	fields []blockField
+}
+
+type blockField struct {
+	index  int
+	num    int
+	handle bgfx.Uniform
+}
+
+// NewBlock creates the uniforms of T. It panics if T is not a struct or
+// one of its tagged fields has no uniform type.
+func NewBlock[T any]() *Block[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("render: uniform block %v is not a struct", t))
+	}
+	b := new(Block[T])
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("uniform")
+		if name == "" {
+			continue
+		}
+		typ, num, ok := uniformType(f.Type)
+		if !ok {
+			b.Destroy()
+			panic(fmt.Sprintf("render: uniform block %v: field %s has no uniform type", t, f.Name))
+		}
+		b.fields = append(b.fields, blockField{
+			index:  i,
+			num:    num,
+			handle: bgfx.CreateUniform(name, typ, num),
+		})
+	}
+	return b
+}
+
+// Set sets every uniform in the block from v for the next draw.
+func (b *Block[T]) Set(v *T) {
+	b.SetTo(Default, v)
+}
+
+// SetTo is like Set, but sets the uniforms on r. The fields are passed
+// by pointer, so v must not change before r is done with them.
+func (b *Block[T]) SetTo(r Renderer, v *T) {
+	rv := reflect.ValueOf(v).Elem()
+	for _, f := range b.fields {
+		r.SetUniform(f.handle, rv.Field(f.index).Addr().Interface(), f.num)
+	}
+}
+
+// Destroy destroys the block's uniforms.
+func (b *Block[T]) Destroy() {
+	for _, f := range b.fields {
+		bgfx.DestroyUniform(f.handle)
+	}
+	b.fields = nil
+}
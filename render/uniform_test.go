@@ -0,0 +1,56 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+)
+
+func TestUniformType(t *testing.T) {
+	for _, tt := range []struct {
+		v   interface{}
+		typ bgfx.UniformType
+		num int
+	}{
+		{float32(0), bgfx.Uniform1f, 1},
+		{[2]float32{}, bgfx.Uniform1fv, 2},
+		{[3]float32{}, bgfx.Uniform3fv, 1},
+		{[4]float32{}, bgfx.Uniform4fv, 1},
+		{[9]float32{}, bgfx.Uniform3x3fv, 1},
+		{[16]float32{}, bgfx.Uniform4x4fv, 1},
+		{[8][4]float32{}, bgfx.Uniform4fv, 8},
+		{[2][16]float32{}, bgfx.Uniform4x4fv, 2},
+	} {
+		typ, num, ok := uniformType(reflect.TypeOf(tt.v))
+		if !ok || typ != tt.typ || num != tt.num {
+			t.Errorf("%T: got %v, %d, %v; want %v, %d", tt.v, typ, num, ok, tt.typ, tt.num)
+		}
+	}
+	for _, v := range []interface{}{0, float64(0), [4]int{}, [2][2]float32{}} {
+		if _, _, ok := uniformType(reflect.TypeOf(v)); ok {
+			t.Errorf("%T has a uniform type", v)
+		}
+	}
+}
+
+// keeper keeps the pointers it is given, as a render queue does.
+type keeper struct {
+	Recorder
+	ptrs []interface{}
+}
+
+func (k *keeper) SetUniform(u bgfx.Uniform, ptr interface{}, num int) {
+	k.ptrs = append(k.ptrs, ptr)
+}
+
+func TestUniformSetCopies(t *testing.T) {
+	u := &Uniform[[4]float32]{Name: "u_test", Num: 2}
+	var k keeper
+	values := [][4]float32{{1, 2, 3, 4}, {5, 6, 7, 8}}
+	u.SetTo(&k, values...)
+	values[0][0] = 0
+	if got := k.ptrs[0].(*[4]float32); got[0] != 1 {
+		t.Errorf("kept value changed with the caller's slice: %v", *got)
+	}
+}
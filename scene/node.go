@@ -0,0 +1,178 @@
+/*
+Package scene is a simple scene graph: a hierarchy of nodes with
+transforms, some of which draw a mesh, that can be culled against a
+camera and submitted to a view in one call.
+*/
+package scene
+
+import (
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"j4k.co/cgm/mat4"
+)
+
+// Texture is a texture bound to a sampler stage when a node is drawn.
+type Texture struct {
+	Stage   uint8
+	Sampler bgfx.Uniform
+	Texture bgfx.Texture
+}
+
+// Uniform is a uniform value set when a node is drawn. Value is a
+// pointer, as for bgfx.SetUniform.
+type Uniform struct {
+	Uniform bgfx.Uniform
+	Value   interface{}
+	Num     int
+}
+
+// Node is a transform in the hierarchy, optionally drawing a mesh. Its
+// world transform is its parent's world transform times its local
+// transform, and is only recomputed after it or one of its ancestors
+// has changed.
+type Node struct {
+	Name string
+
+	// Drawing; a node without a mesh only groups its children. If
+	// Material is set, its program and state are used instead of
+	// Program and State, and it is applied before Textures and
+	// Uniforms.
+	Mesh     *assets.Mesh
+	Material *assets.Material
+	Program  bgfx.Program
+	Textures []Texture
+	Uniforms []Uniform
+	State    bgfx.State // 0 uses the mesh default
+	Hidden   bool       // skip this node and its children
+
+	local, world [16]float32
+	dirty        bool
+	parent       *Node
+	children     []*Node
+}
+
+// NewNode returns a node with an identity transform.
+func NewNode(name string) *Node {
+	return &Node{
+		Name:  name,
+		local: mat4.Identity(),
+		dirty: true,
+	}
+}
+
+// NewMeshNode returns a node drawing mesh with prog.
+func NewMeshNode(name string, mesh *assets.Mesh, prog bgfx.Program) *Node {
+	n := NewNode(name)
+	n.Mesh = mesh
+	n.Program = prog
+	return n
+}
+
+// NewMaterialNode returns a node drawing mesh with mat.
+func NewMaterialNode(name string, mesh *assets.Mesh, mat *assets.Material) *Node {
+	n := NewNode(name)
+	n.Mesh = mesh
+	n.Material = mat
+	return n
+}
+
+// Local returns the transform relative to the parent.
+func (n *Node) Local() [16]float32 {
+	return n.local
+}
+
+// SetLocal sets the transform relative to the parent.
+func (n *Node) SetLocal(mtx [16]float32) {
+	n.local = mtx
+	n.markDirty()
+}
+
+// SetTranslation sets just the translation of the local transform.
+func (n *Node) SetTranslation(x, y, z float32) {
+	n.local[12] = x
+	n.local[13] = y
+	n.local[14] = z
+	n.markDirty()
+}
+
+// World returns the transform relative to the root.
+func (n *Node) World() [16]float32 {
+	if n.dirty {
+		if n.parent != nil {
+			n.world = mat4.Mul(n.parent.World(), n.local)
+		} else {
+			n.world = n.local
+		}
+		n.dirty = false
+	}
+	return n.world
+}
+
+// markDirty marks n and its descendants as needing their world
+// transforms recomputed. A dirty node's descendants are always dirty
+// too, so there is no need to go further down from one.
+func (n *Node) markDirty() {
+	if n.dirty {
+		return
+	}
+	n.dirty = true
+	for _, c := range n.children {
+		c.markDirty()
+	}
+}
+
+// Parent returns the node's parent, or nil for a root.
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Children returns the node's children. The slice must not be
+// modified.
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+// Add makes c a child of n, removing it from its previous parent.
+func (n *Node) Add(c *Node) {
+	if c.parent != nil {
+		c.parent.Remove(c)
+	}
+	c.parent = n
+	n.children = append(n.children, c)
+	c.markDirty()
+}
+
+// Remove removes c from n's children, making it a root.
+func (n *Node) Remove(c *Node) {
+	for i, child := range n.children {
+		if child != c {
+			continue
+		}
+		copy(n.children[i:], n.children[i+1:])
+		n.children[len(n.children)-1] = nil
+		n.children = n.children[:len(n.children)-1]
+		c.parent = nil
+		c.markDirty()
+		return
+	}
+}
+
+// RemoveAll removes all of n's children.
+func (n *Node) RemoveAll() {
+	for _, c := range n.children {
+		c.parent = nil
+		c.markDirty()
+	}
+	n.children = nil
+}
+
+// Walk calls fn for n and its descendants, depth first. If fn returns
+// false, the node's children are skipped.
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.children {
+		c.Walk(fn)
+	}
+}
@@ -0,0 +1,74 @@
+package scene
+
+import (
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
+	"github.com/james4k/go-bgfx-examples/render"
+)
+
+// Stats counts what the last Submit did.
+type Stats struct {
+	Nodes  int // visited, excluding hidden ones
+	Meshes int // mesh nodes tested against the frustum
+	assets.CullStats
+}
+
+// Scene is a node hierarchy submitted as a whole.
+type Scene struct {
+	Root     *Node
+	Renderer render.Renderer // nil uses render.Default
+	Stats    Stats
+}
+
+// New returns an empty scene.
+func New() *Scene {
+	return &Scene{Root: NewNode("root")}
+}
+
+// Submit draws every visible mesh node that is inside the camera's
+// frustum to view.
+func (s *Scene) Submit(view bgfx.ViewID, cam *camera.Camera) {
+	r := s.Renderer
+	if r == nil {
+		r = render.Default
+	}
+	s.Stats = Stats{}
+	viewProj := cam.ViewProj()
+	s.Root.Walk(func(n *Node) bool {
+		if n.Hidden {
+			return false
+		}
+		s.Stats.Nodes++
+		if n.Mesh == nil {
+			return true
+		}
+		s.Stats.Meshes++
+		prog, state := n.Program, n.State
+		if n.Material != nil {
+			prog, state = n.Material.Program, n.Material.State
+		}
+		n.Mesh.SubmitCulledTo(bindings{r, n}, view, prog, n.World(), state, viewProj, &s.Stats.CullStats)
+		return true
+	})
+}
+
+// bindings sets a node's material, textures and uniforms along with its program,
+// so that they are set for each of a mesh's draws.
+type bindings struct {
+	render.Renderer
+	node *Node
+}
+
+func (b bindings) SetProgram(prog bgfx.Program) {
+	b.Renderer.SetProgram(prog)
+	if b.node.Material != nil {
+		b.node.Material.ApplyTo(b.Renderer)
+	}
+	for _, t := range b.node.Textures {
+		b.Renderer.SetTexture(t.Stage, t.Sampler, t.Texture)
+	}
+	for _, u := range b.node.Uniforms {
+		b.Renderer.SetUniform(u.Uniform, u.Value, u.Num)
+	}
+}
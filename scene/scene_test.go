@@ -0,0 +1,185 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	"github.com/james4k/go-bgfx"
+	"github.com/james4k/go-bgfx-examples/assets"
+	"github.com/james4k/go-bgfx-examples/camera"
+	"github.com/james4k/go-bgfx-examples/example"
+	"github.com/james4k/go-bgfx-examples/render"
+	"j4k.co/cgm/mat4"
+)
+
+func translation(x, y, z float32) [16]float32 {
+	m := mat4.Identity()
+	m[12], m[13], m[14] = x, y, z
+	return m
+}
+
+// origin returns where n's world transform puts its local origin.
+func origin(n *Node) [3]float32 {
+	return mat4.Mul3(n.World(), [3]float32{})
+}
+
+func nearly(a, b [3]float32) bool {
+	for i := range a {
+		if math.Abs(float64(a[i]-b[i])) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWorldTransforms(t *testing.T) {
+	root := NewNode("root")
+	a := NewNode("a")
+	b := NewNode("b")
+	c := NewNode("c")
+	root.Add(a)
+	a.Add(b)
+	b.Add(c)
+	a.SetLocal(translation(1, 0, 0))
+	b.SetLocal(mat4.Scale(2, 2, 2))
+	c.SetTranslation(0, 1, 0)
+
+	tests := []struct {
+		node *Node
+		want [3]float32
+	}{
+		{root, [3]float32{0, 0, 0}},
+		{a, [3]float32{1, 0, 0}},
+		{b, [3]float32{1, 0, 0}},
+		{c, [3]float32{1, 2, 0}}, // scaled by b
+	}
+	for _, tt := range tests {
+		if got := origin(tt.node); !nearly(got, tt.want) {
+			t.Errorf("%s at %v, want %v", tt.node.Name, got, tt.want)
+		}
+	}
+}
+
+func TestDirtyPropagation(t *testing.T) {
+	tests := []struct {
+		name string
+		// prime computes some world transforms before the change, so
+		// that they are cached.
+		prime func(a, b, c *Node)
+	}{
+		{"nothing cached", func(a, b, c *Node) {}},
+		{"all cached", func(a, b, c *Node) { c.World() }},
+		{"only the ancestor cached", func(a, b, c *Node) { a.World() }},
+		{"ancestors cached, leaf not", func(a, b, c *Node) { b.World() }},
+	}
+	for _, tt := range tests {
+		a, b, c := NewNode("a"), NewNode("b"), NewNode("c")
+		a.Add(b)
+		b.Add(c)
+		c.SetTranslation(0, 0, 1)
+		tt.prime(a, b, c)
+		a.SetLocal(translation(5, 0, 0))
+		if got, want := origin(c), [3]float32{5, 0, 1}; !nearly(got, want) {
+			t.Errorf("%s: after moving the root, leaf at %v, want %v", tt.name, got, want)
+		}
+		b.SetTranslation(0, 3, 0)
+		if got, want := origin(c), [3]float32{5, 3, 1}; !nearly(got, want) {
+			t.Errorf("%s: after moving the middle, leaf at %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestReparent(t *testing.T) {
+	root := NewNode("root")
+	left, right := NewNode("left"), NewNode("right")
+	left.SetLocal(translation(-1, 0, 0))
+	right.SetLocal(translation(1, 0, 0))
+	root.Add(left)
+	root.Add(right)
+	n := NewNode("n")
+	n.SetTranslation(0, 1, 0)
+	left.Add(n)
+	if got := origin(n); !nearly(got, [3]float32{-1, 1, 0}) {
+		t.Errorf("under left at %v", got)
+	}
+
+	right.Add(n)
+	if n.Parent() != right || len(left.Children()) != 0 || len(right.Children()) != 1 {
+		t.Errorf("after moving: parent %s, %d and %d children",
+			n.Parent().Name, len(left.Children()), len(right.Children()))
+	}
+	if got := origin(n); !nearly(got, [3]float32{1, 1, 0}) {
+		t.Errorf("under right at %v", got)
+	}
+
+	right.Remove(n)
+	if n.Parent() != nil || len(right.Children()) != 0 {
+		t.Errorf("after removing: parent %v, %d children", n.Parent(), len(right.Children()))
+	}
+	if got := origin(n); !nearly(got, [3]float32{0, 1, 0}) {
+		t.Errorf("as a root at %v", got)
+	}
+
+	right.Add(n)
+	root.RemoveAll()
+	if len(root.Children()) != 0 || left.Parent() != nil || right.Parent() != nil {
+		t.Errorf("RemoveAll left %d children", len(root.Children()))
+	}
+	// right is a root now, still at 1, 0, 0.
+	if got := origin(n); !nearly(got, [3]float32{1, 1, 0}) {
+		t.Errorf("under a removed node at %v", got)
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	mesh := assets.NewMesh(bgfx.VertexBuffer{}, bgfx.IndexBuffer{}, assets.Bounds{
+		Sphere: assets.Sphere{Radius: 1},
+		AABB:   assets.AABB{Min: [3]float32{-1, -1, -1}, Max: [3]float32{1, 1, 1}},
+	})
+	var r render.Recorder
+	s := New()
+	s.Renderer = &r
+	var (
+		group   = NewNode("group")
+		front   = NewMeshNode("front", &mesh, bgfx.Program{})
+		behind  = NewMeshNode("behind", &mesh, bgfx.Program{})
+		hidden  = NewNode("hidden")
+		inside  = NewMeshNode("inside hidden", &mesh, bgfx.Program{})
+		farSide = NewMeshNode("far side", &mesh, bgfx.Program{})
+	)
+	front.SetTranslation(0, 0, 10)
+	behind.SetTranslation(0, 0, -10)
+	farSide.SetTranslation(0, 0, 200)
+	inside.SetTranslation(0, 0, 10)
+	hidden.Hidden = true
+	s.Root.Add(group)
+	group.Add(front)
+	group.Add(behind)
+	group.Add(farSide)
+	s.Root.Add(hidden)
+	hidden.Add(inside)
+
+	cam := camera.New([3]float32{0, 0, 0}, [3]float32{0, 0, 1}, [3]float32{0, 1, 0})
+	cam.Update(&example.Application{Width: 1280, Height: 720})
+	s.Submit(3, cam)
+
+	want := Stats{Nodes: 5, Meshes: 3}
+	want.Groups, want.GroupsCulled = 3, 2
+	if s.Stats != want {
+		t.Errorf("stats %+v, want %+v", s.Stats, want)
+	}
+	if len(r.Draws) != 1 {
+		t.Fatalf("%d draws, want 1", len(r.Draws))
+	}
+	if d := r.Draws[0]; d.View != 3 || d.Transform != front.World() {
+		t.Errorf("draw to view %d with transform %v, want the front node's", d.View, d.Transform)
+	}
+
+	// Stats are for the last Submit only.
+	r.Reset()
+	group.Hidden = true
+	s.Submit(3, cam)
+	if want := (Stats{Nodes: 1}); s.Stats != want || len(r.Draws) != 0 {
+		t.Errorf("with everything hidden, stats %+v and %d draws", s.Stats, len(r.Draws))
+	}
+}